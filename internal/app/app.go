@@ -23,12 +23,24 @@ func Run() {
 
 	//init sqlite
 	db, err := sqlite.NewSQLite(&cfg.SQLite)
+	if err != nil {
+		log.Fatal("Error opening database: ", err)
+	}
 
 	//init service
 	r := service.NewTaskRepo(db)
 
+	//convert priorities if the priority scheme changed
+	n, err := r.MigratePriorities(cfg.Priority)
+	if err != nil {
+		log.Fatal("Error migrating priorities: ", err)
+	}
+	if n > 0 {
+		log.Printf("Priority scheme changed, converted priorities of %d task(s)", n)
+	}
+
 	//init cobra
-	c := cobra.NewCmd(r)
+	c := cobra.NewCmd(r, cfg)
 
 	//execute command
 	c.Execute()
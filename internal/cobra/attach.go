@@ -0,0 +1,311 @@
+package cobra
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"time"
+
+	"github.com/EvoSched/gotask/internal/types"
+	"github.com/spf13/cobra"
+)
+
+func (c *Cmd) AttachCmd() *cobra.Command {
+	var cp, refOnly bool
+	attachCmd := &cobra.Command{
+		Use:         "attach",
+		Annotations: changesTasks,
+		Short:       "Attach a file or URL to a task by ID",
+		Long: `Attaches a file or URL to a given task provided the task id. Files are stored as a reference to their path
+unless copying is enabled in config or requested with --copy, in which case their contents are saved in the database.
+
+Required:
+- id    Id referencing task.
+- path  Path of a file, or a URL.`,
+		Example: `gt attach 1 ./docs/design.md
+gt attach 1 ./logs/deploy.log --copy
+gt attach 2 https://github.com/EvoSched/gotask/pull/12`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			ref, p, err := parseAttach(args)
+			if err != nil {
+				fatal(err)
+			}
+			id, err := c.resolveID(ref)
+			if err != nil {
+				log.Fatal(err)
+			}
+			t, err := c.repo.GetTask(id)
+			if err != nil {
+				log.Fatal(err)
+			}
+			now := time.Now()
+			a := &types.Attachment{TaskID: id, CreatedAt: &now}
+			if isURL(p) {
+				a.Kind = types.AttachURL
+				a.Name = p
+			} else {
+				a.Name, err = filepath.Abs(p)
+				if err != nil {
+					log.Fatal(err)
+				}
+				fi, err := os.Stat(a.Name)
+				if err != nil {
+					log.Fatal(err)
+				}
+				if fi.IsDir() {
+					log.Fatalf("cannot attach a directory: %s", p)
+				}
+				if fi.Size() > c.cfg.Attachments.MaxSize {
+					log.Fatalf("file exceeds attachment size limit of %s: %s", formatSize(c.cfg.Attachments.MaxSize), p)
+				}
+				a.Size = fi.Size()
+				a.Kind = types.AttachFile
+				if (c.cfg.Attachments.Copy || cp) && !refOnly {
+					a.Kind = types.AttachCopy
+					a.Data, err = os.ReadFile(a.Name)
+					if err != nil {
+						log.Fatal(err)
+					}
+					a.Name = filepath.Base(a.Name)
+				}
+			}
+			err = c.repo.AddAttachment(a)
+			if err != nil {
+				log.Fatal(err)
+			}
+			c.printf("Task %d '%s' has been updated with a new attachment:\n", t.ShortID, t.Desc)
+			c.printf("  - %s\n", formatAttachment(*a))
+			c.println("1 task updated with an attachment.")
+			c.changedTasks(t)
+		},
+	}
+	attachCmd.Flags().BoolVar(&cp, "copy", false, "copy the file into the database")
+	attachCmd.Flags().BoolVar(&refOnly, "ref", false, "store only a reference to the file")
+	attachCmd.MarkFlagsMutuallyExclusive("copy", "ref")
+	return attachCmd
+}
+
+func (c *Cmd) OpenCmd() *cobra.Command {
+	var printOnly bool
+	openCmd := &cobra.Command{
+		Use:   "open",
+		Short: "Open a task attachment",
+		Long: `Opens an attachment of a given task with the system opener. Attachments are numbered in the order shown by 'gt get'.
+Copied files are written to the user's cache directory to be opened, and removed when not opened for a week.
+
+Required:
+- id  Id referencing task.
+
+Optional:
+- n   Number of the attachment, defaults to 1.`,
+		Example: "gt open 1\ngt open 1 2\ngt open 1 2 --print",
+		Args:    cobra.RangeArgs(1, 2),
+		Run: func(cmd *cobra.Command, args []string) {
+			ref, n, err := parseItemRef(args)
+			if err != nil {
+				fatal(err)
+			}
+			_, a, err := c.attachment(ref, n)
+			if err != nil {
+				log.Fatal(err)
+			}
+			if a.Kind == types.AttachCopy {
+				err = c.repo.GetAttachmentData(a)
+				if err != nil {
+					log.Fatal(err)
+				}
+			}
+			if printOnly {
+				if a.Kind == types.AttachCopy {
+					_, err = os.Stdout.Write(a.Data)
+					if err != nil {
+						log.Fatal(err)
+					}
+				} else {
+					c.println(a.Name)
+				}
+				return
+			}
+			target := a.Name
+			if a.Kind == types.AttachCopy {
+				// copies only exist in the database, so hand the opener a file in the cache
+				target, err = cacheAttachment(a)
+				if err != nil {
+					log.Fatal(err)
+				}
+			}
+			err = c.opener(target).Start()
+			if err != nil {
+				log.Fatal(err)
+			}
+			c.printf("Opened %s\n", target)
+		},
+	}
+	openCmd.Flags().BoolVarP(&printOnly, "print", "p", false, "print the location, or the contents of copied files, instead of opening")
+	return openCmd
+}
+
+func (c *Cmd) DetachCmd() *cobra.Command {
+	detachCmd := &cobra.Command{
+		Use:         "detach",
+		Annotations: changesTasks,
+		Short:       "Remove an attachment from a task",
+		Long: `Removes an attachment from a given task. Attachments are numbered in the order shown by 'gt get'.
+Files that were only referenced are left untouched on disk.
+
+Required:
+- id  Id referencing task.
+- n   Number of the attachment.`,
+		Example: "gt detach 1 2",
+		Args:    cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			ref, n, err := parseItemRef(args)
+			if err != nil {
+				fatal(err)
+			}
+			t, a, err := c.attachment(ref, n)
+			if err != nil {
+				log.Fatal(err)
+			}
+			err = c.repo.DeleteAttachment(a.ID)
+			if err != nil {
+				log.Fatal(err)
+			}
+			removeCachedAttachment(a.ID)
+			c.printf("Removed attachment %d from task %d:\n", n, t.ShortID)
+			c.printf("  - %s\n", formatAttachment(*a))
+			c.changedTasks(t)
+		},
+	}
+	return detachCmd
+}
+
+// attachmentCacheTTL is how long a copied attachment written out to be opened is kept after
+// it was last opened
+const attachmentCacheTTL = 7 * 24 * time.Hour
+
+// attachmentCacheDir returns the directory an attachment is written to when opened, under the
+// user's cache directory
+func attachmentCacheDir(id int) (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gotask", "attachments", strconv.Itoa(id)), nil
+}
+
+// cacheAttachment writes a copied attachment to the cache and returns its path. Opening it again
+// reuses the file while it keeps the size and the modification time it was written with, which is
+// when the attachment was added; files not opened for attachmentCacheTTL are removed.
+func cacheAttachment(a *types.Attachment) (string, error) {
+	dir, err := attachmentCacheDir(a.ID)
+	if err != nil {
+		return "", err
+	}
+	pruneCache(filepath.Dir(dir), time.Now().Add(-attachmentCacheTTL))
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+	p := filepath.Join(dir, filepath.Base(a.Name))
+	// file systems keep modification times to a second or better
+	fi, err := os.Stat(p)
+	cached := err == nil && a.CreatedAt != nil && fi.Size() == int64(len(a.Data)) &&
+		fi.ModTime().Truncate(time.Second).Equal(a.CreatedAt.Truncate(time.Second))
+	if !cached {
+		if err := os.WriteFile(p, a.Data, 0o600); err != nil {
+			return "", err
+		}
+		if a.CreatedAt != nil {
+			if err := os.Chtimes(p, *a.CreatedAt, *a.CreatedAt); err != nil {
+				return "", err
+			}
+		}
+	}
+	now := time.Now()
+	return p, os.Chtimes(dir, now, now)
+}
+
+// removeCachedAttachment removes an attachment written to the cache, once it's detached or its
+// task deleted
+func removeCachedAttachment(id int) {
+	if dir, err := attachmentCacheDir(id); err == nil {
+		os.RemoveAll(dir)
+	}
+}
+
+// pruneCache removes the entries of a cache directory last changed before cutoff
+func pruneCache(root string, cutoff time.Time) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		if fi, err := e.Info(); err == nil && fi.ModTime().Before(cutoff) {
+			os.RemoveAll(filepath.Join(root, e.Name()))
+		}
+	}
+}
+
+// attachment returns the referenced task and its n-th (1-based) attachment
+func (c *Cmd) attachment(ref string, n int) (*types.Task, *types.Attachment, error) {
+	id, err := c.resolveID(ref)
+	if err != nil {
+		return nil, nil, err
+	}
+	t, err := c.repo.GetTask(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if n > len(t.Attachments) {
+		return nil, nil, fmt.Errorf("task %d has %d attachment(s), no attachment %d", t.ShortID, len(t.Attachments), n)
+	}
+	return t, &t.Attachments[n-1], nil
+}
+
+// opener returns the command that opens target with the configured or system opener
+func (c *Cmd) opener(target string) *exec.Cmd {
+	if c.cfg.Attachments.Opener != "" {
+		return exec.Command(c.cfg.Attachments.Opener, target)
+	}
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.Command("open", target)
+	case "windows":
+		return exec.Command("rundll32", "url.dll,FileProtocolHandler", target)
+	default:
+		return exec.Command("xdg-open", target)
+	}
+}
+
+func isURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && u.Scheme != "" && u.Host != ""
+}
+
+func formatAttachment(a types.Attachment) string {
+	switch a.Kind {
+	case types.AttachURL:
+		return a.Name
+	case types.AttachCopy:
+		return fmt.Sprintf("%s (copy, %s)", a.Name, formatSize(a.Size))
+	default:
+		return fmt.Sprintf("%s (%s)", a.Name, formatSize(a.Size))
+	}
+}
+
+func formatSize(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
@@ -0,0 +1,56 @@
+package cobra
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/EvoSched/gotask/internal/types"
+)
+
+func TestCacheAttachment(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", dir)
+	t.Setenv("HOME", dir)
+	t.Setenv("LocalAppData", dir)
+	added := time.Date(2024, time.October, 14, 10, 30, 0, 0, time.UTC)
+	a := &types.Attachment{ID: 3, Kind: types.AttachCopy, Name: "notes.txt", Data: []byte("first"), CreatedAt: &added}
+
+	read := func(p string) string {
+		t.Helper()
+		b, err := os.ReadFile(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+	p, err := cacheAttachment(a)
+	if err != nil || read(p) != "first" || filepath.Base(p) != "notes.txt" {
+		t.Fatalf("cacheAttachment = %q, %v", p, err)
+	}
+
+	// a file with the size and time it was written with is reused
+	if err := os.WriteFile(p, []byte("FIRST"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(p, added, added); err != nil {
+		t.Fatal(err)
+	}
+	if p, err = cacheAttachment(a); err != nil || read(p) != "FIRST" {
+		t.Errorf("cacheAttachment rewrote an unchanged file: %v", err)
+	}
+
+	// a changed file is written again
+	if err := os.WriteFile(p, []byte("changed"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if p, err = cacheAttachment(a); err != nil || read(p) != "first" {
+		t.Errorf("cacheAttachment kept a changed file: %v", err)
+	}
+
+	removeCachedAttachment(a.ID)
+	if _, err := os.Stat(filepath.Dir(p)); !os.IsNotExist(err) {
+		t.Errorf("removeCachedAttachment left %s: %v", filepath.Dir(p), err)
+	}
+}
@@ -0,0 +1,129 @@
+package cobra
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/EvoSched/gotask/internal/types"
+)
+
+// selectTasks returns the tasks selected by a command's arguments, which are either task
+// references (IDs or UUID prefixes) or a filter. byFilter reports which one was used.
+func (c *Cmd) selectTasks(args []string) (tasks []*types.Task, byFilter bool, err error) {
+	if len(args) == 0 {
+		return nil, false, errors.New("no tasks selected, expected IDs or a filter")
+	}
+	refs, err := parseGet(args)
+	if err == nil {
+		ids, err := c.resolveIDs(refs)
+		if err != nil {
+			return nil, false, err
+		}
+		for _, i := range ids {
+			t, err := c.repo.GetTask(i)
+			if err != nil {
+				return nil, false, err
+			}
+			tasks = append(tasks, t)
+		}
+		return tasks, false, nil
+	}
+	if slices.IndexFunc(args, func(a string) bool { return !idList.MatchString(a) }) < 0 {
+		// only IDs, so a mistake like '7-3' is reported as such rather than as a filter
+		return nil, false, err
+	}
+
+	f, err := parseFilter(args)
+	if err != nil {
+		return nil, true, err
+	}
+	found, err := c.repo.FindTasks(f)
+	if err != nil {
+		return nil, true, err
+	}
+	// listings don't load everything a change may need, e.g. reminders and attachments
+	for _, ft := range found {
+		t, err := c.repo.GetTask(ft.ID)
+		if err != nil {
+			return nil, true, err
+		}
+		tasks = append(tasks, t)
+	}
+	return tasks, true, nil
+}
+
+// idList matches an argument made of IDs, commas and dashes only
+var idList = regexp.MustCompile(`^[0-9,-]+$`)
+
+// splitMods splits 'mod' arguments at '--' into the task selection and the changes
+func splitMods(args []string) (sel []string, mods []string, ok bool) {
+	i := slices.Index(args, "--")
+	if i < 0 {
+		return nil, nil, false
+	}
+	return args[:i], args[i+1:], true
+}
+
+// confirm asks a yes/no question on stdin; anything but an answer starting with 'y' is a no
+func (c *Cmd) confirm(question string) bool {
+	c.printf("\n%s (y/n): ", question)
+	s, err := bufio.NewReader(c.answers()).ReadString('\n')
+	if err != nil && s == "" {
+		c.println()
+		return false
+	}
+	s = strings.TrimSpace(s)
+	return len(s) > 0 && (s[0] == 'y' || s[0] == 'Y')
+}
+
+// answers returns where answers to questions are read, stdin unless set
+func (c *Cmd) answers() io.Reader {
+	if c.in == nil {
+		return os.Stdin
+	}
+	return c.in
+}
+
+// pickTask asks on stdin which of several tasks is meant. When stdin isn't a terminal, e.g. in
+// a script, the candidates are returned in an error instead.
+func (c *Cmd) pickTask(question string, tasks []*types.Task) (*types.Task, error) {
+	var b strings.Builder
+	for i, t := range tasks {
+		status := ""
+		if t.Finished {
+			status = " (finished)"
+		}
+		fmt.Fprintf(&b, "\n  %d) Task %d: '%s'%s", i+1, t.ShortID, t.Desc, status)
+	}
+	if !isTerminal(os.Stdin) {
+		return nil, fmt.Errorf("%s, use an ID instead:%s", question, b.String())
+	}
+	c.printf("%s:%s\n\nPick one (1-%d): ", question, b.String(), len(tasks))
+	s, _ := bufio.NewReader(c.answers()).ReadString('\n')
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || n < 1 || n > len(tasks) {
+		return nil, errors.New("no task picked")
+	}
+	return tasks[n-1], nil
+}
+
+// isTerminal reports whether f is a terminal rather than a file or pipe
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// plural returns "1 task" or "n tasks"
+func plural(n int) string {
+	if n == 1 {
+		return "1 task"
+	}
+	return fmt.Sprintf("%d tasks", n)
+}
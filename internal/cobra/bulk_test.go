@@ -0,0 +1,133 @@
+package cobra
+
+import (
+	"bytes"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/EvoSched/gotask/internal/config"
+	"github.com/EvoSched/gotask/internal/service"
+	"github.com/EvoSched/gotask/internal/sqlite"
+	"github.com/EvoSched/gotask/internal/types"
+)
+
+func TestSplitMods(t *testing.T) {
+	tests := []struct {
+		args      []string
+		sel, mods string
+		ok        bool
+	}{
+		{[]string{"+work", "--", "%1"}, "+work", "%1", true},
+		{[]string{"3-5", "7", "--", "+late", "@", "fri"}, "3-5 7", "+late @ fri", true},
+		{[]string{"+work", "-home", "--"}, "+work -home", "", true},
+		{[]string{"+work", "--", "Use", "--", "literally"}, "+work", "Use -- literally", true},
+		{[]string{"5", "+late"}, "", "", false},
+	}
+	for _, tt := range tests {
+		sel, mods, ok := splitMods(tt.args)
+		if ok != tt.ok || strings.Join(sel, " ") != tt.sel || strings.Join(mods, " ") != tt.mods {
+			t.Errorf("splitMods(%q) = %q, %q, %v, want %q, %q, %v", tt.args, sel, mods, ok, tt.sel, tt.mods, tt.ok)
+		}
+	}
+}
+
+// testCmd returns a command on a new database holding tasks, numbered from 1 in order
+func testCmd(t *testing.T, tasks ...*types.Task) *Cmd {
+	t.Helper()
+	db, err := sqlite.NewSQLite(&config.SQLite{Database: filepath.Join(t.TempDir(), "t.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	c := &Cmd{repo: service.NewTaskRepo(db), cfg: &config.Config{}}
+	for _, task := range tasks {
+		if _, err := c.repo.AddTask(task); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return c
+}
+
+// workTasks are the tasks of testCmd in most tests
+func workTasks() []*types.Task {
+	return []*types.Task{
+		types.NewTask("Write report", 3, []string{"work"}, nil, nil, nil),
+		types.NewTask("Water plants", 3, []string{"home"}, nil, nil, nil),
+		types.NewTask("Plan sprint", 3, []string{"work"}, nil, nil, nil),
+	}
+}
+
+func TestSelectTasks(t *testing.T) {
+	c := testCmd(t, workTasks()...)
+
+	tests := []struct {
+		args     []string
+		ids      []int
+		byFilter bool
+	}{
+		{[]string{"1", "3"}, []int{1, 3}, false},
+		{[]string{"1-2"}, []int{1, 2}, false},
+		{[]string{"2,3"}, []int{2, 3}, false},
+		{[]string{"+work"}, []int{1, 3}, true},
+		{[]string{"-work"}, []int{2}, true},
+		{[]string{"desc~^Plan"}, []int{3}, true},
+	}
+	for _, tt := range tests {
+		tasks, byFilter, err := c.selectTasks(tt.args)
+		if err != nil {
+			t.Errorf("selectTasks(%q) error: %v", tt.args, err)
+			continue
+		}
+		var ids []int
+		for _, task := range tasks {
+			ids = append(ids, task.ShortID)
+		}
+		slices.Sort(ids)
+		if !slices.Equal(ids, tt.ids) || byFilter != tt.byFilter {
+			t.Errorf("selectTasks(%q) = %v, by filter %v, want %v, %v", tt.args, ids, byFilter, tt.ids, tt.byFilter)
+		}
+	}
+
+	// a mistyped list of IDs is reported as such, not read as a filter
+	for _, args := range [][]string{{"7-3"}, {"1", "9"}, {}} {
+		if _, byFilter, err := c.selectTasks(args); err == nil || byFilter {
+			t.Errorf("selectTasks(%q) = by filter %v, %v, want an error about IDs", args, byFilter, err)
+		}
+	}
+}
+
+func TestModConfirm(t *testing.T) {
+	tests := []struct {
+		confirm int
+		answer  string
+		prompt  bool
+		changed bool
+	}{
+		{2, "", false, true}, // two tasks at a threshold of two apply without asking
+		{1, "y\n", true, true},
+		{1, "n\n", true, false},
+		{1, "", true, false}, // no answer is a no
+	}
+	for _, tt := range tests {
+		c := testCmd(t, workTasks()...)
+		c.cfg.Bulk.Confirm = tt.confirm
+		c.color = "auto"
+		var out bytes.Buffer
+		c.in, c.out = strings.NewReader(tt.answer), &out
+		cmd := c.ModCmd()
+		cmd.Run(cmd, []string{"+work", "--", "%1"})
+
+		if prompt := strings.Contains(out.String(), "(y/n)"); prompt != tt.prompt {
+			t.Errorf("confirm %d: asked %v, want %v:\n%s", tt.confirm, prompt, tt.prompt, out.String())
+		}
+		tasks, _, err := c.selectTasks([]string{"%1"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if changed := len(tasks) == 2; changed != tt.changed {
+			t.Errorf("confirm %d, answer %q: changed %v, want %v", tt.confirm, tt.answer, changed, tt.changed)
+		}
+	}
+}
@@ -0,0 +1,363 @@
+package cobra
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/EvoSched/gotask/internal/types"
+	"github.com/spf13/cobra"
+)
+
+func (c *Cmd) CalCmd() *cobra.Command {
+	var flags func([]string) ([]string, bool)
+	var week bool
+	var next, prev int
+	var month string
+	calCmd := &cobra.Command{
+		Use:         "cal [filter]",
+		Annotations: readsTasks,
+		Short:       "Show tasks on a month or week calendar",
+		Long: `Shows a month with the number of tasks on each day, or with --week a week with the timed tasks in hourly
+slots. Days with overdue tasks are marked '!' and days with tasks of the highest priority '*'. Tasks spanning
+several days are counted on each. Weeks start on the day set by locale.week_start.`,
+		Example: "gt cal\ngt cal --next\ngt cal --month 2024-12 +work\ngt cal --week --prev 2",
+		Run: func(cmd *cobra.Command, args []string) {
+			args, help := flags(args)
+			if help {
+				return
+			}
+			f, err := parseFilter(args)
+			if err != nil {
+				fatal(err)
+			}
+			// task times are wall-clock times in UTC, so the calendar's days are too
+			anchor := wallNow()
+			if month != "" {
+				m, err := time.Parse("2006-01", month)
+				if err != nil {
+					log.Fatalf("invalid month %q, expected YYYY-MM (e.g. 2024-12)", month)
+				}
+				anchor = m
+			}
+
+			var from, to time.Time
+			if week {
+				from = startOfDay(anchor)
+				from = from.AddDate(0, 0, -int(from.Weekday()-weekStart+7)%7)
+				from = from.AddDate(0, 0, 7*(next-prev))
+				to = from.AddDate(0, 0, 7)
+			} else {
+				from = time.Date(anchor.Year(), anchor.Month()+time.Month(next-prev), 1, 0, 0, 0, 0, anchor.Location())
+				to = from.AddDate(0, 1, 0)
+			}
+
+			tasks, err := c.repo.FindTasks(f)
+			if err != nil {
+				log.Fatal(err)
+			}
+			var shown []*types.Task
+			for _, t := range tasks {
+				if s, e, ok := taskDays(t); ok && s.Before(to) && !e.Before(from) {
+					shown = append(shown, t)
+				}
+			}
+			if c.stdout != nil {
+				c.writeTasks(shown)
+				return
+			}
+			width, _, _ := screenSize()
+			if week {
+				c.print(renderWeek(from, shown, width, c.newTaskStyles()))
+			} else {
+				c.print(renderMonth(from, shown, c.newTaskStyles()))
+			}
+		},
+	}
+	calCmd.Flags().BoolVar(&week, "week", false, "show a week with time slots instead of a month")
+	calCmd.Flags().IntVar(&next, "next", 0, "show the month or week after, or n after with --next=n")
+	calCmd.Flags().IntVar(&prev, "prev", 0, "show the month or week before, or n before with --prev=n")
+	calCmd.Flags().StringVar(&month, "month", "", "show a month given as YYYY-MM, or its first week with --week")
+	calCmd.Flags().Lookup("next").NoOptDefVal = "1"
+	calCmd.Flags().Lookup("prev").NoOptDefVal = "1"
+	flags = c.filterCmd(calCmd)
+	return calCmd
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// taskDays returns the first and last day a task covers, false for tasks without a time
+func taskDays(t *types.Task) (time.Time, time.Time, bool) {
+	if t.StartAt == nil {
+		return time.Time{}, time.Time{}, false
+	}
+	start := startOfDay(*t.StartAt)
+	end := start
+	if t.EndAt != nil && t.EndAt.After(*t.StartAt) {
+		end = startOfDay(*t.EndAt)
+	}
+	return start, end, true
+}
+
+// calDay is what a calendar shows of a day
+type calDay struct {
+	tasks   []*types.Task
+	overdue bool // an open task on the day is past its time
+	urgent  bool // a task on the day has the highest priority
+}
+
+// calendarDays collects the tasks on each day from from, up to but not including to, by date
+// as 2006-01-02
+func calendarDays(tasks []*types.Task, from, to time.Time) map[string]*calDay {
+	days := make(map[string]*calDay)
+	now := wallNow()
+	for _, t := range tasks {
+		start, end, ok := taskDays(t)
+		if !ok {
+			continue
+		}
+		due := *t.StartAt
+		if t.EndAt != nil {
+			due = *t.EndAt
+		}
+		for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+			if d.Before(from) || !d.Before(to) {
+				continue
+			}
+			cd := days[d.Format(time.DateOnly)]
+			if cd == nil {
+				cd = &calDay{}
+				days[d.Format(time.DateOnly)] = cd
+			}
+			cd.tasks = append(cd.tasks, t)
+			cd.overdue = cd.overdue || !t.Finished && due.Before(now)
+			cd.urgent = cd.urgent || priorities.Rank(t.Priority) == 0
+		}
+	}
+	return days
+}
+
+// weekdayHeaders returns the short names of the days of the week starting on weekStart
+func weekdayHeaders(from time.Time) []string {
+	names := make([]string, 7)
+	for i := range names {
+		names[i] = formatLocal(from.AddDate(0, 0, i), "Mon")
+	}
+	return names
+}
+
+// renderMonth draws the month starting on first as a grid of weeks. Each day shows its number
+// of tasks with '!' for overdue and '*' for highest priority ones; today is bracketed.
+func renderMonth(first time.Time, tasks []*types.Task, st *taskStyles) string {
+	const cellW = 8
+	next := first.AddDate(0, 1, 0)
+	days := calendarDays(tasks, first, next)
+	today := startOfDay(wallNow())
+	lead := int(first.Weekday()-weekStart+7) % 7
+	gridStart := first.AddDate(0, 0, -lead)
+
+	var b strings.Builder
+	title := formatLocal(first, "January 2006")
+	b.WriteString(strings.Repeat(" ", max((7*cellW-displayWidth(title))/2, 0)) + title + "\n")
+	var header strings.Builder
+	for _, n := range weekdayHeaders(gridStart) {
+		header.WriteString(fmt.Sprintf("%4s", n) + strings.Repeat(" ", max(cellW-max(displayWidth(n), 4), 0)))
+	}
+	line := strings.TrimRight(header.String(), " ")
+	if st != nil {
+		line = paint(st.theme.Header, line)
+	}
+	b.WriteString(line + "\n")
+
+	total := 0
+	for d := gridStart; d.Before(next); d = d.AddDate(0, 0, 7) {
+		var row strings.Builder
+		for i := 0; i < 7; i++ {
+			day := d.AddDate(0, 0, i)
+			if day.Before(first) || !day.Before(next) {
+				row.WriteString(strings.Repeat(" ", cellW))
+				continue
+			}
+			num := fmt.Sprintf("%d", day.Day())
+			if day.Equal(today) {
+				num = "[" + num + "]"
+			}
+			info := ""
+			cd := days[day.Format(time.DateOnly)]
+			if cd != nil {
+				total += len(cd.tasks)
+				info = fmt.Sprintf("%d", len(cd.tasks))
+				if cd.overdue {
+					info += "!"
+				}
+				if cd.urgent {
+					info += "*"
+				}
+			}
+			cell := fmt.Sprintf("%4s %-3s", num, info)
+			if st != nil {
+				switch {
+				case day.Equal(today):
+					cell = paint("reverse", cell)
+				case cd != nil && cd.overdue:
+					cell = paint(st.theme.Overdue, cell)
+				case cd != nil && cd.urgent:
+					cell = paint(st.priority(priorities.FromRank(0)), cell)
+				}
+			}
+			row.WriteString(cell)
+		}
+		b.WriteString(strings.TrimRight(row.String(), " ") + "\n")
+	}
+	if total == 0 {
+		b.WriteString("No tasks this month.\n")
+	} else {
+		b.WriteString("n: tasks on the day, !: overdue, *: highest priority\n")
+	}
+	return b.String()
+}
+
+// renderWeek draws the week starting on from with a row per hour, each timed task in the slot
+// it starts in and continued down to its end. Tasks covering whole days or due on a date without
+// a time are listed above.
+// The grid fits within maxWidth, or 80 cells when it's 0.
+func renderWeek(from time.Time, tasks []*types.Task, maxWidth int, st *taskStyles) string {
+	if maxWidth <= 0 {
+		maxWidth = 80
+	}
+	to := from.AddDate(0, 0, 7)
+	days := calendarDays(tasks, from, to)
+	hour := func(h int) string {
+		return time.Date(2000, 1, 1, h, 0, 0, 0, time.UTC).Format(clockFmt)
+	}
+	labelW := max(displayWidth(hour(12)), len("all day")) + 1
+	colW := max((maxWidth-labelW)/7-1, 6)
+
+	// slots[day][hour] lists the tasks in a slot, started there or continuing from earlier
+	type slot struct {
+		starts, continues []*types.Task
+	}
+	slots := make([][24]slot, 7)
+	allDay := make([][]*types.Task, 7)
+	first, last := 8, 18
+	for i := 0; i < 7; i++ {
+		day := from.AddDate(0, 0, i)
+		cd := days[day.Format(time.DateOnly)]
+		if cd == nil {
+			continue
+		}
+		for _, t := range cd.tasks {
+			start, end := *t.StartAt, *t.StartAt
+			if t.EndAt != nil {
+				end = *t.EndAt
+			}
+			// dates without a time are due at 23:59, and whole days run from 00:00 to 23:59
+			dateOnly := t.EndAt == nil && start.Hour() == 23 && start.Minute() == 59
+			wholeDays := t.EndAt != nil && start.Hour() == 0 && start.Minute() == 0 && end.Hour() == 23 && end.Minute() == 59
+			if dateOnly || wholeDays || !startOfDay(start).Equal(day) && !startOfDay(end).Equal(day) {
+				allDay[i] = append(allDay[i], t)
+				continue
+			}
+			h0, h1 := 0, 23
+			if startOfDay(start).Equal(day) {
+				h0 = start.Hour()
+			}
+			if startOfDay(end).Equal(day) {
+				h1 = end.Hour()
+				if end.Minute() == 0 && end.After(start) && h1 > h0 {
+					h1-- // a task ending on the hour doesn't take that slot
+				}
+			}
+			first, last = min(first, h0), max(last, h1)
+			slots[i][h0].starts = append(slots[i][h0].starts, t)
+			for h := h0 + 1; h <= h1; h++ {
+				slots[i][h].continues = append(slots[i][h].continues, t)
+			}
+		}
+	}
+
+	var b strings.Builder
+	writeRow := func(label string, cells []string, style func(i int, s string) string) {
+		var row strings.Builder
+		row.WriteString(fit(label, labelW))
+		for i, cell := range cells {
+			row.WriteString("│")
+			row.WriteString(style(i, fit(cell, colW)))
+		}
+		b.WriteString(strings.TrimRight(row.String(), " ") + "\n")
+	}
+	cells := make([]string, 7)
+
+	title := formatDate(from) + " - " + formatDate(to.AddDate(0, 0, -1))
+	b.WriteString(title + "\n")
+	today := startOfDay(wallNow())
+	for i, n := range weekdayHeaders(from) {
+		cells[i] = fmt.Sprintf("%s %d", n, from.AddDate(0, 0, i).Day())
+	}
+	writeRow("", cells, func(i int, s string) string {
+		switch {
+		case st == nil:
+			return s
+		case from.AddDate(0, 0, i).Equal(today):
+			return paint("reverse", s)
+		}
+		return paint(st.theme.Header, s)
+	})
+	b.WriteString(strings.Repeat("─", labelW) + strings.Repeat("┼"+strings.Repeat("─", colW), 7) + "\n")
+
+	label := func(t *types.Task) string { return fmt.Sprintf("%d %s", t.ShortID, t.Desc) }
+	taskStyle := func(t *types.Task) func(int, string) string {
+		return func(_ int, s string) string {
+			if st == nil || t == nil {
+				return s
+			}
+			return paint(st.row(t), s)
+		}
+	}
+	// grid writes a row of the grid, each cell styled by the state of the task it shows
+	grid := func(name string, pick func(i int) (string, *types.Task)) {
+		shown := make([]*types.Task, 7)
+		for i := 0; i < 7; i++ {
+			cells[i], shown[i] = pick(i)
+		}
+		writeRow(name, cells, func(i int, s string) string { return taskStyle(shown[i])(i, s) })
+	}
+
+	for n := 0; ; n++ {
+		more := false
+		for i := 0; i < 7; i++ {
+			more = more || n < len(allDay[i])
+		}
+		if !more {
+			break
+		}
+		name := ""
+		if n == 0 {
+			name = "all day"
+		}
+		grid(name, func(i int) (string, *types.Task) {
+			if n < len(allDay[i]) {
+				return label(allDay[i][n]), allDay[i][n]
+			}
+			return "", nil
+		})
+	}
+	for h := first; h <= last; h++ {
+		grid(hour(h), func(i int) (string, *types.Task) {
+			s := slots[i][h]
+			switch {
+			case len(s.starts) > 1:
+				return fmt.Sprintf("%s +%d", label(s.starts[0]), len(s.starts)-1), s.starts[0]
+			case len(s.starts) == 1:
+				return label(s.starts[0]), s.starts[0]
+			case len(s.continues) > 0:
+				return "│", s.continues[0]
+			}
+			return "", nil
+		})
+	}
+	return b.String()
+}
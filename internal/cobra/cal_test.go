@@ -0,0 +1,120 @@
+package cobra
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EvoSched/gotask/internal/types"
+)
+
+func withWeek(t *testing.T) {
+	t.Helper()
+	prevStart, prevClock := weekStart, clockFmt
+	weekStart, clockFmt = time.Monday, ClockFmt24h
+	t.Cleanup(func() { weekStart, clockFmt = prevStart, prevClock })
+}
+
+func calTasks() []*types.Task {
+	at := func(d, h, m int) *time.Time {
+		v := time.Date(2024, time.October, d, h, m, 0, 0, time.UTC)
+		return &v
+	}
+	return []*types.Task{
+		{ShortID: 1, Desc: "Send invoice", Priority: 3, StartAt: at(10, 9, 0)},
+		{ShortID: 2, Desc: "Review", Priority: 1, StartAt: at(15, 14, 0), EndAt: at(15, 16, 0)},
+		{ShortID: 3, Desc: "Offsite", Priority: 3, StartAt: at(16, 0, 0), EndAt: at(17, 23, 59)},
+		{ShortID: 4, Desc: "Someday", Priority: 3},
+		{ShortID: 5, Desc: "Standup", Priority: 3, StartAt: at(15, 9, 0), Finished: true},
+	}
+}
+
+func TestRenderMonth(t *testing.T) {
+	withClock(t, fixedNow)
+	withWeek(t)
+	first := time.Date(2024, time.October, 1, 0, 0, 0, 0, time.UTC)
+	want := strings.Join([]string{
+		"                      October 2024",
+		" Mon     Tue     Wed     Thu     Fri     Sat     Sun",
+		"           1       2       3       4       5       6",
+		"   7       8       9      10 1!   11      12      13",
+		"[14]      15 2*   16 1    17 1    18      19      20",
+		"  21      22      23      24      25      26      27",
+		"  28      29      30      31",
+		"n: tasks on the day, !: overdue, *: highest priority",
+		"",
+	}, "\n")
+	if got := renderMonth(first, calTasks(), nil); got != want {
+		t.Errorf("renderMonth =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRenderWeek(t *testing.T) {
+	withClock(t, fixedNow)
+	withWeek(t)
+	from := time.Date(2024, time.October, 14, 0, 0, 0, 0, time.UTC)
+	want := strings.Join([]string{
+		"Mon, 14 Oct 2024 - Sun, 20 Oct 2024",
+		"        │Mon 14  │Tue 15  │Wed 16  │Thu 17  │Fri 18  │Sat 19  │Sun 20",
+		"────────┼────────┼────────┼────────┼────────┼────────┼────────┼────────",
+		"all day │        │        │3 Offs..│3 Offs..│        │        │",
+		"08:00   │        │        │        │        │        │        │",
+		"09:00   │        │5 Stan..│        │        │        │        │",
+		"10:00   │        │        │        │        │        │        │",
+		"11:00   │        │        │        │        │        │        │",
+		"12:00   │        │        │        │        │        │        │",
+		"13:00   │        │        │        │        │        │        │",
+		"14:00   │        │2 Review│        │        │        │        │",
+		"15:00   │        ││       │        │        │        │        │",
+		"16:00   │        │        │        │        │        │        │",
+		"17:00   │        │        │        │        │        │        │",
+		"18:00   │        │        │        │        │        │        │",
+		"",
+	}, "\n")
+	if got := renderWeek(from, calTasks(), 76, nil); got != want {
+		t.Errorf("renderWeek =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestCalendarZone(t *testing.T) {
+	// task times are wall-clock times, so a clock just past midnight in UTC+2 is already Tuesday
+	// and tasks stay on the day and hour they were given
+	withClock(t, time.Date(2024, time.October, 15, 0, 30, 0, 0, time.FixedZone("CEST", 2*60*60)))
+	withWeek(t)
+	at := func(d, h, m int) *time.Time {
+		v := time.Date(2024, time.October, d, h, m, 0, 0, time.UTC)
+		return &v
+	}
+	tasks := []*types.Task{
+		{ShortID: 1, Desc: "Pay rent", Priority: 3, StartAt: at(18, 23, 59)},
+		{ShortID: 2, Desc: "Dentist", Priority: 3, StartAt: at(15, 10, 0)},
+	}
+
+	month := renderMonth(time.Date(2024, time.October, 1, 0, 0, 0, 0, time.UTC), tasks, nil)
+	if want := "  14    [15] 1    16      17      18 1    19      20"; !strings.Contains(month, want+"\n") {
+		t.Errorf("renderMonth =\n%s\nwant a row\n%s", month, want)
+	}
+
+	want := strings.Join([]string{
+		"Mon, 14 Oct 2024 - Sun, 20 Oct 2024",
+		"        │Mon 14  │Tue 15  │Wed 16  │Thu 17  │Fri 18  │Sat 19  │Sun 20",
+		"────────┼────────┼────────┼────────┼────────┼────────┼────────┼────────",
+		"all day │        │        │        │        │1 Pay ..│        │",
+		"08:00   │        │        │        │        │        │        │",
+		"09:00   │        │        │        │        │        │        │",
+		"10:00   │        │2 Dent..│        │        │        │        │",
+		"11:00   │        │        │        │        │        │        │",
+		"12:00   │        │        │        │        │        │        │",
+		"13:00   │        │        │        │        │        │        │",
+		"14:00   │        │        │        │        │        │        │",
+		"15:00   │        │        │        │        │        │        │",
+		"16:00   │        │        │        │        │        │        │",
+		"17:00   │        │        │        │        │        │        │",
+		"18:00   │        │        │        │        │        │        │",
+		"",
+	}, "\n")
+	from := time.Date(2024, time.October, 14, 0, 0, 0, 0, time.UTC)
+	if got := renderWeek(from, tasks, 76, nil); got != want {
+		t.Errorf("renderWeek =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,164 @@
+package cobra
+
+import (
+	"log"
+
+	"github.com/EvoSched/gotask/internal/types"
+	"github.com/spf13/cobra"
+)
+
+func (c *Cmd) CheckCmd() *cobra.Command {
+	checkCmd := &cobra.Command{
+		Use:         "check",
+		Annotations: changesTasks,
+		Short:       "Check off a checklist item of a task",
+		Long: `Marks a checklist item of a task as done. Items are numbered in the order shown by 'gt get'.
+If 'checklist.auto_finish' is enabled in config, the task is finished once every item is checked.
+
+Required:
+- id  Id referencing task.
+- n   Number of the checklist item.`,
+		Example: `gt check add 1 "Update changelog"
+gt check 1 2`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			c.setCheckItem(args, true)
+		},
+	}
+	checkCmd.AddCommand(c.checkAddCmd(), c.checkRemoveCmd())
+	return checkCmd
+}
+
+func (c *Cmd) UncheckCmd() *cobra.Command {
+	uncheckCmd := &cobra.Command{
+		Use:         "uncheck",
+		Annotations: changesTasks,
+		Short:       "Uncheck a checklist item of a task",
+		Long: `Marks a checklist item of a task as not done. Items are numbered in the order shown by 'gt get'.
+
+Required:
+- id  Id referencing task.
+- n   Number of the checklist item.`,
+		Example: "gt uncheck 1 2",
+		Args:    cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			c.setCheckItem(args, false)
+		},
+	}
+	return uncheckCmd
+}
+
+func (c *Cmd) checkAddCmd() *cobra.Command {
+	addCmd := &cobra.Command{
+		Use:         "add",
+		Annotations: changesTasks,
+		Short:       "Add a checklist item to a task",
+		Long: `Adds an item to the end of a task's checklist.
+
+Required:
+- id    Id referencing task.
+- item  Text of the checklist item.`,
+		Example: `gt check add 1 "Update changelog"`,
+		Args:    cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			ref, text, err := parseCheckAdd(args)
+			if err != nil {
+				fatal(err)
+			}
+			id, err := c.resolveID(ref)
+			if err != nil {
+				log.Fatal(err)
+			}
+			t, err := c.repo.GetTask(id)
+			if err != nil {
+				log.Fatal(err)
+			}
+			err = c.repo.AddCheckItem(&types.CheckItem{TaskID: id, Text: text})
+			if err != nil {
+				log.Fatal(err)
+			}
+			c.printf("Task %d '%s' has been updated with a new checklist item:\n", t.ShortID, t.Desc)
+			c.printf("  %d. [ ] %s\n", len(t.Checklist)+1, text)
+			c.changedTasks(t)
+		},
+	}
+	return addCmd
+}
+
+func (c *Cmd) checkRemoveCmd() *cobra.Command {
+	removeCmd := &cobra.Command{
+		Use:         "rm",
+		Annotations: changesTasks,
+		Short:       "Remove a checklist item from a task",
+		Long: `Removes an item from a task's checklist. Later items are renumbered.
+
+Required:
+- id  Id referencing task.
+- n   Number of the checklist item.`,
+		Example: "gt check rm 1 2",
+		Args:    cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			t, item := c.checkItem(args)
+			err := c.repo.DeleteCheckItem(item.ID)
+			if err != nil {
+				log.Fatal(err)
+			}
+			c.printf("Removed checklist item from task %d '%s':\n", t.ShortID, t.Desc)
+			c.printf("  - %s\n", item.Text)
+			c.changedTasks(t)
+		},
+	}
+	return removeCmd
+}
+
+// setCheckItem checks or unchecks the item referenced by args, applying the auto finish rule
+func (c *Cmd) setCheckItem(args []string, done bool) {
+	t, item := c.checkItem(args)
+	if item.Done == done {
+		c.printf("Nothing to do, item is already %s: %s\n", formatCheck(done), item.Text)
+		return
+	}
+	err := c.repo.UpdateCheckItem(item.ID, done)
+	if err != nil {
+		log.Fatal(err)
+	}
+	item.Done = done
+	d, n := t.Progress()
+	c.printf("Task %d '%s' checklist %d/%d:\n", t.ShortID, t.Desc, d, n)
+	c.printf("  %s %s\n", formatCheck(done), item.Text)
+	c.changedTasks(t)
+	if done && d == n && !t.Finished && c.cfg.Checklist.AutoFinish {
+		err = c.repo.UpdateStatus(t.ID, true)
+		if err != nil {
+			log.Fatal(err)
+		}
+		c.printf("All items checked, finished task %d '%s'.\n", t.ShortID, t.Desc)
+	}
+}
+
+// checkItem returns the task and checklist item referenced by args
+func (c *Cmd) checkItem(args []string) (*types.Task, *types.CheckItem) {
+	ref, n, err := parseItemRef(args)
+	if err != nil {
+		fatal(err)
+	}
+	id, err := c.resolveID(ref)
+	if err != nil {
+		log.Fatal(err)
+	}
+	t, err := c.repo.GetTask(id)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if n > len(t.Checklist) {
+		log.Fatalf("task %d has %d checklist item(s), no item %d", t.ShortID, len(t.Checklist), n)
+	}
+	return t, &t.Checklist[n-1]
+}
+
+func formatCheck(done bool) string {
+	if done {
+		return "[x]"
+	}
+	return "[ ]"
+}
@@ -1,29 +1,110 @@
 package cobra
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
 
+	"github.com/EvoSched/gotask/internal/config"
 	"github.com/EvoSched/gotask/internal/service"
 )
 
 // TODO: divide to cli and tui handlers
 type Cmd struct {
 	repo *service.TaskRepo
+	cfg  *config.Config
+
+	output       string    // --output format, empty for text
+	format       string    // --format template or name from config
+	templateFile string    // --template file
+	color        string    // --color: auto, always or never
+	stdout       io.Writer // where --output, --format or --template is written, nil for text
+	out          io.Writer // where messages are written, stdout unless it carries a result
+	in           io.Reader // where answers to questions are read, stdin unless set
+	changed      []int     // IDs of the tasks changed, for the result printed with --output
 }
 
-func NewCmd(repo *service.TaskRepo) *Cmd {
-	return &Cmd{repo}
+func NewCmd(repo *service.TaskRepo, cfg *config.Config) *Cmd {
+	udaDefs = cfg.UDA
+	priorities = cfg.Priority
+	setLocale(cfg.Locale, cfg.Display)
+	return &Cmd{repo: repo, cfg: cfg}
 }
 
 func (c *Cmd) Execute() {
 	rootCmd := c.RootCmd()
 
 	rootCmd.AddCommand(c.AddCmd(), c.ModCmd(), c.DeleteCmd(), c.GetCmd(), c.ListCmd(), c.DueCmd(), c.ArchivedCmd(),
-		c.DoneCmd(), c.UndoCmd(), c.NoteCmd())
+		c.DoneCmd(), c.UndoCmd(), c.NoteCmd(), c.ImportCmd(), c.ExportCmd(),
+		c.AttachCmd(), c.OpenCmd(), c.DetachCmd(), c.RenumberCmd(),
+		c.DaemonCmd(), c.SnoozeCmd(), c.TemplateCmd(), c.CheckCmd(), c.UncheckCmd(),
+		c.LinkCmd(), c.UnlinkCmd(), c.DupCmd(), c.CopyCmd(), c.PostponeCmd(), c.TuiCmd(), c.CalCmd())
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
 }
+
+// resolveID resolves a task reference to a task id. A description reference ('~words') matching
+// several tasks asks which one is meant.
+func (c *Cmd) resolveID(ref string) (int, error) {
+	q, ok := strings.CutPrefix(ref, "~")
+	if !ok {
+		return c.repo.ResolveID(ref)
+	}
+	tasks, err := c.repo.MatchDesc(q)
+	if err != nil {
+		return 0, err
+	}
+	switch len(tasks) {
+	case 0:
+		return 0, fmt.Errorf("%w matching '%s'", service.ErrNoTask, q)
+	case 1:
+		return tasks[0].ID, nil
+	}
+	t, err := c.pickTask(fmt.Sprintf("'%s' matches %d tasks", q, len(tasks)), tasks)
+	if err != nil {
+		return 0, err
+	}
+	return t.ID, nil
+}
+
+// resolveIDs resolves task references to task ids, each once. References matching no task are
+// reported together, e.g. "no tasks with IDs 5, 8".
+func (c *Cmd) resolveIDs(refs []string) ([]int, error) {
+	var ids []int
+	var missingIDs, missing []string
+	for _, r := range refs {
+		id, err := c.resolveID(r)
+		if errors.Is(err, service.ErrNoTask) {
+			if _, nerr := strconv.Atoi(r); nerr == nil {
+				missingIDs = append(missingIDs, r)
+			} else {
+				missing = append(missing, err.Error())
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	switch len(missingIDs) {
+	case 0:
+	case 1:
+		missing = append([]string{"no task with ID " + missingIDs[0]}, missing...)
+	default:
+		missing = append([]string{"no tasks with IDs " + strings.Join(missingIDs, ", ")}, missing...)
+	}
+	if len(missing) > 0 {
+		return nil, errors.New(strings.Join(missing, "; "))
+	}
+	return ids, nil
+}
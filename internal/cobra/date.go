@@ -0,0 +1,357 @@
+package cobra
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/EvoSched/gotask/internal/types"
+)
+
+// timeNow returns the current time; tests replace it to pin the clock
+var timeNow = time.Now
+
+// wallNow returns the current time as task times are stored, see types.WallClock
+func wallNow() time.Time {
+	return types.WallClock(timeNow())
+}
+
+// maxDateWords is the longest date phrase, e.g. "first mon of nov 2025"
+const maxDateWords = 5
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "sunday": time.Sunday,
+	"mon": time.Monday, "monday": time.Monday,
+	"tue": time.Tuesday, "tues": time.Tuesday, "tuesday": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday,
+	"thu": time.Thursday, "thur": time.Thursday, "thurs": time.Thursday, "thursday": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday,
+}
+
+var months = map[string]time.Month{
+	"jan": time.January, "january": time.January,
+	"feb": time.February, "february": time.February,
+	"mar": time.March, "march": time.March,
+	"apr": time.April, "april": time.April,
+	"may": time.May,
+	"jun": time.June, "june": time.June,
+	"jul": time.July, "july": time.July,
+	"aug": time.August, "august": time.August,
+	"sep": time.September, "sept": time.September, "september": time.September,
+	"oct": time.October, "october": time.October,
+	"nov": time.November, "november": time.November,
+	"dec": time.December, "december": time.December,
+}
+
+// ordinals maps the words selecting a weekday within a month to its occurrence, -1 being the last
+var ordinals = map[string]int{
+	"first": 1, "1st": 1,
+	"second": 2, "2nd": 2,
+	"third": 3, "3rd": 3,
+	"fourth": 4, "4th": 4,
+	"fifth": 5, "5th": 5,
+	"last": -1,
+}
+
+var (
+	offsetDate  = regexp.MustCompile(`^\+(\d+)([dwmy]?)$`)
+	ordinalDay  = regexp.MustCompile(`^(\d{1,2})(st|nd|rd|th)?$`)
+	isoWeekDate = regexp.MustCompile(`^w(\d{1,2})$`)
+	yearNumber  = regexp.MustCompile(`^\d{4}$`)
+)
+
+// parseDate processes date expressions and returns a time.Time
+// Dates resolve to the end of their day (23:59), except 'now'
+//
+// Example inputs:
+//  1. Keywords:
+//     - "today"/"tod", "tomorrow"/"tmrw", "yesterday"/"yest"
+//     - "now" -> current time
+//     - "eod", "eow", "eom", "eoq", "eoy" -> end of day, week, month, quarter, year
+//  2. Days of week, always in the future, including localized names:
+//     - "mon", "monday", "fri", ..., and "next fri" for Friday of next week
+//     - weeks start on the locale's week_start, Sunday by default
+//  3. Offsets:
+//     - "+3" (days), "+2w", "+1m", "+1y", "in 3 days", "in 2 weeks"
+//  4. Calendar dates:
+//     - "jan 5", "5 jan", "jan 5th 2025", "5th" (day of this or next month)
+//     - "w42" (Monday of ISO week 42)
+//     - "first mon of next month", "last fri of this month", "2nd tue of nov"
+//     - "next week", "next month", "next year" (the start of each)
+//  5. Numeric dates in the locale's date orders (defined in dateFormats and dayMonthFormats)
+//     - "15/03/2024", "15-03-2024", "15/03" with DMY, "03/15/2024" with MDY
+//
+// Dates without a year, and days without a month, that have passed this year or month
+// roll forward to the next one
+func parseDate(arg string) (*time.Time, error) {
+	now := wallNow()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 23, 59, 0, 0, time.UTC)
+
+	f := strings.Fields(strings.ToLower(arg))
+	if len(f) == 0 {
+		return nil, fmt.Errorf("invalid date format: %s", arg)
+	}
+	if len(f) == 1 && f[0] == "now" {
+		return &now, nil
+	}
+	if d, ok := dateWords(f, today); ok {
+		return &d, nil
+	}
+
+	// If not a phrase, try parsing with the date formats of the configured date orders
+	for _, v := range dateFormats {
+		t, err := time.Parse(v, arg)
+		if err == nil {
+			d := time.Date(t.Year(), t.Month(), t.Day(), 23, 59, 0, 0, time.UTC)
+			return &d, nil
+		}
+	}
+	// Then without a year, rolling forward once passed (e.g., "5/1" in DMY is 5 Jan)
+	for _, v := range dayMonthFormats {
+		t, err := time.Parse(v, arg)
+		if err != nil {
+			continue
+		}
+		d, ok := calendarDate(today.Year(), t.Month(), t.Day())
+		if ok && d.Before(today) {
+			d, ok = calendarDate(today.Year()+1, t.Month(), t.Day())
+		}
+		if ok {
+			return &d, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid date format: %s", arg)
+}
+
+// parseDatePhrase returns the date formed by the longest run of args starting at the first,
+// along with the number of args it spans
+// Example: ["first", "mon", "of", "next", "month", "9am"] -> (first Monday of next month, 5)
+func parseDatePhrase(args []string) (*time.Time, int, error) {
+	for n := min(len(args), maxDateWords); n > 0; n-- {
+		if d, err := parseDate(strings.Join(args[:n], " ")); err == nil {
+			return d, n, nil
+		}
+	}
+	return nil, 0, fmt.Errorf("invalid date format: %s", args[0])
+}
+
+// dateWords matches lowercase words against the date grammar relative to today (at 23:59)
+func dateWords(f []string, today time.Time) (time.Time, bool) {
+	switch len(f) {
+	case 1:
+		return dateWord(f[0], today)
+	case 2:
+		if f[0] == "next" {
+			return nextPeriod(f[1], today)
+		}
+		return monthDay(f[0], f[1], "", today)
+	case 3:
+		if f[0] == "in" {
+			n, err := strconv.Atoi(f[1])
+			if err != nil {
+				return time.Time{}, false
+			}
+			unit := strings.TrimSuffix(f[2], "s")
+			if u, ok := map[string]string{"day": "d", "week": "w", "month": "m", "year": "y"}[unit]; ok {
+				return addOffset(today, n, u), true
+			}
+			return time.Time{}, false
+		}
+		return monthDay(f[0], f[1], f[2], today)
+	default:
+		// "<ordinal> <weekday> of <period>"
+		if len(f) < 4 || f[2] != "of" {
+			return time.Time{}, false
+		}
+		return weekdayOfMonth(f[0], f[1], f[3:], today)
+	}
+}
+
+// dateWord matches a single word: a keyword, weekday, offset, ordinal day or ISO week
+func dateWord(w string, today time.Time) (time.Time, bool) {
+	switch w {
+	case "today", "tod", "eod":
+		return today, true
+	case "tomorrow", "tmrw":
+		return today.AddDate(0, 0, 1), true
+	case "yesterday", "yest":
+		return today.AddDate(0, 0, -1), true
+	case "eow":
+		// Calculate days until the last day of the week, today if it is that day
+		// Example: If weeks start on Sunday and today is Wednesday (3), then Saturday (6) - Wednesday (3) = 3 days
+		last := (weekStart + 6) % 7
+		return today.AddDate(0, 0, int(last-today.Weekday()+7)%7), true
+	case "eom":
+		return lastOfMonth(today.Year(), today.Month()), true
+	case "eoq":
+		q := (today.Month()-1)/3*3 + 3
+		return lastOfMonth(today.Year(), q), true
+	case "eoy":
+		return lastOfMonth(today.Year(), time.December), true
+	}
+	if wd, ok := weekdays[w]; ok {
+		// Always the next occurrence, a week away if it's today
+		// Example: If today is Wednesday (3), then Saturday (6) - Wednesday (3) = 3 days
+		d := int(wd-today.Weekday()+7) % 7
+		if d == 0 {
+			d = 7
+		}
+		return today.AddDate(0, 0, d), true
+	}
+	if m := offsetDate.FindStringSubmatch(w); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		return addOffset(today, n, m[2]), true
+	}
+	if m := ordinalDay.FindStringSubmatch(w); m != nil && m[2] != "" {
+		day, _ := strconv.Atoi(m[1])
+		for i := 0; i < 12; i++ {
+			y, mo := today.Year(), today.Month()+time.Month(i)
+			if d, ok := calendarDate(y, mo, day); ok && !d.Before(today) {
+				return d, true
+			}
+		}
+		return time.Time{}, false
+	}
+	if m := isoWeekDate.FindStringSubmatch(w); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		y, _ := today.ISOWeek()
+		for _, yr := range []int{y, y + 1} {
+			mon, ok := isoWeekStart(yr, n)
+			if ok && !mon.AddDate(0, 0, 6).Before(today) {
+				return mon, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// nextPeriod resolves "next <weekday>", "next week", "next month" and "next year"
+func nextPeriod(w string, today time.Time) (time.Time, bool) {
+	// First day of next week, weeks starting on weekStart
+	nextWeek := today.AddDate(0, 0, 7-int(today.Weekday()-weekStart+7)%7)
+	switch w {
+	case "week":
+		return nextWeek, true
+	case "month":
+		return time.Date(today.Year(), today.Month()+1, 1, 23, 59, 0, 0, time.UTC), true
+	case "year":
+		return time.Date(today.Year()+1, time.January, 1, 23, 59, 0, 0, time.UTC), true
+	}
+	if wd, ok := weekdays[w]; ok {
+		return nextWeek.AddDate(0, 0, int(wd-weekStart+7)%7), true
+	}
+	return time.Time{}, false
+}
+
+// monthDay resolves "jan 5", "5 jan" and "jan 5th", with an optional year
+func monthDay(a, b, year string, today time.Time) (time.Time, bool) {
+	mo, ok := months[a]
+	day := b
+	if !ok {
+		mo, ok = months[b]
+		day = a
+	}
+	m := ordinalDay.FindStringSubmatch(day)
+	if !ok || m == nil {
+		return time.Time{}, false
+	}
+	dn, _ := strconv.Atoi(m[1])
+	if year != "" {
+		if !yearNumber.MatchString(year) {
+			return time.Time{}, false
+		}
+		y, _ := strconv.Atoi(year)
+		return calendarDate(y, mo, dn)
+	}
+	d, ok := calendarDate(today.Year(), mo, dn)
+	if ok && d.Before(today) {
+		d, ok = calendarDate(today.Year()+1, mo, dn)
+	}
+	return d, ok
+}
+
+// weekdayOfMonth resolves "<ordinal> <weekday> of <period>", where period is "month",
+// "this month", "next month" or a month name with an optional year
+func weekdayOfMonth(ord, day string, period []string, today time.Time) (time.Time, bool) {
+	n, ok := ordinals[ord]
+	if !ok {
+		return time.Time{}, false
+	}
+	wd, ok := weekdays[day]
+	if !ok {
+		return time.Time{}, false
+	}
+	y, mo := today.Year(), today.Month()
+	switch strings.Join(period, " ") {
+	case "month", "this month":
+	case "next month":
+		mo++
+	default:
+		mo, ok = months[period[0]]
+		if !ok || len(period) > 2 {
+			return time.Time{}, false
+		}
+		if len(period) == 2 {
+			if !yearNumber.MatchString(period[1]) {
+				return time.Time{}, false
+			}
+			y, _ = strconv.Atoi(period[1])
+		} else if mo < today.Month() {
+			y++
+		}
+	}
+	// Normalize month overflow (e.g., next month in December)
+	first := time.Date(y, mo, 1, 23, 59, 0, 0, time.UTC)
+	if n == -1 {
+		last := lastOfMonth(first.Year(), first.Month())
+		return last.AddDate(0, 0, -(int(last.Weekday()-wd+7) % 7)), true
+	}
+	d := first.AddDate(0, 0, int(wd-first.Weekday()+7)%7+(n-1)*7)
+	if d.Month() != first.Month() {
+		return time.Time{}, false
+	}
+	return d, true
+}
+
+// addOffset adds n days, weeks, months or years to d, clamping to the end of shorter months
+func addOffset(d time.Time, n int, unit string) time.Time {
+	switch unit {
+	case "w":
+		return d.AddDate(0, 0, 7*n)
+	case "m", "y":
+		if unit == "y" {
+			n *= 12
+		}
+		first := time.Date(d.Year(), d.Month()+time.Month(n), 1, 23, 59, 0, 0, time.UTC)
+		last := lastOfMonth(first.Year(), first.Month())
+		return first.AddDate(0, 0, min(d.Day(), last.Day())-1)
+	default:
+		return d.AddDate(0, 0, n)
+	}
+}
+
+// calendarDate returns the given day at 23:59, reporting whether the day exists
+func calendarDate(y int, m time.Month, day int) (time.Time, bool) {
+	d := time.Date(y, m, day, 23, 59, 0, 0, time.UTC)
+	// Normalize month overflow before comparing (e.g., month 13)
+	want := time.Date(y, m, 1, 0, 0, 0, 0, time.UTC)
+	return d, day > 0 && d.Month() == want.Month()
+}
+
+func lastOfMonth(y int, m time.Month) time.Time {
+	return time.Date(y, m+1, 0, 23, 59, 0, 0, time.UTC)
+}
+
+// isoWeekStart returns the Monday of ISO week n of year y
+func isoWeekStart(y, n int) (time.Time, bool) {
+	// January 4th is always in week 1
+	jan4 := time.Date(y, time.January, 4, 23, 59, 0, 0, time.UTC)
+	mon := jan4.AddDate(0, 0, -((int(jan4.Weekday())+6)%7)+(n-1)*7)
+	if wy, wn := mon.ISOWeek(); n < 1 || wy != y || wn != n {
+		return time.Time{}, false
+	}
+	return mon, true
+}
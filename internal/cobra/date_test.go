@@ -0,0 +1,240 @@
+package cobra
+
+import (
+	"testing"
+	"time"
+
+	"github.com/EvoSched/gotask/internal/config"
+)
+
+// fixedNow is Monday, 14 Oct 2024, in ISO week 42
+var fixedNow = time.Date(2024, time.October, 14, 10, 30, 0, 0, time.UTC)
+
+func withClock(t *testing.T, now time.Time) {
+	t.Helper()
+	prev := timeNow
+	timeNow = func() time.Time { return now }
+	t.Cleanup(func() { timeNow = prev })
+}
+
+func day(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 23, 59, 0, 0, time.UTC)
+}
+
+func TestParseDate(t *testing.T) {
+	withClock(t, fixedNow)
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		// keywords
+		{"now", fixedNow},
+		{"today", day(2024, 10, 14)},
+		{"tod", day(2024, 10, 14)},
+		{"eod", day(2024, 10, 14)},
+		{"tomorrow", day(2024, 10, 15)},
+		{"tmrw", day(2024, 10, 15)},
+		{"yesterday", day(2024, 10, 13)},
+		{"yest", day(2024, 10, 13)},
+		{"eow", day(2024, 10, 19)},
+		{"eom", day(2024, 10, 31)},
+		{"eoq", day(2024, 12, 31)},
+		{"eoy", day(2024, 12, 31)},
+		{"TODAY", day(2024, 10, 14)},
+
+		// weekdays are always in the future, so today's weekday is a week away
+		{"mon", day(2024, 10, 21)},
+		{"monday", day(2024, 10, 21)},
+		{"tue", day(2024, 10, 15)},
+		{"Friday", day(2024, 10, 18)},
+		{"sat", day(2024, 10, 19)},
+		{"sun", day(2024, 10, 20)},
+		{"next mon", day(2024, 10, 21)},
+		{"next fri", day(2024, 10, 25)},
+		{"next sunday", day(2024, 10, 20)},
+		{"next week", day(2024, 10, 20)},
+		{"next month", day(2024, 11, 1)},
+		{"next year", day(2025, 1, 1)},
+
+		// offsets
+		{"+3", day(2024, 10, 17)},
+		{"+3d", day(2024, 10, 17)},
+		{"+2w", day(2024, 10, 28)},
+		{"+1m", day(2024, 11, 14)},
+		{"+1y", day(2025, 10, 14)},
+		{"in 3 days", day(2024, 10, 17)},
+		{"in 1 day", day(2024, 10, 15)},
+		{"in 1 week", day(2024, 10, 21)},
+		{"in 2 months", day(2024, 12, 14)},
+		{"in 1 year", day(2025, 10, 14)},
+
+		// calendar dates, rolling forward when passed
+		{"nov 5", day(2024, 11, 5)},
+		{"5 nov", day(2024, 11, 5)},
+		{"Dec 25th", day(2024, 12, 25)},
+		{"jan 5", day(2025, 1, 5)},
+		{"oct 14", day(2024, 10, 14)},
+		{"jan 5 2026", day(2026, 1, 5)},
+		{"5th", day(2024, 11, 5)},
+		{"14th", day(2024, 10, 14)},
+		{"20th", day(2024, 10, 20)},
+		{"31st", day(2024, 10, 31)},
+		{"w42", day(2024, 10, 14)},
+		{"w43", day(2024, 10, 21)},
+		{"w1", day(2024, 12, 30)},
+
+		// weekdays within a month
+		{"first mon of next month", day(2024, 11, 4)},
+		{"first mon of month", day(2024, 10, 7)},
+		{"last fri of this month", day(2024, 10, 25)},
+		{"2nd tue of nov", day(2024, 11, 12)},
+		{"first mon of jan", day(2025, 1, 6)},
+		{"last fri of feb 2025", day(2025, 2, 28)},
+
+		// fixed formats
+		{"15-03-2024", day(2024, 3, 15)},
+		{"15/03/2024", day(2024, 3, 15)},
+		{"2024-03-15", day(2024, 3, 15)},
+		{"15/03", day(2025, 3, 15)},
+		{"20/10", day(2024, 10, 20)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseDate(tt.in)
+			if err != nil {
+				t.Fatalf("parseDate(%q) error: %v", tt.in, err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("parseDate(%q) = %s, want %s", tt.in, got.Format(time.RFC1123), tt.want.Format(time.RFC1123))
+			}
+		})
+	}
+}
+
+func TestParseDateInvalid(t *testing.T) {
+	withClock(t, fixedNow)
+	for _, in := range []string{
+		"",
+		"someday",
+		"next banana",
+		"in x days",
+		"in 3 fortnights",
+		"feb 30",
+		"jan 5 25",
+		"w54",
+		"fifth mon of nov",
+		"first mon of smarch",
+		"13/13/2024",
+		"2pm",
+	} {
+		if got, err := parseDate(in); err == nil {
+			t.Errorf("parseDate(%q) = %s, want error", in, got)
+		}
+	}
+}
+
+func TestAddOffsetClampsMonths(t *testing.T) {
+	tests := []struct {
+		from time.Time
+		n    int
+		unit string
+		want time.Time
+	}{
+		{day(2024, 1, 31), 1, "m", day(2024, 2, 29)},
+		{day(2024, 3, 31), 1, "m", day(2024, 4, 30)},
+		{day(2024, 2, 29), 1, "y", day(2025, 2, 28)},
+		{day(2024, 12, 15), 1, "m", day(2025, 1, 15)},
+	}
+	for _, tt := range tests {
+		if got := addOffset(tt.from, tt.n, tt.unit); !got.Equal(tt.want) {
+			t.Errorf("addOffset(%s, %d, %s) = %s, want %s", tt.from.Format(time.DateOnly), tt.n, tt.unit, got.Format(time.DateOnly), tt.want.Format(time.DateOnly))
+		}
+	}
+}
+
+func TestParseTaskDatePhrase(t *testing.T) {
+	withClock(t, fixedNow)
+	tests := []struct {
+		args  []string
+		start time.Time
+		tags  []string
+	}{
+		{[]string{"x", "@", "first", "mon", "of", "next", "month", "9am", "+work"}, time.Date(2024, 11, 4, 9, 0, 0, 0, time.UTC), []string{"work"}},
+		{[]string{"x", "@", "in", "3", "days", "+a"}, day(2024, 10, 17), []string{"a"}},
+		{[]string{"x", "@tomorrow", "2pm"}, time.Date(2024, 10, 15, 14, 0, 0, 0, time.UTC), nil},
+		{[]string{"x", "@", "next", "fri", "+b"}, day(2024, 10, 25), []string{"b"}},
+	}
+	for _, tt := range tests {
+		ti, err := parseTask(tt.args, true)
+		if err != nil {
+			t.Fatalf("parseTask(%q) error: %v", tt.args, err)
+		}
+		if ti.startAt == nil || !ti.startAt.Equal(tt.start) {
+			t.Errorf("parseTask(%q) start = %v, want %s", tt.args, ti.startAt, tt.start)
+		}
+		if len(ti.addTags) != len(tt.tags) || (len(tt.tags) > 0 && ti.addTags[0] != tt.tags[0]) {
+			t.Errorf("parseTask(%q) tags = %v, want %v", tt.args, ti.addTags, tt.tags)
+		}
+	}
+}
+
+func TestParseDateLocale(t *testing.T) {
+	withClock(t, fixedNow)
+	prevFormats, prevDayMonth, prevStart := dateFormats, dayMonthFormats, weekStart
+	prevMonths, prevWeekdays := months, weekdays
+	prevClock, prevClockLong := clockFmt, clockFmtLong
+	t.Cleanup(func() {
+		dateFormats, dayMonthFormats, weekStart = prevFormats, prevDayMonth, prevStart
+		clockFmt, clockFmtLong = prevClock, prevClockLong
+		months, weekdays = prevMonths, prevWeekdays
+		monthNames, shortMonthNames, dayNames, shortDayNames = nil, nil, nil, nil
+	})
+	months, weekdays = copyMap(months), copyMap(weekdays)
+	setLocale(config.Locale{
+		DateOrders: []string{config.DateOrderMDY, config.DateOrderDMY},
+		WeekStart:  "monday",
+		Months:     []string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+		Weekdays:   []string{"Sonntag", "Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag"},
+	}, config.Display{Clock: config.Clock24h})
+
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		// ambiguous dates resolve in the first order, others fall back to the next
+		{"03/04/2024", day(2024, 3, 4)},
+		{"13/04/2024", day(2024, 4, 13)},
+		{"11/5", day(2024, 11, 5)},
+		// weeks start on Monday
+		{"eow", day(2024, 10, 20)},
+		{"next week", day(2024, 10, 21)},
+		{"next sun", day(2024, 10, 27)},
+		// localized names
+		{"freitag", day(2024, 10, 18)},
+		{"mär 5", day(2025, 3, 5)},
+		{"5 dezember", day(2024, 12, 5)},
+	}
+	for _, tt := range tests {
+		got, err := parseDate(tt.in)
+		if err != nil {
+			t.Errorf("parseDate(%q) error: %v", tt.in, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parseDate(%q) = %s, want %s", tt.in, got.Format(time.RFC1123), tt.want.Format(time.RFC1123))
+		}
+	}
+
+	// Tuesday 5 March, with the weekday and month localized
+	if got, want := formatDateTime(time.Date(2024, 3, 5, 14, 5, 0, 0, time.UTC)), "Die, 05 Mär 2024 14:05"; got != want {
+		t.Errorf("formatDateTime = %q, want %q", got, want)
+	}
+}
+
+func copyMap[K comparable, V any](m map[K]V) map[K]V {
+	c := make(map[K]V, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
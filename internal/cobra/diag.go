@@ -0,0 +1,233 @@
+package cobra
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Codes of parse errors. They are stable, so scripts and editor plugins can rely on them.
+const (
+	CodeDescMissing       = "desc-missing"       // a new task has no description
+	CodeRefInvalid        = "ref-invalid"        // not a task ID or UUID prefix
+	CodeTimeMissing       = "time-missing"       // '@' without a date or time
+	CodeTimeDuplicate     = "time-duplicate"     // a second '@', date or time of day
+	CodeTimeInvalid       = "time-invalid"       // a time of day that can't be read
+	CodeTimeOrder         = "time-order"         // a range ending before it starts
+	CodeTimeRange         = "time-range"         // a malformed range
+	CodeDateInvalid       = "date-invalid"       // a date that can't be read
+	CodePriorityMissing   = "priority-missing"   // '%' without a value
+	CodePriorityInvalid   = "priority-invalid"   // a value outside the priority scheme
+	CodePriorityDuplicate = "priority-duplicate" // a second '%'
+	CodeReminderInvalid   = "reminder-invalid"   // a 'remind:' value that can't be read
+	CodeAttrInvalid       = "attr-invalid"       // a value of the wrong type for its attribute
+	CodeAttrDuplicate     = "attr-duplicate"     // an attribute given twice
+	CodeQuoteUnterminated = "quote-unterminated" // a quote without its closing quote
+	CodeEscapeTrailing    = "escape-trailing"    // a '\' at the end of the input
+	CodeFilterInvalid     = "filter-invalid"     // a filter that can't be parsed
+	CodeItemMissing       = "item-missing"       // a checklist item without text
+	CodeItemInvalid       = "item-invalid"       // an item number that isn't a positive number
+)
+
+// ParseError is a problem with input, located at a word and an offset within it
+type ParseError struct {
+	Code   string
+	Msg    string
+	Hint   string // a suggested fix, if any
+	Word   int    // index of the word
+	Offset int    // byte offset of the problem within the word
+	Length int    // bytes of the word at fault, 0 for the rest of the word
+
+	Input string // the input as shown to the user
+	Col   int    // byte offset of the problem in Input
+}
+
+func (e *ParseError) Error() string {
+	return e.Msg
+}
+
+// parseErr returns a ParseError at the start of the first word
+func parseErr(code string, format string, a ...any) *ParseError {
+	return &ParseError{Code: code, Msg: fmt.Sprintf(format, a...)}
+}
+
+// at places the error at offset within a word, marking length bytes (0 for the rest of the word)
+func (e *ParseError) at(word, offset, length int) *ParseError {
+	e.Word, e.Offset, e.Length = word, offset, length
+	return e
+}
+
+// hint adds a suggested fix
+func (e *ParseError) hint(format string, a ...any) *ParseError {
+	e.Hint = fmt.Sprintf(format, a...)
+	return e
+}
+
+// shift moves a ParseError found within a part of the input to its place in the whole; other
+// errors are given code and placed at the start of word
+func shift(err error, code string, word, offset int) error {
+	var pe *ParseError
+	if !errors.As(err, &pe) {
+		return parseErr(code, "%v", err).at(word, offset, 0)
+	}
+	if pe.Word == 0 {
+		pe.Offset += offset
+	}
+	pe.Word += word
+	return pe
+}
+
+// locate sets the input the error is shown against and its column, from the positions of words
+func locate(err error, words []word, input string) error {
+	var pe *ParseError
+	if !errors.As(err, &pe) || pe.Input != "" {
+		return err
+	}
+	pe.Input = input
+	if pe.Word < len(words) {
+		w := words[pe.Word]
+		pe.Col = min(w.pos+pe.Offset, len(input))
+		if pe.Length == 0 {
+			pe.Length = max(len(w.text)-pe.Offset, 1)
+		}
+	} else {
+		pe.Col = len(input)
+	}
+	return pe
+}
+
+// atLine prefixes an error with the line of input it was found on
+func atLine(n int, err error) error {
+	var pe *ParseError
+	if errors.As(err, &pe) {
+		pe.Msg = fmt.Sprintf("line %d: %s", n, pe.Msg)
+		return pe
+	}
+	return fmt.Errorf("line %d: %w", n, err)
+}
+
+// Render returns the error with its code, the input with a caret under the problem, and a hint
+//
+// Example:
+//
+//	error[date-invalid]: invalid date: tommorow
+//	  Buy milk @ tommorow 5pm
+//	             ^^^^^^^^
+//	  hint: did you mean 'tomorrow' or 'tmrw'?
+func (e *ParseError) Render() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "error[%s]: %s\n", e.Code, e.Msg)
+	if e.Input != "" {
+		pad := utf8.RuneCountInString(e.Input[:e.Col])
+		n := max(utf8.RuneCountInString(e.Input[e.Col:min(e.Col+e.Length, len(e.Input))]), 1)
+		fmt.Fprintf(&b, "  %s\n  %s%s\n", e.Input, strings.Repeat(" ", pad), strings.Repeat("^", n))
+	}
+	if e.Hint != "" {
+		fmt.Fprintf(&b, "  hint: %s\n", e.Hint)
+	}
+	return b.String()
+}
+
+// fatal reports err and exits. Parse errors are rendered with their input and exit with status 2.
+func fatal(err error) {
+	var pe *ParseError
+	if !errors.As(err, &pe) {
+		log.Fatal(err)
+	}
+	fmt.Fprint(os.Stderr, pe.Render())
+	os.Exit(2)
+}
+
+// locateArgs locates a ParseError within command line arguments, one word each
+func locateArgs(err error, args []string) error {
+	input, words := displayArgs(args)
+	return locate(err, words, input)
+}
+
+// displayArgs joins command line arguments as they would be typed, quoting those with spaces,
+// and returns their words with the position of each
+func displayArgs(args []string) (string, []word) {
+	var b strings.Builder
+	words := make([]word, 0, len(args))
+	for i, a := range args {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		pos := b.Len()
+		if a == "" || strings.ContainsAny(a, " \t\n") {
+			b.WriteString("'" + a + "'")
+		} else {
+			b.WriteString(a)
+		}
+		words = append(words, word{text: a, pos: pos})
+	}
+	return b.String(), words
+}
+
+// dateAbbrevs are the short forms suggested along with a keyword
+var dateAbbrevs = map[string]string{
+	"tomorrow":  "tmrw",
+	"today":     "tod",
+	"yesterday": "yest",
+}
+
+// suggestDate returns the date keywords closest to a misspelled word, or nil if none is close
+func suggestDate(s string) []string {
+	s = strings.ToLower(s)
+	vocab := []string{"now", "today", "tomorrow", "yesterday", "eod", "eow", "eom", "eoq", "eoy", "next"}
+	for w := range weekdays {
+		vocab = append(vocab, w)
+	}
+	for m := range months {
+		vocab = append(vocab, m)
+	}
+	sort.Strings(vocab)
+
+	best, dist := "", 3
+	if utf8.RuneCountInString(s) <= 4 {
+		dist = 2 // short words are easily close to anything
+	}
+	for _, w := range vocab {
+		if d := editDistance(s, w); d < dist {
+			best, dist = w, d
+		}
+	}
+	if best == "" || best == s {
+		return nil
+	}
+	if a, ok := dateAbbrevs[best]; ok {
+		return []string{best, a}
+	}
+	return []string{best}
+}
+
+// didYouMean formats suggestions as a hint, e.g. "did you mean 'tomorrow' or 'tmrw'?"
+func didYouMean(s []string) string {
+	return "did you mean '" + strings.Join(s, "' or '") + "'?"
+}
+
+// editDistance returns the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
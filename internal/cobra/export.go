@@ -0,0 +1,236 @@
+package cobra
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/EvoSched/gotask/internal/config"
+	"github.com/EvoSched/gotask/internal/types"
+	"github.com/spf13/cobra"
+)
+
+// exportVersion is bumped whenever the layout of taskRecord changes incompatibly
+const exportVersion = 1
+
+// exportFile is the document written by 'gt export' and read by 'gt import'
+type exportFile struct {
+	Version        int          `json:"version"`
+	PriorityScheme string       `json:"priority_scheme,omitempty"` // scheme the priorities were stored under
+	Tasks          []taskRecord `json:"tasks"`
+}
+
+// taskRecord is the serialized form of a task, independent of the database layout
+type taskRecord struct {
+	ID          int               `json:"id"`
+	UUID        string            `json:"uuid"`
+	Desc        string            `json:"desc"`
+	Priority    int               `json:"priority"`
+	Tags        []string          `json:"tags,omitempty"`
+	Notes       []string          `json:"notes,omitempty"`
+	Attrs       map[string]string `json:"attrs,omitempty"`
+	Links       []linkRecord      `json:"links,omitempty"` // outgoing links only, incoming ones are exported by their source
+	StartAt     *time.Time        `json:"start_at,omitempty"`
+	EndAt       *time.Time        `json:"end_at,omitempty"`
+	UpdatedAt   *time.Time        `json:"updated_at,omitempty"`
+	CompletedAt *time.Time        `json:"completed_at,omitempty"`
+	Finished    bool              `json:"finished"`
+}
+
+// linkRecord is an outgoing link, referencing the linked task by UUID so it survives renumbering
+type linkRecord struct {
+	Kind types.LinkKind `json:"kind"`
+	UUID string         `json:"uuid"`
+}
+
+// newTaskRecord serializes a task, using uuids to look up the UUIDs of linked tasks
+func newTaskRecord(t *types.Task, uuids map[int]string) taskRecord {
+	var links []linkRecord
+	for _, l := range t.Links {
+		if l.FromID == t.ID {
+			links = append(links, linkRecord{l.Kind, uuids[l.ToID]})
+		}
+	}
+	return taskRecord{
+		ID:          t.ShortID,
+		UUID:        t.UUID,
+		Desc:        t.Desc,
+		Priority:    t.Priority,
+		Tags:        t.Tags,
+		Notes:       t.Notes,
+		Attrs:       t.Attrs,
+		Links:       links,
+		StartAt:     t.StartAt,
+		EndAt:       t.EndAt,
+		UpdatedAt:   t.UpdatedAt,
+		CompletedAt: t.CompletedAt,
+		Finished:    t.Finished,
+	}
+}
+
+// task converts the record back into a task, validating attributes declared in config
+func (r taskRecord) task() (*types.Task, error) {
+	t := types.NewTask(r.Desc, r.Priority, r.Tags, r.Notes, r.StartAt, r.EndAt)
+	if r.UUID != "" {
+		t.UUID = strings.ToLower(r.UUID)
+	}
+	if r.UpdatedAt != nil {
+		t.UpdatedAt = r.UpdatedAt
+	}
+	t.CompletedAt = r.CompletedAt
+	t.Finished = r.Finished
+	for n, v := range r.Attrs {
+		if _, ok := udaDefs[n]; ok {
+			a, err := parseAttr(n, v)
+			if err != nil {
+				return nil, err
+			}
+			v = a
+		}
+		if t.Attrs == nil {
+			t.Attrs = make(map[string]string)
+		}
+		t.Attrs[n] = v
+	}
+	return t, nil
+}
+
+func (c *Cmd) ImportCmd() *cobra.Command {
+	importCmd := &cobra.Command{
+		Use:         "import",
+		Annotations: changesTasks,
+		Short:       "Import tasks from a file",
+		Long: `Imports tasks from a file written by 'gt export'. Imported tasks are assigned new IDs but keep their UUIDs,
+so tasks that already exist in the database are skipped.
+
+Required:
+- file  Path of the file to import.`,
+		Example: "gt import tasks.json",
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			c.printf("Importing tasks from '%s'...\n", args[0])
+			b, err := os.ReadFile(args[0])
+			if err != nil {
+				log.Fatal(err)
+			}
+			var f exportFile
+			if err := json.Unmarshal(b, &f); err != nil {
+				log.Fatal(err)
+			}
+			if f.Version != exportVersion {
+				log.Fatalf("unsupported export version: %d", f.Version)
+			}
+			c.printf("  - %d tasks found in the file\n", len(f.Tasks))
+			scheme := priorities
+			if f.PriorityScheme != "" {
+				scheme, err = config.ParseSignature(f.PriorityScheme)
+				if err != nil {
+					log.Fatal(err)
+				}
+			}
+
+			var tasks []*types.Task
+			for _, r := range f.Tasks {
+				t, err := r.task()
+				if err != nil {
+					log.Fatalf("task %d: %v", r.ID, err)
+				}
+				// convert priorities exported under a different scheme
+				t.Priority = priorities.Convert(scheme, t.Priority)
+				dup, err := c.repo.HasUUID(t.UUID)
+				if err != nil {
+					log.Fatal(err)
+				}
+				if dup {
+					c.printf("  - task skipped (duplicate UUID: %s)\n", t.UUID)
+					continue
+				}
+				tasks = append(tasks, t)
+			}
+			for _, t := range tasks {
+				i, err := c.repo.AddTask(t)
+				if err != nil {
+					log.Fatal(err)
+				}
+				c.changedTasks(t)
+				for _, n := range t.Notes {
+					if err := c.repo.AddNote(i, n); err != nil {
+						log.Fatal(err)
+					}
+				}
+			}
+			// links are restored once every task exists, so they can point forward in the file
+			links := 0
+			for _, r := range f.Tasks {
+				if r.UUID == "" {
+					continue
+				}
+				for _, l := range r.Links {
+					if _, err := types.ParseLinkKind(string(l.Kind)); err != nil {
+						log.Fatalf("task %d: %v", r.ID, err)
+					}
+					from, err := c.repo.ResolveID(strings.ToLower(r.UUID))
+					if err != nil {
+						log.Fatal(err)
+					}
+					to, err := c.repo.ResolveID(strings.ToLower(l.UUID))
+					if err != nil {
+						c.printf("  - link skipped (unknown task: %s)\n", l.UUID)
+						continue
+					}
+					if err := c.repo.AddLink(&types.Link{FromID: from, ToID: to, Kind: l.Kind}); err != nil {
+						log.Fatal(err)
+					}
+					links++
+				}
+			}
+			c.printf("\nImport complete. %d tasks added, %d links restored.\n", len(tasks), links)
+		},
+	}
+	return importCmd
+}
+
+func (c *Cmd) ExportCmd() *cobra.Command {
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export tasks to file",
+		Long: `Exports all tasks, including their tags, notes, attributes and links, to a JSON file that can be read by 'gt import'.
+
+Required:
+- file  Path of the file to write.`,
+		Example: "gt export tasks.json",
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			c.printf("Exporting tasks to '%s'...\n", args[0])
+			tasks, err := c.repo.GetTasks()
+			if err != nil {
+				log.Fatal(err)
+			}
+			f := exportFile{Version: exportVersion, PriorityScheme: priorities.Signature(), Tasks: []taskRecord{}}
+			uuids := make(map[int]string)
+			for _, t := range tasks {
+				uuids[t.ID] = t.UUID
+			}
+			for _, t := range tasks {
+				// GetTasks doesn't load notes, so fetch each task in full
+				full, err := c.repo.GetTask(t.ID)
+				if err != nil {
+					log.Fatal(err)
+				}
+				f.Tasks = append(f.Tasks, newTaskRecord(full, uuids))
+			}
+			b, err := json.MarshalIndent(f, "", "  ")
+			if err != nil {
+				log.Fatal(err)
+			}
+			if err := os.WriteFile(args[0], b, 0644); err != nil {
+				log.Fatal(err)
+			}
+			c.printf("  - %d tasks exported\n\n", len(f.Tasks))
+			c.printf("Export complete. All tasks saved to '%s'.\n", args[0])
+		},
+	}
+	return exportCmd
+}
@@ -0,0 +1,133 @@
+package cobra
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/EvoSched/gotask/internal/filter"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const filterHelp = `Filters select tasks with the same prefixes used to add them:
+  +tag, -tag               has, or doesn't have, the tag
+  %2, %<3, %>=2, %!=1      compares the priority
+  @ [before|on|by|after] <date>
+                           starts before, on, by the end of, or after the day
+  status:open, status:done open or finished tasks
+  desc~<regex>             description matches a regular expression
+  has:<field>              has notes, tags, attachments, reminders, checklist, links or time
+  name:value               user-defined attribute has the value
+Terms next to each other must all match. Combine them with 'and', 'or', 'not' and parentheses;
+'not' binds tightest, then 'and', then 'or'. One-letter flags like -o go before the filter.`
+
+// filterResolver resolves filter values with the parser settings from config
+type filterResolver struct{}
+
+func (filterResolver) Priority(s string) (int, error) {
+	return priorities.Parse(s)
+}
+
+func (filterResolver) Date(words []string) (time.Time, int, error) {
+	d, n, err := parseDatePhrase(words)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	return *d, n, nil
+}
+
+func (filterResolver) Attr(name, value string) (string, error) {
+	return parseAttr(name, value)
+}
+
+// parseFilter parses filter arguments; no arguments return a nil filter, matching every task.
+// Errors are ParseErrors pointing at the token at fault.
+func parseFilter(args []string) (filter.Expr, error) {
+	f, err := filter.Parse(args, filterResolver{})
+	var fe *filter.Error
+	if !errors.As(err, &fe) {
+		return f, err
+	}
+	pe := parseErr(CodeFilterInvalid, "invalid filter: %s", fe.Msg)
+	if fe.Token == "" {
+		pe.Word = len(args) // an expression cut short is reported at its end
+	} else {
+		pe.Word, pe.Length = fe.Arg, len(fe.Token)
+		pe.Offset = max(strings.Index(args[fe.Arg], fe.Token), 0)
+	}
+	if strings.Contains(fe.Msg, "date") {
+		if s := suggestDate(fe.Token); s != nil {
+			pe.hint("%s", didYouMean(s))
+		}
+	}
+	input, words := displayArgs(args)
+	return nil, locate(pe, words, input)
+}
+
+// filterCmd configures a command taking a filter. Flag parsing is disabled so '-tag' reaches the
+// filter, and the returned function sets the command's flags from the arguments itself, returning
+// the rest and whether help was requested instead.
+func (c *Cmd) filterCmd(cmd *cobra.Command) func(args []string) ([]string, bool) {
+	cmd.DisableFlagParsing = true
+	cmd.Long += "\n\n" + filterHelp
+	return func(args []string) ([]string, bool) {
+		for _, a := range args {
+			if a == "-h" || a == "--help" {
+				if err := cmd.Help(); err != nil {
+					c.println(err)
+				}
+				return nil, true
+			}
+		}
+		args, err := takeFlags(cmd, args)
+		if err != nil {
+			log.Fatal(err)
+		}
+		c.setupOutput(cmd)
+		return args, false
+	}
+}
+
+// takeFlags sets the flags of a command that doesn't parse them from args, returning the other
+// arguments. Only words naming a flag the command has are taken, so filter terms like '-work'
+// are left alone, and nothing after '--' is. Shorthands like '-o' are only taken before the
+// first other argument, after which they are negated one-letter tags.
+func takeFlags(cmd *cobra.Command, args []string) ([]string, error) {
+	flags := cmd.Flags()
+	flags.AddFlagSet(cmd.InheritedFlags())
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		if a == "--" {
+			return append(rest, args[i:]...), nil
+		}
+		name, v, hasValue := strings.Cut(a, "=")
+		var f *pflag.Flag
+		if n, ok := strings.CutPrefix(name, "--"); ok {
+			f = flags.Lookup(n)
+		} else if len(name) == 2 && name[0] == '-' && len(rest) == 0 {
+			f = flags.ShorthandLookup(name[1:])
+		}
+		if f == nil {
+			rest = append(rest, a)
+			continue
+		}
+		if !hasValue {
+			if f.NoOptDefVal != "" {
+				v = f.NoOptDefVal
+			} else if i+1 < len(args) {
+				i++
+				v = args[i]
+			} else {
+				return nil, fmt.Errorf("flag needs an argument: %s", name)
+			}
+		}
+		if err := flags.Set(f.Name, v); err != nil {
+			return nil, fmt.Errorf("invalid argument %q for %s: %v", v, name, err)
+		}
+	}
+	return rest, nil
+}
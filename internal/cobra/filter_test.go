@@ -0,0 +1,64 @@
+package cobra
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestTakeFlags(t *testing.T) {
+	tests := []struct {
+		args         []string
+		rest         string
+		output, sort string
+		relative     bool
+		next         int
+	}{
+		// filter terms that look like flags are left alone
+		{[]string{"+work", "-home", "--sort", "due"}, "+work -home", "", "due", false, 0},
+		{[]string{"-o", "json", "-work", "status:open"}, "-work status:open", "json", "", false, 0},
+		{[]string{"--output=csv", "--relative", "%1"}, "%1", "csv", "", true, 0},
+		{[]string{"-x", "--bogus", "+a"}, "-x --bogus +a", "", "", false, 0},
+		// after a filter term, a shorthand is a negated one-letter tag
+		{[]string{"+work", "-o", "--sort", "due"}, "+work -o", "", "due", false, 0},
+		{[]string{"-o", "json", "-x", "-o"}, "-x -o", "json", "", false, 0},
+		// optional values don't take the next word
+		{[]string{"--next", "+work"}, "+work", "", "", false, 1},
+		{[]string{"--next=3"}, "", "", "", false, 3},
+		// nothing after '--' is a flag
+		{[]string{"+work", "--", "--sort", "-home"}, "+work -- --sort -home", "", "", false, 0},
+	}
+	for _, tt := range tests {
+		var output, sort string
+		var relative bool
+		var next int
+		root := &cobra.Command{Use: "gt"}
+		root.PersistentFlags().StringVarP(&output, "output", "o", "", "")
+		cmd := &cobra.Command{Use: "list"}
+		cmd.Flags().StringVar(&sort, "sort", "", "")
+		cmd.Flags().BoolVar(&relative, "relative", false, "")
+		cmd.Flags().IntVar(&next, "next", 0, "")
+		cmd.Flags().Lookup("next").NoOptDefVal = "1"
+		root.AddCommand(cmd)
+
+		rest, err := takeFlags(cmd, tt.args)
+		if err != nil {
+			t.Errorf("takeFlags(%q) error: %v", tt.args, err)
+			continue
+		}
+		if strings.Join(rest, " ") != tt.rest || output != tt.output || sort != tt.sort || relative != tt.relative || next != tt.next {
+			t.Errorf("takeFlags(%q) = %q with output %q, sort %q, relative %v, next %d, want %q, %q, %q, %v, %d",
+				tt.args, rest, output, sort, relative, next, tt.rest, tt.output, tt.sort, tt.relative, tt.next)
+		}
+	}
+
+	for _, args := range [][]string{{"+work", "--sort"}, {"--relative=maybe"}} {
+		cmd := &cobra.Command{Use: "list"}
+		cmd.Flags().String("sort", "", "")
+		cmd.Flags().Bool("relative", false, "")
+		if _, err := takeFlags(cmd, args); err == nil {
+			t.Errorf("takeFlags(%q) gave no error", args)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package cobra
+
+import (
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/EvoSched/gotask/internal/types"
+)
+
+// formatCommand returns the canonical 'gt add' command for a task, which parses back to the same
+// description, tags, priority, time, attributes and reminders
+//
+// Example output:
+//
+//	gt add 'Buy milk' +HOME @ 2024-11-01 17:00 %3 store:corner remind:-30m
+//
+// The description is quoted unless it is a single plain word, so no part of it is read as a
+// property. Dates are written in ISO form, which every locale accepts, and times to the minute.
+// Notes, checklists, attachments, links and status aren't part of the command.
+func formatCommand(t *types.Task) string {
+	return "gt add " + strings.Join(taskWords(t), " ")
+}
+
+// taskWords returns the words of the canonical command for a task, each quoted for a shell
+func taskWords(t *types.Task) []string {
+	words := []string{shellQuote(t.Desc)}
+
+	tags := append([]string(nil), t.Tags...)
+	sort.Strings(tags)
+	for _, tg := range tags {
+		words = append(words, "+"+shellEscape(tg))
+	}
+	if tw := timeWords(t.StartAt, t.EndAt); tw != nil {
+		words = append(words, "@")
+		words = append(words, tw...)
+	}
+	words = append(words, "%"+shellEscape(priorities.Format(t.Priority)))
+	for _, n := range sortedKeys(t.Attrs) {
+		words = append(words, n+":"+shellEscape(t.Attrs[n]))
+	}
+	for _, r := range t.Reminders {
+		if r.Offset != nil {
+			words = append(words, "remind:"+offsetWord(*r.Offset))
+		} else if r.At != nil {
+			words = append(words, "remind:"+r.At.Format(reminderAtFmt))
+		}
+	}
+	return words
+}
+
+// timeWords returns the time expression following '@' for a start and end, or nil without a time
+// A task with only an end is written with it as the start, the only form the parser produces
+//
+// Example outputs:
+//   - 2024-11-01                          (a date, due at 23:59)
+//   - 2024-11-01 17:00                    (a date and time)
+//   - 2024-11-01 14:00-16:00              (a range within a day)
+//   - 2024-11-01..2024-11-05              (whole days)
+//   - 2024-11-01 22:00 - 2024-11-02 02:00 (a range across days)
+func timeWords(start, end *time.Time) []string {
+	if start == nil {
+		start, end = end, nil
+	}
+	if start == nil {
+		return nil
+	}
+	day := start.Format(time.DateOnly)
+	clock := start.Format(ClockFmt24h)
+	if end == nil {
+		if start.Hour() == 23 && start.Minute() == 59 {
+			return []string{day}
+		}
+		return []string{day, clock}
+	}
+
+	endDay := end.Format(time.DateOnly)
+	nextDay := start.AddDate(0, 0, 1).Format(time.DateOnly)
+	switch {
+	case endDay == day:
+		return []string{day, clock + "-" + end.Format(ClockFmt24h)}
+	case endDay == nextDay && end.Hour() == 0 && end.Minute() == 0:
+		// an end at midnight closes the start's day
+		return []string{day, clock + "-00:00"}
+	case start.Hour() == 0 && start.Minute() == 0 && end.Hour() == 23 && end.Minute() == 59:
+		return []string{day + ".." + endDay}
+	}
+	return []string{day, clock, "-", endDay, end.Format(ClockFmt24h)}
+}
+
+// offsetWord formats a reminder offset with its sign, e.g. "-30m", "+1h", "-1d2h"
+func offsetWord(d time.Duration) string {
+	s := formatDuration(d)
+	if d%time.Minute != 0 {
+		s = d.String()
+	}
+	if d >= 0 {
+		s = "+" + s
+	}
+	return s
+}
+
+// plainRune reports whether r is kept as is by both a shell and the task lexer
+func plainRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("_-+=@%:,./^", r)
+}
+
+// shellQuote returns s as one literal word: bare if it needs no quoting, in single quotes if it
+// has none itself, otherwise in double quotes with '"', '\', '$' and '`' escaped
+func shellQuote(s string) string {
+	if s != "" && strings.IndexFunc(s, func(r rune) bool { return !plainRune(r) }) < 0 &&
+		!strings.ContainsAny(s[:1], "+@%!-") && !strings.Contains(s, ":") {
+		return s
+	}
+	if !strings.Contains(s, "'") {
+		return "'" + s + "'"
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`, "`", "\\`").Replace(s) + `"`
+}
+
+// shellEscape escapes the special characters of s with backslashes, keeping it one unquoted word
+// so a prefix before it like '+' or 'name:' still takes effect
+func shellEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if !plainRune(r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
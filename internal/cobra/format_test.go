@@ -0,0 +1,187 @@
+package cobra
+
+import (
+	"maps"
+	"math/rand"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"testing/quick"
+	"time"
+
+	"github.com/EvoSched/gotask/internal/config"
+	"github.com/EvoSched/gotask/internal/types"
+)
+
+func withUDAs(t *testing.T) {
+	t.Helper()
+	prev := udaDefs
+	udaDefs = map[string]config.UDA{
+		"store":  {Type: config.UDAString},
+		"points": {Type: config.UDANumber},
+		"review": {Type: config.UDADate},
+		"est":    {Type: config.UDADuration},
+		"size":   {Type: config.UDAEnum, Values: []string{"S", "M", "L"}},
+	}
+	t.Cleanup(func() { udaDefs = prev })
+}
+
+func TestFormatCommand(t *testing.T) {
+	withUDAs(t)
+	at := func(d, h, m int) *time.Time {
+		v := time.Date(2024, time.November, d, h, m, 0, 0, time.UTC)
+		return &v
+	}
+	offset := -30 * time.Minute
+	tests := []struct {
+		task *types.Task
+		want string
+	}{
+		{&types.Task{Desc: "Groceries", Priority: 3}, "gt add Groceries %3"},
+		{&types.Task{Desc: "Buy milk", Priority: 2, Tags: []string{"WORK", "HOME"}, StartAt: at(1, 23, 59)},
+			"gt add 'Buy milk' +HOME +WORK @ 2024-11-01 %2"},
+		{&types.Task{Desc: "Call +1 555", Priority: 1, StartAt: at(1, 17, 0),
+			Reminders: []types.Reminder{{Offset: &offset}, {At: at(1, 9, 5)}}},
+			"gt add 'Call +1 555' @ 2024-11-01 17:00 %1 remind:-30m remind:2024-11-01T09:05"},
+		{&types.Task{Desc: "it's $5", Priority: 3, StartAt: at(1, 14, 0), EndAt: at(1, 16, 30)},
+			`gt add "it's \$5" @ 2024-11-01 14:00-16:30 %3`},
+		{&types.Task{Desc: "Party", Priority: 3, StartAt: at(1, 22, 0), EndAt: at(2, 0, 0)},
+			"gt add Party @ 2024-11-01 22:00-00:00 %3"},
+		{&types.Task{Desc: "Trip", Priority: 3, StartAt: at(1, 0, 0), EndAt: at(5, 23, 59)},
+			"gt add Trip @ 2024-11-01..2024-11-05 %3"},
+		{&types.Task{Desc: "Shift", Priority: 3, StartAt: at(1, 22, 0), EndAt: at(2, 6, 0)},
+			"gt add Shift @ 2024-11-01 22:00 - 2024-11-02 06:00 %3"},
+		{&types.Task{Desc: "Pick", Priority: 3, Tags: []string{"A&B"}, Attrs: map[string]string{"store": "corner shop", "size": "M"}},
+			`gt add Pick +A\&B %3 size:M store:corner\ shop`},
+	}
+	for _, tt := range tests {
+		if got := formatCommand(tt.task); got != tt.want {
+			t.Errorf("formatCommand(%q) = %s, want %s", tt.task.Desc, got, tt.want)
+		}
+	}
+}
+
+// quickTask generates tasks of every shape the parser can produce, with descriptions, tags and
+// attribute values full of characters that need quoting
+type quickTask struct{ *types.Task }
+
+var (
+	descWords = []string{"Buy", "milk", "+1", "@", "tmrw", "%2", "5pm", "it's", `say "hi"`, "$HOME",
+		`back\slash`, "x:y", "store:corner", "`cmd`", "Grüße", "#3", "-x", "remind:-1h", "a  b", "!", "*"}
+	tagNames    = []string{"HOME", "WORK", "A&B", "X'Y", "ÜBER", "1"}
+	storeValues = []string{"corner", "corner shop", "it's", `"q"`, "$1", "a:b"}
+)
+
+func (quickTask) Generate(r *rand.Rand, size int) reflect.Value {
+	pick := func(s []string) string { return s[r.Intn(len(s))] }
+	minute := func(d time.Time) time.Time { return d.Add(time.Duration(r.Intn(24*60)) * time.Minute) }
+
+	var desc []string
+	for n := 1 + r.Intn(4); n > 0; n-- {
+		desc = append(desc, pick(descWords))
+	}
+	t := &types.Task{Desc: strings.Join(desc, " "), Priority: 1 + r.Intn(5)}
+	for _, tg := range tagNames {
+		if r.Intn(4) == 0 {
+			t.Tags = append(t.Tags, tg)
+		}
+	}
+
+	day := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, r.Intn(800))
+	set := func(start, end time.Time) { t.StartAt, t.EndAt = &start, &end }
+	switch r.Intn(7) {
+	case 1: // a date, due at the end of the day
+		d := day.Add(23*time.Hour + 59*time.Minute)
+		t.StartAt = &d
+	case 2: // a date and time
+		d := minute(day)
+		t.StartAt = &d
+	case 3: // a range within a day
+		s := day.Add(time.Duration(r.Intn(23*60)) * time.Minute)
+		set(s, s.Add(time.Duration(1+r.Intn(int(day.Add(24*time.Hour-time.Minute).Sub(s).Minutes())))*time.Minute))
+	case 4: // a range ending at midnight
+		set(minute(day), day.AddDate(0, 0, 1))
+	case 5: // whole days
+		set(day, day.AddDate(0, 0, 1+r.Intn(5)).Add(23*time.Hour+59*time.Minute))
+	case 6: // a range across days
+		set(minute(day), minute(day.AddDate(0, 0, 1+r.Intn(5))))
+	}
+
+	attrs := map[string]string{
+		"store":  pick(storeValues),
+		"points": pick([]string{"3", "2.5", "-1"}),
+		"review": day.Format(time.DateOnly),
+		"est":    pick([]string{"1h30m0s", "48h0m0s", "15m0s"}),
+		"size":   pick([]string{"S", "M", "L"}),
+	}
+	for n, v := range attrs {
+		if r.Intn(3) == 0 {
+			if t.Attrs == nil {
+				t.Attrs = make(map[string]string)
+			}
+			t.Attrs[n] = v
+		}
+	}
+
+	for n := r.Intn(3); n > 0; n-- {
+		if t.StartAt != nil && r.Intn(2) == 0 {
+			d := time.Duration(r.Intn(4*24*60)-2*24*60) * time.Minute
+			if r.Intn(4) == 0 {
+				d += 30 * time.Second
+			}
+			t.Reminders = append(t.Reminders, types.Reminder{Offset: &d})
+		} else {
+			d := minute(day)
+			t.Reminders = append(t.Reminders, types.Reminder{At: &d})
+		}
+	}
+	return reflect.ValueOf(quickTask{t})
+}
+
+// sameTask reports whether b has the fields of a that the canonical command holds
+func sameTask(a, b *types.Task) bool {
+	sameTime := func(x, y *time.Time) bool { return (x == nil) == (y == nil) && (x == nil || x.Equal(*y)) }
+	if a.Desc != b.Desc || a.Priority != b.Priority || !sameTime(a.StartAt, b.StartAt) || !sameTime(a.EndAt, b.EndAt) {
+		return false
+	}
+	ta, tb := slices.Clone(a.Tags), slices.Clone(b.Tags)
+	slices.Sort(ta)
+	slices.Sort(tb)
+	if !slices.Equal(ta, tb) || !maps.Equal(a.Attrs, b.Attrs) || len(a.Reminders) != len(b.Reminders) {
+		return false
+	}
+	for i, r := range a.Reminders {
+		s := b.Reminders[i]
+		if (r.Offset == nil) != (s.Offset == nil) || (r.Offset != nil && *r.Offset != *s.Offset) || !sameTime(r.At, s.At) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestFormatCommandRoundTrip(t *testing.T) {
+	withClock(t, fixedNow)
+	withUDAs(t)
+	roundTrip := func(q quickTask) bool {
+		line := formatCommand(q.Task)
+		ti, err := parseLine(strings.TrimPrefix(line, "gt add "))
+		if err != nil {
+			t.Logf("%s: %v", line, err)
+			return false
+		}
+		got, err := newTask(ti)
+		if err != nil {
+			t.Logf("%s: %v", line, err)
+			return false
+		}
+		if !sameTask(q.Task, got) {
+			t.Logf("%s parsed back as %s", line, formatCommand(got))
+			return false
+		}
+		return true
+	}
+	if err := quick.Check(roundTrip, &quick.Config{MaxCount: 2000, Rand: rand.New(rand.NewSource(1))}); err != nil {
+		t.Error(err)
+	}
+}
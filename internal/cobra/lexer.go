@@ -0,0 +1,124 @@
+package cobra
+
+import (
+	"strings"
+	"unicode"
+)
+
+// word is a token of task input. Literal words were quoted or escaped, so they are always
+// part of the description and never read as a tag, time, priority or attribute.
+type word struct {
+	text    string
+	literal bool
+	pos     int // byte offset of the word in the input, for error messages
+}
+
+// splitLine splits a line of task input into words much like a shell: whitespace separates words,
+// '...' and "..." starting a word group them, and a backslash escapes the next character (inside
+// double quotes only before '"', '\', '$' or '`'). Quotes inside a word are kept, as in "don't".
+// A word starting with a quoted or escaped part is literal; escapes later in a word only keep
+// characters from the shell, as in +a\&b.
+//
+// Example inputs:
+//   - Buy milk +home @ tmrw 5pm  -> [Buy milk +home @ tmrw 5pm]
+//   - "Call +1 555" %2           -> [Call +1 555 (literal), %2]
+//   - \+1 reviewer               -> [+1 (literal), reviewer]
+//   - sprint:week\ 12            -> [sprint:week 12]
+func splitLine(line string) ([]word, error) {
+	var words []word
+	var b strings.Builder
+	var quote rune
+	inWord, literal, escaped := false, false, false
+	start, quoteAt, escapeAt := 0, 0, 0
+	for i, r := range line {
+		if !inWord && !unicode.IsSpace(r) {
+			start = i
+		}
+		switch {
+		case escaped:
+			if quote == '"' && !strings.ContainsRune("\"\\$`", r) {
+				b.WriteRune('\\')
+			}
+			b.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			literal = literal || !inWord
+			inWord, escaped, escapeAt = true, true, i
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			b.WriteRune(r)
+		case (r == '\'' || r == '"') && !inWord:
+			inWord, literal, quote, quoteAt = true, true, r, i
+		case unicode.IsSpace(r):
+			if inWord {
+				words = append(words, word{b.String(), literal, start})
+				b.Reset()
+				inWord, literal = false, false
+			}
+		default:
+			inWord = true
+			b.WriteRune(r)
+		}
+	}
+	switch {
+	case escaped:
+		e := parseErr(CodeEscapeTrailing, "trailing '\\' escapes nothing").hint("write '\\\\' for a backslash")
+		e.Input, e.Col, e.Length = line, escapeAt, 1
+		return nil, e
+	case quote != 0:
+		e := parseErr(CodeQuoteUnterminated, "unterminated quote: %c", quote).hint("close it with %c", quote)
+		e.Input, e.Col, e.Length = line, quoteAt, 1
+		return nil, e
+	case inWord:
+		words = append(words, word{b.String(), literal, start})
+	}
+	return words, nil
+}
+
+// argWords converts command line arguments to words, returning them with the arguments as they
+// would be typed. The shell has already split and unquoted them, so an argument containing
+// whitespace was quoted on purpose and is taken literally, as descriptions always were, unless it
+// sets an attribute (e.g. 'customer:acme corp'), and a leading backslash escapes a prefix, e.g.
+// '\+1' or \\+1.
+func argWords(args []string) ([]word, string) {
+	input, all := displayArgs(args)
+	var words []word
+	for _, w := range all {
+		a := w.text
+		switch {
+		case a == "":
+		case strings.IndexFunc(a, unicode.IsSpace) >= 0:
+			_, _, attr := splitAttr(a)
+			words = append(words, word{a, !attr, w.pos})
+		case a[0] == '\\' && len(a) > 1:
+			words = append(words, word{a[1:], true, w.pos + 1})
+		default:
+			words = append(words, word{a, false, w.pos})
+		}
+	}
+	return words, input
+}
+
+// cliArgs returns the arguments a command would be given for words, which argWords reads back as
+// the same words: literal words are escaped with a leading backslash unless whitespace already
+// makes them literal
+func cliArgs(words []word) []string {
+	args := make([]string, len(words))
+	for i, w := range words {
+		args[i] = w.text
+		if w.literal && strings.IndexFunc(w.text, unicode.IsSpace) < 0 {
+			args[i] = `\` + w.text
+		}
+	}
+	return args
+}
+
+// texts returns the text of each word
+func texts(words []word) []string {
+	s := make([]string, len(words))
+	for i, w := range words {
+		s[i] = w.text
+	}
+	return s
+}
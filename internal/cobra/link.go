@@ -0,0 +1,178 @@
+package cobra
+
+import (
+	"log"
+
+	"github.com/EvoSched/gotask/internal/types"
+	"github.com/spf13/cobra"
+)
+
+func (c *Cmd) LinkCmd() *cobra.Command {
+	var kind string
+	linkCmd := &cobra.Command{
+		Use:         "link",
+		Annotations: changesTasks,
+		Short:       "Link a task to another task",
+		Long: `Records a typed link from the first task to the second. Links are listed by 'gt get' on both tasks.
+Writing '#id' in a description or note links the task to the referenced task automatically.
+
+Required:
+- id     Id referencing the task the link is from.
+- other  Id referencing the task the link is to.`,
+		Example: `gt link 4 2
+gt link 5 2 --type follows`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			k, err := types.ParseLinkKind(kind)
+			if err != nil {
+				log.Fatal(err)
+			}
+			from, to := c.linkPair(args)
+			err = c.repo.AddLink(&types.Link{FromID: from.ID, ToID: to.ID, Kind: k})
+			if err != nil {
+				log.Fatal(err)
+			}
+			c.printf("Task %d '%s' %s task %d '%s'.\n", from.ShortID, from.Desc, k.Outgoing(), to.ShortID, to.Desc)
+			c.changedTasks(from, to)
+		},
+	}
+	linkCmd.Flags().StringVarP(&kind, "type", "t", string(types.LinkRelates), "type of link: relates, duplicates or follows")
+	return linkCmd
+}
+
+func (c *Cmd) UnlinkCmd() *cobra.Command {
+	unlinkCmd := &cobra.Command{
+		Use:         "unlink",
+		Annotations: changesTasks,
+		Short:       "Remove the links between two tasks",
+		Long: `Removes every link between two tasks, in either direction.
+
+Required:
+- id     Id referencing a task.
+- other  Id referencing the other task.`,
+		Example: "gt unlink 4 2",
+		Args:    cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			a, b := c.linkPair(args)
+			n, err := c.repo.DeleteLink(a.ID, b.ID)
+			if err != nil {
+				log.Fatal(err)
+			}
+			if n == 0 {
+				log.Fatalf("tasks %d and %d are not linked", a.ShortID, b.ShortID)
+			}
+			c.printf("Removed %d link(s) between task %d and task %d.\n", n, a.ShortID, b.ShortID)
+			c.changedTasks(a, b)
+		},
+	}
+	return unlinkCmd
+}
+
+func (c *Cmd) DupCmd() *cobra.Command {
+	var of string
+	dupCmd := &cobra.Command{
+		Use:         "dup",
+		Annotations: changesTasks,
+		Short:       "Close a task as a duplicate of another",
+		Long: `Marks a task as complete and links it as a duplicate of the task given by '--of'.
+
+Required:
+- id    Id referencing the duplicate task.
+- --of  Id referencing the task it duplicates.`,
+		Example: "gt dup 7 --of 3",
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			dup, orig := c.linkPair([]string{args[0], of})
+			err := c.repo.AddLink(&types.Link{FromID: dup.ID, ToID: orig.ID, Kind: types.LinkDuplicates})
+			if err != nil {
+				log.Fatal(err)
+			}
+			if !dup.Finished {
+				err = c.repo.UpdateStatus(dup.ID, true)
+				if err != nil {
+					log.Fatal(err)
+				}
+			}
+			c.printf("Closed task %d '%s' as a duplicate of task %d '%s'.\n", dup.ShortID, dup.Desc, orig.ShortID, orig.Desc)
+			c.changedTasks(dup, orig)
+		},
+	}
+	dupCmd.Flags().StringVar(&of, "of", "", "id of the task this one duplicates")
+	_ = dupCmd.MarkFlagRequired("of")
+	return dupCmd
+}
+
+// linkPair returns the two distinct tasks referenced by args
+func (c *Cmd) linkPair(args []string) (*types.Task, *types.Task) {
+	a, b, err := parseLink(args)
+	if err != nil {
+		fatal(err)
+	}
+	ids, err := c.resolveIDs([]string{a, b})
+	if err != nil {
+		log.Fatal(err)
+	}
+	if ids[0] == ids[1] {
+		log.Fatal("a task cannot be linked to itself")
+	}
+	from, err := c.repo.GetTask(ids[0])
+	if err != nil {
+		log.Fatal(err)
+	}
+	to, err := c.repo.GetTask(ids[1])
+	if err != nil {
+		log.Fatal(err)
+	}
+	return from, to
+}
+
+// autoLink links t to the tasks mentioned as '#id' in text, skipping mentions of unknown tasks
+func (c *Cmd) autoLink(t *types.Task, text string) {
+	linked, err := c.linkMentions(t, text)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, o := range linked {
+		c.printf("  - Linked to task %d '%s'\n", o.ShortID, o.Desc)
+	}
+}
+
+// linkMentions links t to the tasks mentioned as '#id' in text, returning the tasks linked
+func (c *Cmd) linkMentions(t *types.Task, text string) ([]*types.Task, error) {
+	var linked []*types.Task
+	for _, ref := range parseMentions(text) {
+		id, err := c.resolveID(ref)
+		if err != nil || id == t.ID {
+			continue
+		}
+		o, err := c.repo.GetTask(id)
+		if err != nil {
+			return linked, err
+		}
+		err = c.repo.AddLink(&types.Link{FromID: t.ID, ToID: id, Kind: types.LinkRelates})
+		if err != nil {
+			return linked, err
+		}
+		linked = append(linked, o)
+	}
+	return linked, nil
+}
+
+// displayLinks prints the links from and to a task along with the linked tasks
+func (c *Cmd) displayLinks(t *types.Task) {
+	if len(t.Links) == 0 {
+		return
+	}
+	c.printf("\nLinks:\n")
+	for _, l := range t.Links {
+		phrase, other := l.Kind.Outgoing(), l.ToID
+		if l.ToID == t.ID {
+			phrase, other = l.Kind.Incoming(), l.FromID
+		}
+		o, err := c.repo.GetTask(other)
+		if err != nil {
+			log.Fatal(err)
+		}
+		c.printf("  - %-15s %s %d %s\n", phrase, formatCheck(o.Finished), o.ShortID, o.Desc)
+	}
+}
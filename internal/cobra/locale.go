@@ -0,0 +1,135 @@
+package cobra
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/EvoSched/gotask/internal/config"
+)
+
+const (
+	DateFmtDMY      = "02-01-2006"
+	DateFmtDMYSlash = "02/01/2006"
+
+	ClockFmt12h = "03:04pm"
+	ClockFmt24h = "15:04"
+)
+
+// dateLayouts are the numeric date layouts accepted for each date order. ISO dates are always accepted.
+var dateLayouts = map[string][]string{
+	config.DateOrderDMY: {DateFmtDMY, DateFmtDMYSlash, "2-1-2006", "2/1/2006", "02.01.2006", "2.1.2006"},
+	config.DateOrderMDY: {"01-02-2006", "01/02/2006", "1-2-2006", "1/2/2006"},
+	config.DateOrderYMD: {"2006/01/02", "2006.01.02", "2006/1/2"},
+}
+
+// dayMonthLayouts are the numeric layouts without a year accepted for each date order
+var dayMonthLayouts = map[string][]string{
+	config.DateOrderDMY: {"2/1"},
+	config.DateOrderMDY: {"1/2"},
+	config.DateOrderYMD: {"1/2"},
+}
+
+// Locale settings from config, applied by setLocale
+var (
+	// dateFormats are the numeric date layouts the parser accepts, in order of preference
+	dateFormats = []string{DateFmtDMY, DateFmtDMYSlash, time.DateOnly}
+	// dayMonthFormats are the numeric layouts without a year the parser accepts, in order of preference
+	dayMonthFormats = []string{"2/1"}
+	// weekStart is the first day of the week, used by 'eow' and week views
+	weekStart = time.Sunday
+	// dateFmt is the layout dates are shown in
+	dateFmt = "Mon, 02 Jan 2006"
+	// clockFmt and clockFmtLong are the time of day layouts of the configured clock style,
+	// used by listings and detail views respectively
+	clockFmt, clockFmtLong = ClockFmt12h, time.Kitchen
+	// monthNames and dayNames replace the English names in output when set
+	monthNames, shortMonthNames, dayNames, shortDayNames []string
+)
+
+// setLocale applies the locale and display settings from config
+func setLocale(l config.Locale, d config.Display) {
+	dateFormats, dayMonthFormats = nil, nil
+	for _, o := range l.DateOrders {
+		dateFormats = append(dateFormats, dateLayouts[o]...)
+		dayMonthFormats = append(dayMonthFormats, dayMonthLayouts[o]...)
+	}
+	dateFormats = append(dateFormats, time.DateOnly)
+	weekStart, _ = l.FirstWeekday()
+	if l.DateFormat != "" {
+		dateFmt = l.DateFormat
+	}
+	if d.Clock == config.Clock24h {
+		clockFmt, clockFmtLong = ClockFmt24h, ClockFmt24h
+	}
+
+	monthNames, shortMonthNames = l.Months, shortNames(l.Months, l.ShortMonths)
+	dayNames, shortDayNames = l.Weekdays, shortNames(l.Weekdays, l.ShortWeekdays)
+	// Localized names are accepted as input alongside the English ones
+	for i, n := range monthNames {
+		months[strings.ToLower(n)] = time.Month(i + 1)
+		months[strings.ToLower(shortMonthNames[i])] = time.Month(i + 1)
+	}
+	for i, n := range dayNames {
+		weekdays[strings.ToLower(n)] = time.Weekday(i)
+		weekdays[strings.ToLower(shortDayNames[i])] = time.Weekday(i)
+	}
+}
+
+// shortNames returns short, or the first three letters of each name when short isn't set
+func shortNames(names, short []string) []string {
+	if len(short) > 0 || len(names) == 0 {
+		return short
+	}
+	s := make([]string, len(names))
+	for i, n := range names {
+		s[i] = n
+		if utf8.RuneCountInString(n) > 3 {
+			s[i] = string([]rune(n)[:3])
+		}
+	}
+	return s
+}
+
+// Placeholders substituted for name elements of a layout so they survive time.Format
+const (
+	phMonth      = "\x01"
+	phShortMonth = "\x02"
+	phDay        = "\x03"
+	phShortDay   = "\x04"
+)
+
+// formatLocal formats t with layout, using the localized month and day names
+func formatLocal(t time.Time, layout string) string {
+	if monthNames == nil && dayNames == nil {
+		return t.Format(layout)
+	}
+	if monthNames != nil {
+		layout = strings.ReplaceAll(layout, "January", phMonth)
+		layout = strings.ReplaceAll(layout, "Jan", phShortMonth)
+	}
+	if dayNames != nil {
+		layout = strings.ReplaceAll(layout, "Monday", phDay)
+		layout = strings.ReplaceAll(layout, "Mon", phShortDay)
+	}
+	s := t.Format(layout)
+	if monthNames != nil {
+		s = strings.ReplaceAll(s, phMonth, monthNames[t.Month()-1])
+		s = strings.ReplaceAll(s, phShortMonth, shortMonthNames[t.Month()-1])
+	}
+	if dayNames != nil {
+		s = strings.ReplaceAll(s, phDay, dayNames[t.Weekday()])
+		s = strings.ReplaceAll(s, phShortDay, shortDayNames[t.Weekday()])
+	}
+	return s
+}
+
+// formatDate formats the date of t for output, e.g. "Mon, 02 Jan 2006"
+func formatDate(t time.Time) string {
+	return formatLocal(t, dateFmt)
+}
+
+// formatDateTime formats the date and time of t for output, e.g. "Mon, 02 Jan 2006 03:04pm"
+func formatDateTime(t time.Time) string {
+	return formatLocal(t, dateFmt+" "+clockFmt)
+}
@@ -0,0 +1,311 @@
+package cobra
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/EvoSched/gotask/internal/types"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// outputVersion is bumped whenever the fields of taskOutput or resultOutput change incompatibly
+const outputVersion = 1
+
+// outputFormats are the values accepted by --output
+var outputFormats = []string{"json", "yaml", "csv", "tsv", "ndjson"}
+
+// Commands declare what they print with --output through this annotation: the tasks they read,
+// or a result listing the tasks they changed
+const outputAnnotation = "output"
+
+var (
+	readsTasks   = map[string]string{outputAnnotation: "tasks"}
+	changesTasks = map[string]string{outputAnnotation: "result"}
+)
+
+// tasksOutput is the document printed by read commands as json or yaml
+type tasksOutput struct {
+	Version int          `json:"version" yaml:"version"`
+	Tasks   []taskOutput `json:"tasks" yaml:"tasks"`
+}
+
+// taskOutput is the machine-readable form of a task. Times are RFC 3339 with the local zone's
+// offset and null when unset; lists and attributes are empty rather than null.
+type taskOutput struct {
+	ID            int               `json:"id" yaml:"id"`
+	UUID          string            `json:"uuid" yaml:"uuid"`
+	Desc          string            `json:"desc" yaml:"desc"`
+	Status        string            `json:"status" yaml:"status"` // open or done
+	Priority      int               `json:"priority" yaml:"priority"`
+	PriorityLabel string            `json:"priority_label" yaml:"priority_label"` // as shown by 'gt list'
+	Tags          []string          `json:"tags" yaml:"tags"`
+	Attrs         map[string]string `json:"attrs" yaml:"attrs"`
+	Start         *string           `json:"start" yaml:"start"`
+	End           *string           `json:"end" yaml:"end"`
+	Updated       *string           `json:"updated" yaml:"updated"`
+	Completed     *string           `json:"completed" yaml:"completed"`
+	Notes         []string          `json:"notes" yaml:"notes"`
+	Checklist     []checkOutput     `json:"checklist" yaml:"checklist"`
+}
+
+type checkOutput struct {
+	Text string `json:"text" yaml:"text"`
+	Done bool   `json:"done" yaml:"done"`
+}
+
+// resultOutput is printed by commands that change tasks, with the IDs of the tasks changed
+type resultOutput struct {
+	Version int    `json:"version"`
+	Command string `json:"command"`
+	IDs     []int  `json:"ids"`
+}
+
+func newTaskOutput(t *types.Task) taskOutput {
+	o := taskOutput{
+		ID:            t.ShortID,
+		UUID:          t.UUID,
+		Desc:          t.Desc,
+		Status:        "open",
+		Priority:      t.Priority,
+		PriorityLabel: priorities.Format(t.Priority),
+		Tags:          append([]string{}, t.Tags...),
+		Attrs:         map[string]string{},
+		Start:         rfc3339(wallInstant(t.StartAt)),
+		End:           rfc3339(wallInstant(t.EndAt)),
+		Updated:       rfc3339(t.UpdatedAt),
+		Completed:     rfc3339(t.CompletedAt),
+		Notes:         append([]string{}, t.Notes...),
+		Checklist:     []checkOutput{},
+	}
+	if t.Finished {
+		o.Status = "done"
+	}
+	for n, v := range t.Attrs {
+		o.Attrs[n] = v
+	}
+	for _, item := range t.Checklist {
+		o.Checklist = append(o.Checklist, checkOutput{item.Text, item.Done})
+	}
+	return o
+}
+
+// rfc3339 formats an instant in the local zone, nil if it's unset
+func rfc3339(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	s := t.Local().Format(time.RFC3339)
+	return &s
+}
+
+// wallInstant returns the instant a task's wall-clock time stands for, nil if it's unset
+func wallInstant(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	l := types.LocalTime(*t)
+	return &l
+}
+
+// setupOutput checks --output, --format and --template against what the command prints.
+// Commands changing tasks write their messages to stderr instead, keeping stdout for the result.
+func (c *Cmd) setupOutput(cmd *cobra.Command) {
+	if !slices.Contains(colorModes, c.color) {
+		log.Fatalf("unknown color mode %q, expected one of %s", c.color, strings.Join(colorModes, ", "))
+	}
+	if c.stdout != nil {
+		return
+	}
+	set := 0
+	for _, v := range []string{c.output, c.format, c.templateFile} {
+		if v != "" {
+			set++
+		}
+	}
+	switch {
+	case set == 0:
+		return
+	case set > 1:
+		log.Fatal("only one of --output, --format and --template can be given")
+	case c.output != "" && !slices.Contains(outputFormats, c.output):
+		log.Fatalf("unknown output format %q, expected one of %s", c.output, strings.Join(outputFormats, ", "))
+	}
+	switch cmd.Annotations[outputAnnotation] {
+	case "tasks":
+		c.stdout = os.Stdout
+	case "result":
+		if c.output != "json" {
+			log.Fatalf("'%s' only reports its result with --output json", cmd.CommandPath())
+		}
+		c.stdout = os.Stdout
+		c.out = os.Stderr
+	default:
+		log.Fatalf("'%s' has no --output, --format or --template", cmd.CommandPath())
+	}
+}
+
+// printf, println and print write a command's messages
+func (c *Cmd) printf(format string, a ...any) {
+	fmt.Fprintf(c.messages(), format, a...)
+}
+
+func (c *Cmd) println(a ...any) {
+	fmt.Fprintln(c.messages(), a...)
+}
+
+func (c *Cmd) print(a ...any) {
+	fmt.Fprint(c.messages(), a...)
+}
+
+// messages returns where messages are written, stdout unless set up otherwise
+func (c *Cmd) messages() io.Writer {
+	if c.out == nil {
+		return os.Stdout
+	}
+	return c.out
+}
+
+// reportResult prints the result of a command that changed tasks, when asked for with --output
+func (c *Cmd) reportResult(cmd *cobra.Command) {
+	if c.stdout == nil || cmd.Annotations[outputAnnotation] != "result" {
+		return
+	}
+	r := resultOutput{
+		Version: outputVersion,
+		Command: strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" "),
+		IDs:     append([]int{}, c.changed...),
+	}
+	if err := json.NewEncoder(c.stdout).Encode(r); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// changedTasks records the tasks a command changed, for its result
+func (c *Cmd) changedTasks(tasks ...*types.Task) {
+	for _, t := range tasks {
+		c.changed = append(c.changed, t.ShortID)
+	}
+}
+
+// writeTasks prints tasks as asked for with --output, --format or --template. Listings don't
+// load notes or checklists, so each task is read in full first.
+func (c *Cmd) writeTasks(tasks []*types.Task) {
+	full := make([]*types.Task, 0, len(tasks))
+	for _, t := range tasks {
+		ft, err := c.repo.GetTask(t.ID)
+		if err != nil {
+			log.Fatal(err)
+		}
+		full = append(full, ft)
+	}
+	c.printTasks(full)
+}
+
+// printTasks prints tasks read in full as asked for with --output, --format or --template
+func (c *Cmd) printTasks(tasks []*types.Task) {
+	if c.output == "" {
+		c.renderTasks(c.stdout, tasks)
+		return
+	}
+	if err := writeTasks(c.stdout, c.output, tasks); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// writeTasks writes tasks as json, yaml, csv, tsv or ndjson
+func writeTasks(w io.Writer, format string, tasks []*types.Task) error {
+	out := make([]taskOutput, 0, len(tasks))
+	for _, t := range tasks {
+		out = append(out, newTaskOutput(t))
+	}
+	switch format {
+	case "json":
+		b, err := json.MarshalIndent(tasksOutput{outputVersion, out}, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "%s\n", b)
+		return err
+	case "yaml":
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(tasksOutput{outputVersion, out}); err != nil {
+			return err
+		}
+		return enc.Close()
+	case "ndjson":
+		enc := json.NewEncoder(w)
+		for _, o := range out {
+			if err := enc.Encode(o); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "csv":
+		cw := csv.NewWriter(w)
+		if err := cw.WriteAll(taskRows(out)); err != nil {
+			return err
+		}
+		return cw.Error()
+	case "tsv":
+		for _, row := range taskRows(out) {
+			for i, v := range row {
+				row[i] = tsvEscaper.Replace(v)
+			}
+			if _, err := fmt.Fprintln(w, strings.Join(row, "\t")); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown output format %q", format)
+}
+
+// tsvEscaper keeps each field on one line and free of tabs
+var tsvEscaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+
+// taskRows returns a header and a row per task for csv and tsv. Tags are separated by spaces and
+// notes by newlines; each attribute in use has its own 'attr.<name>' column. Checklists are left out.
+func taskRows(tasks []taskOutput) [][]string {
+	var attrs []string
+	for _, t := range tasks {
+		for n := range t.Attrs {
+			if !slices.Contains(attrs, n) {
+				attrs = append(attrs, n)
+			}
+		}
+	}
+	slices.Sort(attrs)
+
+	header := []string{"id", "uuid", "status", "priority", "priority_label", "desc", "tags",
+		"start", "end", "updated", "completed", "notes"}
+	for _, n := range attrs {
+		header = append(header, "attr."+n)
+	}
+	rows := [][]string{header}
+	str := func(s *string) string {
+		if s == nil {
+			return ""
+		}
+		return *s
+	}
+	for _, t := range tasks {
+		row := []string{strconv.Itoa(t.ID), t.UUID, t.Status, strconv.Itoa(t.Priority), t.PriorityLabel, t.Desc,
+			strings.Join(t.Tags, " "), str(t.Start), str(t.End), str(t.Updated), str(t.Completed),
+			strings.Join(t.Notes, "\n")}
+		for _, n := range attrs {
+			row = append(row, t.Attrs[n])
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
@@ -0,0 +1,175 @@
+package cobra
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EvoSched/gotask/internal/config"
+	"github.com/EvoSched/gotask/internal/types"
+	"github.com/spf13/cobra"
+)
+
+func outputTasks() []*types.Task {
+	start := time.Date(2024, time.November, 1, 10, 0, 0, 0, time.UTC)
+	updated := time.Date(2024, time.October, 28, 9, 12, 44, 0, time.UTC)
+	return []*types.Task{
+		{ID: 7, ShortID: 4, UUID: "b1c44182", Desc: "Call about\tinvoice", Priority: 2, Tags: []string{"WORK", "CALL"},
+			Notes: []string{"first", "second"}, Attrs: map[string]string{"customer": "acme"}, StartAt: &start,
+			UpdatedAt: &updated, Checklist: []types.CheckItem{{Text: "Find it", Done: true}}},
+		{ID: 9, ShortID: 5, UUID: "822cd627", Desc: "Plain", Priority: 3, UpdatedAt: &updated, Finished: true,
+			CompletedAt: &updated},
+	}
+}
+
+// withLocal sets the local zone for the duration of a test
+func withLocal(t *testing.T, loc *time.Location) {
+	t.Helper()
+	prev := time.Local
+	time.Local = loc
+	t.Cleanup(func() { time.Local = prev })
+}
+
+// cet is the zone the output tests run in, so wall-clock times and instants both get its offset
+var cet = time.FixedZone("CET", 60*60)
+
+func TestWriteTasksJSON(t *testing.T) {
+	withLocal(t, cet)
+	var b bytes.Buffer
+	if err := writeTasks(&b, "json", outputTasks()); err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Version int              `json:"version"`
+		Tasks   []map[string]any `json:"tasks"`
+	}
+	if err := json.Unmarshal(b.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Version != outputVersion || len(doc.Tasks) != 2 {
+		t.Fatalf("writeTasks json = %s", b.String())
+	}
+	first, second := doc.Tasks[0], doc.Tasks[1]
+	if first["id"] != 4.0 || first["start"] != "2024-11-01T10:00:00+01:00" || first["end"] != nil || first["status"] != "open" {
+		t.Errorf("first task = %v", first)
+	}
+	if second["status"] != "done" || second["completed"] != "2024-10-28T10:12:44+01:00" {
+		t.Errorf("second task = %v", second)
+	}
+	// empty lists and attributes are written as such rather than null
+	if tags, ok := second["tags"].([]any); !ok || len(tags) != 0 {
+		t.Errorf("second task tags = %#v", second["tags"])
+	}
+	if attrs, ok := second["attrs"].(map[string]any); !ok || len(attrs) != 0 {
+		t.Errorf("second task attrs = %#v", second["attrs"])
+	}
+}
+
+func TestWriteTasksRows(t *testing.T) {
+	withLocal(t, cet)
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{"csv", []string{
+			"id,uuid,status,priority,priority_label,desc,tags,start,end,updated,completed,notes,attr.customer",
+			"4,b1c44182,open,2,2,Call about\tinvoice,WORK CALL,2024-11-01T10:00:00+01:00,,2024-10-28T10:12:44+01:00,,\"first\nsecond\",acme",
+			"5,822cd627,done,3,3,Plain,,,,2024-10-28T10:12:44+01:00,2024-10-28T10:12:44+01:00,,",
+		}},
+		{"tsv", []string{
+			"id\tuuid\tstatus\tpriority\tpriority_label\tdesc\ttags\tstart\tend\tupdated\tcompleted\tnotes\tattr.customer",
+			"4\tb1c44182\topen\t2\t2\tCall about\\tinvoice\tWORK CALL\t2024-11-01T10:00:00+01:00\t\t2024-10-28T10:12:44+01:00\t\tfirst\\nsecond\tacme",
+			"5\t822cd627\tdone\t3\t3\tPlain\t\t\t\t2024-10-28T10:12:44+01:00\t2024-10-28T10:12:44+01:00\t\t",
+		}},
+		{"ndjson", []string{
+			`{"id":4,"uuid":"b1c44182","desc":"Call about\tinvoice","status":"open","priority":2,"priority_label":"2","tags":["WORK","CALL"],"attrs":{"customer":"acme"},"start":"2024-11-01T10:00:00+01:00","end":null,"updated":"2024-10-28T10:12:44+01:00","completed":null,"notes":["first","second"],"checklist":[{"text":"Find it","done":true}]}`,
+			`{"id":5,"uuid":"822cd627","desc":"Plain","status":"done","priority":3,"priority_label":"3","tags":[],"attrs":{},"start":null,"end":null,"updated":"2024-10-28T10:12:44+01:00","completed":"2024-10-28T10:12:44+01:00","notes":[],"checklist":[]}`,
+		}},
+	}
+	for _, tt := range tests {
+		var b bytes.Buffer
+		if err := writeTasks(&b, tt.format, outputTasks()); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := b.String(), strings.Join(tt.want, "\n")+"\n"; got != want {
+			t.Errorf("writeTasks %s =\n%s\nwant\n%s", tt.format, got, want)
+		}
+	}
+}
+
+func TestRenderTasks(t *testing.T) {
+	withClock(t, fixedNow)
+	cfg := &config.Config{Display: config.Display{Formats: map[string]string{"short": "{{.ID}} {{.Desc}}"}}}
+	tests := []struct {
+		format, template string
+		want             string
+	}{
+		{`{{.ID}}\t{{.Desc | trunc 8}}\t{{join .Tags ","}}`, "", "4\tCall a..\tWORK,CALL\n5\tPlain\t\n"},
+		{"short", "", "4 Call about\tinvoice\n5 Plain\n"},
+		{`{{iso .Start}}|{{layout "15:04" .Start}}|{{until .Start | duration}}|{{color "red" .Status}}`, "",
+			"2024-11-01|10:00|17d23h30m|open\n||0s|done\n"},
+		{"", "{{range .Tasks}}- {{check .Done}} {{.Desc | upper}}\n{{end}}{{iso .Now}}",
+			"- [ ] CALL ABOUT\tINVOICE\n- [x] PLAIN\n2024-10-14"},
+	}
+	for _, tt := range tests {
+		c := &Cmd{cfg: cfg, format: tt.format}
+		if tt.template != "" {
+			c.templateFile = filepath.Join(t.TempDir(), "digest.tmpl")
+			if err := os.WriteFile(c.templateFile, []byte(tt.template), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		var b bytes.Buffer
+		c.renderTasks(&b, outputTasks())
+		if got := b.String(); got != tt.want {
+			t.Errorf("renderTasks %q = %q, want %q", tt.format+tt.template, got, tt.want)
+		}
+	}
+
+	// task times are wall-clock times, so the clock's zone doesn't shift them
+	withClock(t, time.Date(2024, time.October, 14, 10, 30, 0, 0, time.FixedZone("UTC+2", 2*60*60)))
+	var b bytes.Buffer
+	(&Cmd{cfg: cfg, format: "{{until .Start | duration}}"}).renderTasks(&b, outputTasks()[:1])
+	if got, want := b.String(), "17d23h30m\n"; got != want {
+		t.Errorf("until in UTC+2 = %q, want %q", got, want)
+	}
+
+	for _, format := range []string{"nope", "{{.ID", `{{color "pink" .Desc}}`} {
+		c := &Cmd{cfg: cfg, format: format}
+		tmpl, err := c.taskFormat(false)
+		if err == nil {
+			err = tmpl.Execute(io.Discard, newTaskView(outputTasks()[0]))
+		}
+		if err == nil {
+			t.Errorf("format %q gave no error", format)
+		}
+	}
+}
+
+func TestSetupOutputResult(t *testing.T) {
+	stdout := os.Stdout
+	cmd := &cobra.Command{Use: "mod", Annotations: changesTasks}
+	c := &Cmd{output: "json", color: "auto"}
+	c.setupOutput(cmd)
+	if os.Stdout != stdout || c.stdout != stdout || c.messages() != os.Stderr {
+		t.Fatalf("setupOutput: os.Stdout = %v, result to %v, messages to %v", os.Stdout, c.stdout, c.messages())
+	}
+
+	// messages and the result are written apart
+	var msg, res bytes.Buffer
+	c.out, c.stdout = &msg, &res
+	c.printf("Added task %d.\n", 4)
+	c.changedTasks(&types.Task{ShortID: 4})
+	c.reportResult(cmd)
+	if got, want := msg.String(), "Added task 4.\n"; got != want {
+		t.Errorf("messages = %q, want %q", got, want)
+	}
+	if got, want := res.String(), `{"version":1,"command":"mod","ids":[4]}`+"\n"; got != want {
+		t.Errorf("result = %q, want %q", got, want)
+	}
+}
@@ -4,277 +4,461 @@ package cobra
 import (
 	"errors"
 	"fmt"
+	"regexp"
 	"strconv"
+	"strings"
 	"time"
+
+	"github.com/EvoSched/gotask/internal/config"
+	"github.com/EvoSched/gotask/internal/service"
+	"github.com/EvoSched/gotask/internal/types"
 )
 
+// udaDefs holds the user-defined attributes declared in config, keyed by name
+var udaDefs map[string]config.UDA
+
+// priorities is the priority scheme from config that '%' values are validated against
+var priorities = config.DefaultPriority
+
 // taskInfo represents the structure of a task with all its properties
 // All fields are pointers to allow for optional values
 type taskInfo struct {
-	id       *int       // Unique identifier for the task
-	desc     *string    // Task description
-	startAt  *time.Time // Start time of the task
-	endAt    *time.Time // End time of the task
-	addTags  []string   // Tags to be added to the task
-	remTags  []string   // Tags to be removed from the task
-	priority *int       // Task priority (1-5, where 1 is highest)
+	ref            *string           // Reference to an existing task (short ID or UUID prefix)
+	desc           *string           // Task description
+	startAt        *time.Time        // Start time of the task
+	endAt          *time.Time        // End time of the task
+	dateOnly       bool              // Whether the time is a date without a time of day
+	shift          *timeShift        // Move of the task's current time (mod only)
+	addTags        []string          // Tags to be added to the task
+	remTags        []string          // Tags to be removed from the task
+	priority       *int              // Task priority, stored as defined by the priority scheme
+	attrs          map[string]string // User-defined attributes to set ("" clears the attribute)
+	reminders      []types.Reminder  // Reminders to add to the task
+	clearReminders bool              // Whether existing reminders are removed (remind: with no value)
 }
 
 // timeStamp represents a time range with optional start and end times
 type timeStamp struct {
-	start *time.Time // Start time
-	end   *time.Time // End time (optional)
+	start    *time.Time // Start time
+	end      *time.Time // End time (optional)
+	dateOnly bool       // Whether only a date was given, due at the end of its day
+}
+
+// timeShift moves a task's time from where it is, keeping the length of a range
+type timeShift struct {
+	by   time.Duration // Offset to move by (e.g., "+1h", "shift +30m")
+	date *time.Time    // Date to move to at the same time of day (e.g., "same-time fri")
 }
 
 // parseTask processes command line arguments to create or modify a task
 // isAdd determines whether this is a new task (true) or modifying an existing task (false)
+//
+// Words that aren't a tag, time, priority, reminder or attribute make up the description, so
+// quotes are only needed around words that would otherwise be read as one of those.
+// Example: gt add Buy milk +home @ tmrw 5pm %3
 func parseTask(args []string, isAdd bool) (*taskInfo, error) {
+	words, input := argWords(args)
+	ti, err := parseWords(words, isAdd)
+	return ti, locate(err, words, input)
+}
+
+// parseLine processes a whole line of input to create a task, as read from stdin
+// Example: Call "+1 555 0100" about the invoice @ fri 10am +work
+func parseLine(line string) (*taskInfo, error) {
+	words, err := splitLine(line)
+	if err != nil {
+		return nil, err
+	}
+	ti, err := parseWords(words, true)
+	return ti, locate(err, words, line)
+}
+
+// parseWords processes the words of a task; for modifications the first word references the task
+func parseWords(words []word, isAdd bool) (*taskInfo, error) {
 	// Initialize a new taskInfo object
 	task := new(taskInfo)
 
-	// Handle new task creation
-	if isAdd {
-		// Set the task description from the first argument
-		task.desc = &args[0]
-	} else {
-		// Handle existing task modification
-		// Parse the task ID from the first argument
-		id, err := strconv.Atoi(args[0])
+	// Handle existing task modification
+	// Parse the task reference from the first word
+	if !isAdd {
+		if len(words) == 0 {
+			return nil, parseErr(CodeRefInvalid, "mod requires a task ID")
+		}
+		ref, err := parseRef(words[0].text)
 		if err != nil {
+			if idRange.MatchString(words[0].text) || strings.Contains(words[0].text, ",") {
+				return nil, parseErr(CodeRefInvalid, "several tasks need '--' before the changes").
+					hint("use 'gt mod %s -- <changes>'", words[0].text)
+			}
+			return nil, err
+		}
+		task.ref = &ref
+		if err := parseFields(task, words[1:], isAdd); err != nil {
+			var pe *ParseError
+			if errors.As(err, &pe) {
+				pe.Word++ // Count the reference
+			}
 			return nil, err
 		}
-		task.id = &id
+		return task, nil
 	}
 
-	// Initialize variables to track date and time parsing
-	var date *time.Time
-	var tStmp *timeStamp
+	if err := parseFields(task, words, isAdd); err != nil {
+		return nil, err
+	}
+	// Handle new task creation, which requires a description
+	if task.desc == nil {
+		return nil, parseErr(CodeDescMissing, "task requires a description").at(len(words), 0, 0).
+			hint("words that aren't a tag, time, priority or attribute make up the description")
+	}
+	return task, nil
+}
+
+// parseMods processes the changes given to 'mod' after '--', which apply to every selected task
+//
+// Example usage:
+//
+//	gt mod +sprint12 status:open -- %3 +carryover
+//	args would be: ["%3", "+carryover"]
+func parseMods(args []string) (*taskInfo, error) {
+	if len(args) == 0 {
+		return nil, errors.New("mod requires changes after '--'")
+	}
+	words, input := argWords(args)
+	return parseModWords(words, input)
+}
+
+// parseModLine processes a whole line of changes to a task, as typed in the interactive view
+// Example: "Call +1 555" %2 +work
+func parseModLine(line string) (*taskInfo, error) {
+	words, err := splitLine(line)
+	if err != nil {
+		return nil, err
+	}
+	return parseModWords(words, line)
+}
+
+// parseModWords processes the words of changes to a task, locating errors within input
+func parseModWords(words []word, input string) (*taskInfo, error) {
+	task := new(taskInfo)
+	if err := parseFields(task, words, false); err != nil {
+		return nil, locate(err, words, input)
+	}
+	return task, nil
+}
+
+// parseFields processes the words of a task after its reference, collecting the description
+// from the words that aren't properties. Errors are *ParseError located within words.
+func parseFields(task *taskInfo, words []word, isAdd bool) error {
+	var desc []string
+	// Track whether the time expression has been processed
 	timeFlg := false
 
-	// This loop processes each argument in the command after the first one
-	// Example command: gt add "Complete homework" +school @tomorrow %1
-	// args would be: ["Complete homework", "+school", "@", "tomorrow", "%1"]
-	for i := 1; i < len(args); i++ {
-		// CASE 1: Adding Tags
-		// If argument starts with '+', it's a tag to add
-		// Example: +school, +urgent, +work
-		if args[i][0] == '+' {
-			// args[i][1:] removes the '+' and takes the rest of the string
+	// This loop processes each word in turn
+	// Example command: gt add Complete homework +school @tomorrow %1
+	// words would be: ["Complete", "homework", "+school", "@tomorrow", "%1"]
+	for i := 0; i < len(words); i++ {
+		arg := words[i].text
+
+		// CASE 1: Description Words
+		// Quoted or escaped words are always part of the description
+		// Example: "+1 reviewer", \+1
+		if words[i].literal {
+			desc = append(desc, arg)
+
+			// CASE 2: Adding Tags
+			// If argument starts with '+', it's a tag to add
+			// Example: +school, +urgent, +work
+		} else if arg[0] == '+' && len(arg) > 1 {
+			// arg[1:] removes the '+' and takes the rest of the string
 			// Example: "+school" becomes "school"
-			task.addTags = append(task.addTags, args[i][1:])
+			task.addTags = append(task.addTags, arg[1:])
 
-			// CASE 2: Removing Tags (only for existing tasks)
+			// CASE 3: Removing Tags (only for existing tasks)
 			// If argument starts with '!' and we're modifying an existing task
 			// Example: !school (removes 'school' tag)
-		} else if args[i][0] == '!' && !isAdd {
-			task.remTags = append(task.remTags, args[i][1:])
-
-			// CASE 3: Setting Time/Date
-			// If argument starts with '@' and we haven't processed time yet
-			// The '@' must be separated from the time expression
-			// Example: gt add "work" @ 12-3pm +MA
-			//         NOT: gt add "work" @12-3pm +MA
-		} else if !timeFlg && args[i][0] == '@' {
+		} else if arg[0] == '!' && len(arg) > 1 && !isAdd {
+			task.remTags = append(task.remTags, arg[1:])
+
+			// CASE 4: Setting Time/Date
+			// If argument starts with '@'; only one time expression is allowed
+			// The '@' may be attached to the first word of the time expression
+			// Example: gt add work @ 12-3pm +MA
+			//          gt add work @tomorrow 12-3pm +MA
+		} else if arg[0] == '@' {
+			if timeFlg {
+				return parseErr(CodeTimeDuplicate, "time already set, only one '@' is allowed").at(i, 0, 1).
+					hint("escape a literal '@' as \\@")
+			}
 			timeFlg = true // Mark that we're processing time
-			c := i + 3     // Look ahead up to 3 arguments for time components
-			j := i + 1     // Start from next argument after '@'
-			curIdx := 0    // Track how many time components we've processed
+			expr := texts(words[i+1:])
+			base, offset := i+1, 0 // Where the expression's first word is in words
+			if len(arg) > 1 {
+				expr = append([]string{arg[1:]}, expr...)
+				base, offset = i, 1
+				i-- // The attached word is counted among the consumed arguments
+			}
+			if len(expr) == 0 {
+				return parseErr(CodeTimeMissing, "'@' requires a date or time").at(i, len(arg), 0).
+					hint("e.g. '@ tmrw 5pm' or '@ fri'")
+			}
 
-			// Look at up to 3 arguments after '@' for time/date information
-			// This allows formats like: @ tomorrow 2pm
-			//                          @ 2pm
-			//                          @ 2-4pm
-			for ; j < len(args) && j < c; j++ {
-				curIdx++
-				// Try to parse the argument as either a date or time
-				t, ts, err := parseTime(args[j])
-
-				// If parsing failed and we haven't found any valid time yet, return error
-				if err != nil && date == nil && tStmp == nil {
-					return nil, err
-				} else if err != nil {
-					// If parsing failed but we already have some time info, just skip this part
-					continue
+			// A shift moves the time an existing task already has
+			// Example: gt mod 5 @ +1h
+			//          gt mod 5 @ shift +1m
+			//          gt mod 5 @ same-time fri
+			if !isAdd {
+				sh, n, err := parseShift(expr)
+				if err != nil {
+					return shift(err, CodeTimeInvalid, base, offset)
 				}
-
-				// If we got a date (like "tomorrow", "next week")
-				if t != nil {
-					// Can't set date twice
-					if date != nil {
-						return nil, errors.New("task date already set")
-					}
-					date = t
+				if sh != nil {
+					task.shift = sh
+					i += n
+					continue
 				}
+			}
 
-				// If we got a timestamp (like "2pm", "2-4pm")
-				if ts != nil {
-					// Can't set timestamp twice
-					if tStmp != nil {
-						return nil, errors.New("task timestamp already set")
+			// Parse the time expression following '@', which halts at the first
+			// argument that isn't part of it
+			// This allows formats like: @ tomorrow 2pm
+			//                          @ 2-4pm
+			//                          @ mon 9am - wed 5pm
+			//                          @ 2024-11-01..2024-11-05
+			ts, n, err := parseTimeExpr(expr)
+			if err != nil {
+				var pe *ParseError
+				if errors.As(err, &pe) && pe.Word == 0 {
+					switch {
+					case isAdd && (isShift(expr[0]) || isShiftWord(expr[0])):
+						pe.hint("shifts move an existing task, e.g. 'gt mod 5 @ +1h' or 'gt mod 5 @ same-time fri'")
+					case isShift(expr[0]):
+						pe.hint("to move the task's time, write '@ shift %s'", expr[0])
 					}
-					tStmp = ts
 				}
+				return shift(err, CodeTimeInvalid, base, offset)
 			}
+			task.startAt = ts.start
+			task.endAt = ts.end
+			task.dateOnly = ts.dateOnly
+
 			// Skip the arguments we just processed
-			i = j - 1
-
-			// CASE 4: Setting Priority
-			// If argument starts with '%' and priority isn't set yet
-			// Example: %1 (highest priority) to %5 (lowest priority)
-		} else if task.priority == nil && args[i][0] == '%' {
-			// Check if there's a number after '%'
-			if len(args[i]) > 1 {
-				// Convert the priority string to number
-				// Example: "%1" becomes 1
-				p, err := strconv.Atoi(args[i][1:])
+			i += n
+
+			// CASE 5: Setting Priority
+			// If argument starts with '%'; only one priority is allowed
+			// Example: %1 (highest priority) to %5 (lowest priority), or %H with named levels
+		} else if arg[0] == '%' {
+			if task.priority != nil {
+				return parseErr(CodePriorityDuplicate, "priority already set, only one '%%' is allowed").at(i, 0, 0).
+					hint("escape a literal '%%' as \\%%")
+			}
+			// Check if there's a value after '%'
+			if len(arg) > 1 {
+				// Convert the priority to its stored number, validating it against the scheme
+				// Example: "%1" becomes 1, "%M" becomes 2 with levels H, M, L
+				p, err := priorities.Parse(arg[1:])
 				if err != nil {
-					return nil, err
+					return parseErr(CodePriorityInvalid, "invalid priority: %s", arg[1:]).at(i, 1, 0).
+						hint("priority must be %s", priorities.Describe())
 				}
 				task.priority = &p
+			} else {
+				return parseErr(CodePriorityMissing, "priority requires a value").at(i, 0, 1).
+					hint("priority must be %s", priorities.Describe())
+			}
+
+			// CASE 6: Adding Reminders
+			// If argument starts with 'remind:', relative to the task's start or at a fixed time
+			// Example: remind:-30m, remind:tmrw, remind:9am, remind: (removes reminders, mod only)
+		} else if strings.HasPrefix(arg, "remind:") {
+			v := strings.TrimPrefix(arg, "remind:")
+			if v == "" {
+				if isAdd {
+					return parseErr(CodeReminderInvalid, "reminder requires a time").at(i, len(arg), 0).
+						hint("e.g. remind:-30m or remind:9am")
+				}
+				task.clearReminders = true
+			} else {
+				r, err := parseReminder(v)
+				if err != nil {
+					return parseErr(CodeReminderInvalid, "%v", err).at(i, len("remind:"), 0).
+						hint("give an offset from the task's time (-30m, -1d) or a date or time (tmrw, 9am)")
+				}
+				task.reminders = append(task.reminders, *r)
 			}
 
-			// CASE 5: Updating Description (only for existing tasks)
-			// If we're modifying a task and haven't set description yet
-		} else if !isAdd && task.desc == nil {
-			task.desc = &args[i]
+			// CASE 7: Setting User-Defined Attributes
+			// If argument is 'name:value' and name is declared in config
+			// Example: customer:acme, estimate:2h, sprint: (clears sprint)
+		} else if name, val, ok := splitAttr(arg); ok {
+			if _, set := task.attrs[name]; set {
+				return parseErr(CodeAttrDuplicate, "attribute %s already set", name).at(i, 0, len(name))
+			}
+			if val != "" {
+				v, err := parseAttr(name, val)
+				if err != nil {
+					return parseErr(CodeAttrInvalid, "%v", err).at(i, len(name)+1, 0)
+				}
+				val = v
+			} else if isAdd {
+				return parseErr(CodeAttrInvalid, "attribute %s requires a value", name).at(i, len(arg), 0)
+			}
+			if task.attrs == nil {
+				task.attrs = make(map[string]string)
+			}
+			task.attrs[name] = val
 
-			// CASE 6: Error Cases
-			// If none of the above cases match, it's an invalid argument
-		} else if isAdd {
-			// For new tasks, only +, @, and % prefixes are allowed
-			return nil, errors.New("attempts to use invalid prefix outside of valid set for add {+, @, %}")
+			// CASE 8: Description Words
+			// Anything else is part of the description
+			// Example: Complete homework
 		} else {
-			// For existing tasks, only +, -, @, and % prefixes are allowed
-			return nil, errors.New("attempts to use invalid prefix outside of valid set for mod {+, -, @, %}")
+			desc = append(desc, arg)
 		}
 	}
 
-	// FINAL STEP: Combining Date and Time Information
-	// We might have both a date (like "tomorrow") and a time (like "2-4pm")
-	// We need to combine them correctly
+	if len(desc) > 0 {
+		d := strings.Join(desc, " ")
+		task.desc = &d
+	}
+	return nil
+}
 
-	// CASE 1: We have both date and time
-	// Example: @ tomorrow 2-4pm
-	if date != nil && tStmp != nil {
-		// Create a new datetime by combining:
-		// - Date parts (year, month, day) from the date argument
-		// - Time parts (hour, minute) from the time argument
-		// Example: If date is "tomorrow" (2024-01-20) and time is "2:30pm"
-		//         Result will be "2024-01-20 14:30:00"
-		s := time.Date(
-			date.Year(),          // Year from date (e.g., 2024)
-			date.Month(),         // Month from date (e.g., January)
-			date.Day(),           // Day from date (e.g., 20)
-			tStmp.start.Hour(),   // Hour from time (e.g., 14 for 2pm)
-			tStmp.start.Minute(), // Minute from time (e.g., 30)
-			0,                    // Seconds (always 0)
-			0,                    // Nanoseconds (always 0)
-			time.UTC,             // Timezone
-		)
-
-		// If we have an end time (e.g., "2-4pm"), set both start and end
-		if tStmp.end != nil {
-			// Create end time similar to start time
-			e := time.Date(
-				date.Year(), date.Month(), date.Day(),
-				tStmp.end.Hour(), tStmp.end.Minute(),
-				0, 0, time.UTC,
-			)
-			task.startAt = &s
-			task.endAt = &e
-		} else {
-			// If no end time, just set the start time
-			task.startAt = &s
+// parseRef validates a task reference: a short ID, a UUID prefix, 'last' for the most recently
+// added task, or '~' and words of its description
+// The reference is resolved to a task by the command, which asks when a description is ambiguous
+//
+// Example inputs: "12", "3f2a9c", "3f2a9c1e-8b7d-4c2e-9a51-0d6f3b8e2c47", "last", "~readme"
+func parseRef(arg string) (string, error) {
+	if _, err := strconv.Atoi(arg); err == nil {
+		return arg, nil
+	}
+	if strings.EqualFold(arg, lastRef) {
+		return lastRef, nil
+	}
+	if q, ok := strings.CutPrefix(arg, "~"); ok {
+		if strings.TrimSpace(q) == "" {
+			return "", parseErr(CodeRefInvalid, "missing description after '~'").
+				hint("write a word of the task's description, e.g. ~readme")
 		}
+		return arg, nil
+	}
+	if idRange.MatchString(arg) {
+		return "", parseErr(CodeRefInvalid, "expected a single task, not the range %s", arg)
+	}
+	if service.IsUUIDPrefix(arg) {
+		return strings.ToLower(arg), nil
+	}
+	return "", parseErr(CodeRefInvalid, "invalid task reference, expected an ID or UUID prefix: %s", arg).
+		hint("IDs are shown by 'gt list'; UUID prefixes need at least 4 characters; '~word' matches a description")
+}
 
-		// CASE 2: We only have a date
-		// Example: @ tomorrow
-	} else if date != nil {
-		task.startAt = date // Just use the date as is
+// lastRef references the most recently added task
+const lastRef = "last"
 
-		// CASE 3: We only have a time
-		// Example: @ 2-4pm
-	} else if tStmp != nil {
-		task.startAt = tStmp.start // Set the start time
-		task.endAt = tStmp.end     // Set the end time (might be nil)
-	}
+// maxIDRange is the most IDs a range like '3-7' may expand to
+const maxIDRange = 1000
 
-	return task, nil
-}
+// idRange matches a range of short IDs; a first number of 8 digits or more is the start of a UUID
+var idRange = regexp.MustCompile(`^(\d{1,7})-(\d+)$`)
 
 // parseGet processes arguments for the 'get' command
-// This function converts a list of string IDs into actual numbers
+// This function validates a list of task references and expands lists and ranges of IDs
 //
 // Example usage:
 //
-//	gt get 1 2 3
-//	args would be: ["1", "2", "3"]
-//	returns: [1, 2, 3]
-func parseGet(args []string) ([]int, error) {
-	// Create an empty slice to store the converted numbers
-	var ids []int
+//	gt get 1 3-5,9 3f2a9c last
+//	args would be: ["1", "3-5,9", "3f2a9c", "last"]
+//	returns: ["1", "3", "4", "5", "9", "3f2a9c", "last"]
+func parseGet(args []string) ([]string, error) {
+	// Create an empty slice to store the references
+	var refs []string
 
 	// Loop through each argument
-	for _, arg := range args {
-		// Try to convert the string to a number
-		// Example: "1" becomes 1
-		id, err := strconv.Atoi(arg)
-
-		// If conversion fails (e.g., "abc" can't become a number)
-		// return an error
-		if err != nil {
-			return nil, err
+	for i, arg := range args {
+		// A description may hold commas, so it is taken whole
+		if strings.HasPrefix(arg, "~") {
+			ref, err := parseRef(arg)
+			if err != nil {
+				input, words := displayArgs(args)
+				return nil, locate(shift(err, CodeRefInvalid, i, 0), words, input)
+			}
+			refs = append(refs, ref)
+			continue
 		}
 
-		// Add the converted number to our list
-		ids = append(ids, id)
+		// Split lists like "3,5,9" and check each part
+		off := 0
+		for _, part := range strings.Split(arg, ",") {
+			expanded, err := parseRefPart(part)
+
+			// If a part is neither a reference nor a range (e.g., "abc!")
+			// return an error pointing at it
+			if err != nil {
+				input, words := displayArgs(args)
+				return nil, locate(shift(err, CodeRefInvalid, i, off), words, input)
+			}
+
+			// Add the references to our list
+			refs = append(refs, expanded...)
+			off += len(part) + 1
+		}
 	}
 
-	// Return the list of task IDs
-	return ids, nil
+	// Return the list of task references
+	return refs, nil
+}
+
+// parseRefPart validates one part of a list of references, expanding a range like "3-7"
+func parseRefPart(part string) ([]string, error) {
+	if part == "" {
+		return nil, parseErr(CodeRefInvalid, "empty task reference in list").at(0, 0, 1).
+			hint("separate IDs with single commas, e.g. 3,5,9")
+	}
+	m := idRange.FindStringSubmatch(part)
+	if m == nil {
+		ref, err := parseRef(part)
+		var pe *ParseError
+		if errors.As(err, &pe) {
+			return nil, pe.at(0, 0, len(part))
+		}
+		return []string{ref}, nil
+	}
+	from, _ := strconv.Atoi(m[1])
+	to, err := strconv.Atoi(m[2])
+	switch {
+	case err != nil || to-from >= maxIDRange:
+		return nil, parseErr(CodeRefInvalid, "ID range %s is too large", part).at(0, 0, len(part)).
+			hint("ranges cover at most %d IDs; use a filter for more", maxIDRange)
+	case to < from:
+		return nil, parseErr(CodeRefInvalid, "ID range %s ends before it starts", part).at(0, 0, len(part)).
+			hint("write the lower ID first, e.g. %s-%s", m[2], m[1])
+	}
+	var refs []string
+	for id := from; id <= to; id++ {
+		refs = append(refs, strconv.Itoa(id))
+	}
+	return refs, nil
 }
 
 // parseDone processes arguments for the 'done' command
-// This function is similar to parseGet, but specifically for marking tasks as completed
+// This function is the same as parseGet, for marking tasks as completed
 //
 // Example usage:
 //
 //	gt done 1 2 3    (mark tasks 1, 2, and 3 as completed)
-//	gt done 5        (mark task 5 as completed)
+//	gt done 3-7,10   (mark tasks 3 to 7 and 10 as completed)
+//	gt done 3f2a9c   (mark the task whose UUID starts with 3f2a9c as completed)
 //
 // Input:
 //
-//	args: ["1", "2", "3"] (array of task IDs as strings)
+//	args: ["1", "2", "3"] (array of task references as strings)
 //
 // Output:
-//   - Success: returns [1, 2, 3] (array of integers)
-//   - Error: returns nil and error if any ID is not a valid number
-func parseDone(args []string) ([]int, error) {
-	// Create an empty slice to store the task IDs
-	var ids []int
-
-	// Process each argument one by one
-	// Example: for args ["1", "2", "3"]:
-	//   First iteration:  arg = "1"
-	//   Second iteration: arg = "2"
-	//   Third iteration:  arg = "3"
-	for _, arg := range args {
-		// Convert the string ID to a number
-		// Example: "1" becomes 1
-		id, err := strconv.Atoi(arg)
-
-		// If conversion fails (e.g., "abc" is not a valid number)
-		// return immediately with an error
-		if err != nil {
-			return nil, err
-		}
-
-		// Add the converted number to our list of IDs
-		ids = append(ids, id)
-	}
-
-	// Return the complete list of task IDs to mark as done
-	return ids, nil
+//   - Success: returns ["1", "2", "3"]
+//   - Error: returns nil and error if any argument is not a valid reference
+func parseDone(args []string) ([]string, error) {
+	return parseGet(args)
 }
 
 // parseNote processes arguments for the 'note' command
@@ -291,28 +475,677 @@ func parseDone(args []string) ([]int, error) {
 //	args[1]: The note content (e.g., "Remember to include tests")
 //
 // Output:
-//   - Success: returns (taskID, noteContent, nil)
-//   - Error: returns (0, "", error) if task ID is not a valid number
+//   - Success: returns (taskRef, noteContent, nil)
+//   - Error: returns ("", "", *ParseError) if the task reference is not valid
 //
 // Note: This function expects exactly 2 arguments:
-//  1. The task ID
+//  1. The task ID or UUID prefix
 //  2. The note content
-func parseNote(args []string) (int, string, error) {
-	// Try to convert the first argument to a number (task ID)
-	// Example: "1" becomes 1
-	id, err := strconv.Atoi(args[0])
+func parseNote(args []string) (string, string, error) {
+	// Check that the first argument is an ID or UUID prefix
+	ref, err := parseRef(args[0])
 
-	// If the conversion fails (e.g., "abc" is not a valid number)
-	// return an error with a helpful message
+	// If it is not (e.g., "abc!")
+	// return an error pointing at it
 	if err != nil {
-		return 0, "", errors.New("invalid number type entered for 'note' command")
+		return "", "", locateArgs(err, args)
 	}
 
 	// Return three values:
-	// 1. The task ID (as a number)
+	// 1. The task reference
 	// 2. The note content (everything after the ID)
 	// 3. nil (no error)
-	return id, args[1], nil
+	return ref, args[1], nil
+}
+
+// parseAttach processes arguments for the 'attach' command
+//
+// Example usage:
+//
+//	gt attach 1 ./design.pdf
+//	gt attach 1 https://github.com/EvoSched/gotask/pull/12
+//	args would be: ["1", "./design.pdf"]
+func parseAttach(args []string) (string, string, error) {
+	ref, err := parseRef(args[0])
+	if err != nil {
+		return "", "", locateArgs(err, args)
+	}
+	return ref, args[1], nil
+}
+
+// parseCheckAdd processes arguments for the 'check add' command
+//
+// Example usage:
+//
+//	gt check add 1 "Update changelog"
+//	args would be: ["1", "Update changelog"]
+func parseCheckAdd(args []string) (string, string, error) {
+	ref, err := parseRef(args[0])
+	if err != nil {
+		return "", "", locateArgs(err, args)
+	}
+	if strings.TrimSpace(args[1]) == "" {
+		return "", "", locateArgs(parseErr(CodeItemMissing, "checklist item cannot be empty").at(1, 0, 0).
+			hint("e.g. 'gt check add 5 \"Write tests\"'"), args)
+	}
+	return ref, args[1], nil
+}
+
+// parseItemRef processes arguments for commands that refer to an item of a task,
+// such as an attachment ('open', 'detach') or a checklist item ('check', 'uncheck')
+// The item number is 1-based in the order shown by 'gt get' and defaults to 1
+//
+// Example usage:
+//
+//	gt open 1      -> ("1", 1)
+//	gt detach 1 2  -> ("1", 2)
+//	gt check 3 4   -> ("3", 4)
+func parseItemRef(args []string) (string, int, error) {
+	ref, err := parseRef(args[0])
+	if err != nil {
+		return "", 0, locateArgs(err, args)
+	}
+	n := 1
+	if len(args) > 1 {
+		n, err = strconv.Atoi(args[1])
+		if err != nil || n < 1 {
+			return "", 0, locateArgs(parseErr(CodeItemInvalid, "item number must be a positive number: %s", args[1]).
+				at(1, 0, 0), args)
+		}
+	}
+	return ref, n, nil
+}
+
+// reminderAtFmt is the layout of a reminder at a date and time, written as a single word
+const reminderAtFmt = "2006-01-02T15:04"
+
+// parsePostpone processes arguments for the 'postpone' command: the tasks, as IDs or a filter,
+// followed by a shift, which is a signed duration as the last word (with or without 'shift',
+// which minutes need as with '@'), or 'same-time' and a date
+//
+// Example usage:
+//
+//	gt postpone 5 6 +2d              -> (["5", "6"], +48h)
+//	gt postpone +work same-time fri  -> (["+work"], Friday)
+func parsePostpone(args []string) ([]string, *timeShift, error) {
+	input, words := displayArgs(args)
+	at := len(args) - 1
+	for i, a := range args {
+		if isShiftWord(a) {
+			at = i
+			break
+		}
+	}
+	if at < 1 {
+		return nil, nil, locate(parseErr(CodeTimeMissing, "postpone requires tasks and a shift").at(len(args), 0, 0).
+			hint("e.g. 'gt postpone 5 +2d' or 'gt postpone 5 same-time fri'"), words, input)
+	}
+	var sh *timeShift
+	var n int
+	var err error
+	if isShift(args[at]) {
+		// '+1m' is a month after '@', so a minute is only taken with 'shift'
+		if readsAsDate(args[at]) && strings.HasSuffix(args[at], "m") {
+			return nil, nil, locate(parseErr(CodeTimeInvalid, "ambiguous shift: %s", args[at]).at(at, 0, 0).
+				hint("'m' is a month in dates; write 'shift %s' to postpone by minutes", args[at]), words, input)
+		}
+		d, _ := parseDuration(args[at])
+		sh, n = &timeShift{by: d}, 1
+	} else {
+		sh, n, err = parseShift(args[at:])
+	}
+	switch {
+	case err != nil:
+		err = shift(err, CodeTimeInvalid, at, 0)
+	case sh == nil:
+		err = parseErr(CodeTimeInvalid, "invalid shift: %s", args[at]).at(at, 0, 0).
+			hint("give a signed duration like +2d, -30m or +1w, or 'same-time' and a date")
+	case at+n < len(args):
+		err = parseErr(CodeTimeInvalid, "unexpected words after the shift").at(at+n, 0, 0)
+	}
+	if err != nil {
+		return nil, nil, locate(err, words, input)
+	}
+	return args[:at], sh, nil
+}
+
+// parseReminder processes the value of a 'remind:' argument
+// Signed durations are relative to the task's start, anything else is parsed as a date or time
+//
+// Example inputs:
+//   - "-30m", "-1d", "+15m" -> relative to the task's start
+//   - "tmrw", "2024-11-01"  -> at that date
+//   - "9am"                 -> today at 9am
+//   - "2024-11-01T09:00"    -> at that date and time
+func parseReminder(arg string) (*types.Reminder, error) {
+	if t, err := time.Parse(reminderAtFmt, arg); err == nil {
+		return &types.Reminder{At: &t}, nil
+	}
+	if arg[0] == '-' || arg[0] == '+' {
+		d, err := parseDuration(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid reminder offset: %s", arg)
+		}
+		return &types.Reminder{Offset: &d}, nil
+	}
+	t, ts, err := parseTime(arg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid reminder time: %s", arg)
+	}
+	if ts != nil {
+		t = ts.start
+	}
+	return &types.Reminder{At: t}, nil
+}
+
+// parseSnooze processes arguments for the 'snooze' command
+// The duration is optional and falls back to the configured default when empty
+//
+// Example usage:
+//
+//	gt snooze 1       -> ("1", 0)
+//	gt snooze 1 1h    -> ("1", 1h)
+func parseSnooze(args []string) (string, time.Duration, error) {
+	ref, err := parseRef(args[0])
+	if err != nil {
+		return "", 0, err
+	}
+	if len(args) < 2 {
+		return ref, 0, nil
+	}
+	d, err := parseDuration(strings.TrimPrefix(args[1], "+"))
+	if err != nil || d <= 0 {
+		return "", 0, fmt.Errorf("snooze requires a positive duration: %s", args[1])
+	}
+	return ref, d, nil
+}
+
+// parseLink processes arguments for the 'link', 'unlink' and 'dup' commands
+//
+// Example usage:
+//
+//	gt link 4 2
+//	args would be: ["4", "2"]
+func parseLink(args []string) (string, string, error) {
+	from, err := parseRef(args[0])
+	if err != nil {
+		return "", "", err
+	}
+	to, err := parseRef(args[1])
+	if err != nil {
+		return "", "", err
+	}
+	if from == to {
+		return "", "", errors.New("a task cannot be linked to itself")
+	}
+	return from, to, nil
+}
+
+// taskMention matches '#42' references to other tasks in descriptions and notes
+var taskMention = regexp.MustCompile(`(?:^|[^\w#])#(\d+)\b`)
+
+// parseMentions returns the short IDs referenced as '#id' in s, without duplicates
+//
+// Example input: "Same crash as #42, see also #7 and #42"
+// returns: ["42", "7"]
+func parseMentions(s string) []string {
+	var refs []string
+	seen := make(map[string]bool)
+	for _, m := range taskMention.FindAllStringSubmatch(s, -1) {
+		if !seen[m[1]] {
+			seen[m[1]] = true
+			refs = append(refs, m[1])
+		}
+	}
+	return refs
+}
+
+// placeholder matches '{{name}}' variables in template descriptions
+var placeholder = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// parseVars processes the '--var name=value' flags of the 'template' commands
+//
+// Example usage:
+//
+//	gt template apply release --var version=1.4 --var owner=sam
+//	vars would be: ["version=1.4", "owner=sam"]
+//	returns: {"version": "1.4", "owner": "sam"}
+func parseVars(vars []string) (map[string]string, error) {
+	m := make(map[string]string)
+	for _, v := range vars {
+		name, val, found := strings.Cut(v, "=")
+		if !found || !placeholder.MatchString("{{"+name+"}}") {
+			return nil, fmt.Errorf("variables must be given as name=value: %s", v)
+		}
+		m[name] = val
+	}
+	return m, nil
+}
+
+// expandVars replaces '{{name}}' placeholders with their variables, reporting any that are missing
+func expandVars(s string, vars map[string]string) (string, error) {
+	var missing []string
+	out := placeholder.ReplaceAllStringFunc(s, func(m string) string {
+		name := placeholder.FindStringSubmatch(m)[1]
+		v, ok := vars[name]
+		if !ok {
+			missing = append(missing, name)
+			return m
+		}
+		return v
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("missing template variable(s) %s, set with --var name=value", strings.Join(missing, ", "))
+	}
+	return out, nil
+}
+
+// parseAnchor processes the '--anchor' date templates are applied relative to
+// The result is the start (midnight) of the given day, today when arg is empty
+//
+// Example inputs: "fri", "tmrw", "2024-11-01"
+func parseAnchor(arg string) (time.Time, error) {
+	if arg == "" {
+		n := timeNow()
+		return time.Date(n.Year(), n.Month(), n.Day(), 0, 0, 0, 0, time.UTC), nil
+	}
+	d, err := parseDate(arg)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid anchor date: %s", arg)
+	}
+	return time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, d.Location()), nil
+}
+
+// splitAttr splits a 'name:value' argument when name is a declared attribute
+func splitAttr(arg string) (string, string, bool) {
+	name, val, found := strings.Cut(arg, ":")
+	if !found {
+		return "", "", false
+	}
+	if _, ok := udaDefs[name]; !ok {
+		return "", "", false
+	}
+	return name, val, true
+}
+
+// parseAttr validates a value against the type of the attribute declared in config
+// and returns it in the normalized form it is stored in
+//
+// Example inputs:
+//   - number:   "3", "2.50" -> "3", "2.5"
+//   - date:     "fri", "2024-11-01" -> "2024-11-01"
+//   - duration: "90m", "2d" -> "1h30m0s", "48h0m0s"
+//   - enum:     must be one of the configured values
+func parseAttr(name string, val string) (string, error) {
+	def, ok := udaDefs[name]
+	if !ok {
+		return "", fmt.Errorf("unknown attribute: %s", name)
+	}
+	switch def.Type {
+	case config.UDANumber:
+		f, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return "", fmt.Errorf("attribute %s requires a number: %s", name, val)
+		}
+		return strconv.FormatFloat(f, 'f', -1, 64), nil
+	case config.UDADate:
+		t, err := parseDate(val)
+		if err != nil {
+			return "", fmt.Errorf("attribute %s requires a date: %s", name, val)
+		}
+		return t.Format(time.DateOnly), nil
+	case config.UDADuration:
+		d, err := parseDuration(val)
+		if err != nil {
+			return "", fmt.Errorf("attribute %s requires a duration: %s", name, val)
+		}
+		return d.String(), nil
+	case config.UDAEnum:
+		for _, v := range def.Values {
+			if strings.EqualFold(v, val) {
+				return v, nil
+			}
+		}
+		return "", fmt.Errorf("attribute %s must be one of %s: %s", name, strings.Join(def.Values, ", "), val)
+	default:
+		return val, nil
+	}
+}
+
+// attrDefaults returns the configured defaults for attributes not already set
+func attrDefaults(attrs map[string]string) (map[string]string, error) {
+	for name, def := range udaDefs {
+		if def.Default == "" {
+			continue
+		}
+		if _, ok := attrs[name]; ok {
+			continue
+		}
+		v, err := parseAttr(name, def.Default)
+		if err != nil {
+			return nil, fmt.Errorf("invalid default: %w", err)
+		}
+		if attrs == nil {
+			attrs = make(map[string]string)
+		}
+		attrs[name] = v
+	}
+	return attrs, nil
+}
+
+// parseDuration extends time.ParseDuration with day ('d') and week ('w') units
+// A leading sign applies to the whole duration
+//
+// Example inputs: "90m", "1h30m", "2d", "1w", "1d12h", "-1d12h"
+func parseDuration(arg string) (time.Duration, error) {
+	if len(arg) > 1 && (arg[0] == '-' || arg[0] == '+') {
+		d, err := parseDuration(arg[1:])
+		if err != nil || (arg[1] == '-' || arg[1] == '+') {
+			return 0, fmt.Errorf("invalid duration: %s", arg)
+		}
+		if arg[0] == '-' {
+			d = -d
+		}
+		return d, nil
+	}
+	var total time.Duration
+	rest := arg
+	for rest != "" {
+		i := strings.IndexAny(rest, "dw")
+		if i == -1 {
+			d, err := time.ParseDuration(rest)
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration: %s", arg)
+			}
+			return total + d, nil
+		}
+		n, err := strconv.Atoi(rest[:i])
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration: %s", arg)
+		}
+		if rest[i] == 'd' {
+			total += time.Duration(n) * 24 * time.Hour
+		} else {
+			total += time.Duration(n) * 7 * 24 * time.Hour
+		}
+		rest = rest[i+1:]
+	}
+	if arg == "" {
+		return 0, fmt.Errorf("invalid duration: %s", arg)
+	}
+	return total, nil
+}
+
+// timeSide is one end of a time expression: a date, a time of day, or both
+type timeSide struct {
+	date    *time.Time // Date of the side (e.g., "mon", "2024-11-01")
+	clock   *timeStamp // Time of day, possibly a range within the day (e.g., "9am", "2-4pm")
+	weekday bool       // The date is a bare weekday, which an end resolves from the start
+}
+
+func (s timeSide) empty() bool {
+	return s.date == nil && s.clock == nil
+}
+
+// parseTimeExpr processes the time expression following '@'
+// It consumes arguments until one isn't part of the expression and returns the
+// resulting start and end times along with the number of arguments consumed
+//
+// Valid formats:
+//  1. Within one day:
+//     - "tmrw", "2pm", "2-4pm", "fri 10am", "11-01-2024 10am-4:15"
+//  2. Across days, with '-' or '..' between the two sides:
+//     - "mon 9am - wed 5pm"
+//     - "fri 10pm - sat 2am"
+//     - "10pm - 2am" (an end earlier than the start falls on the next day)
+//     - "2024-11-01..2024-11-05" or "2024-11-01 .. 2024-11-05"
+//
+// Rules:
+//   - Each side takes at most one date and one time
+//   - A side without a date takes the start's date (or today)
+//   - An end given as a weekday is the first such day on or after the start's date
+//   - A side without a time starts at 00:00 or ends at 23:59
+//   - An end time without a date runs overnight only from the afternoon into the morning
+//   - The end must come after the start
+//
+// Errors are ParseErrors placed at the word of args at fault.
+func parseTimeExpr(args []string) (*timeStamp, int, error) {
+	var sides [2]timeSide
+	cur := 0                // Side being parsed, 1 once the separator has been seen
+	endWord, endOff := 0, 0 // Where the end of a range starts, for errors
+	n := 0
+	for ; n < len(args); n++ {
+		arg := args[n]
+
+		// A separator on its own switches to the end of the range
+		// Example: @ mon 9am - wed 5pm
+		if arg == "-" || arg == ".." {
+			if cur == 1 || sides[0].empty() {
+				return nil, 0, parseErr(CodeTimeRange, "range separator '%s' requires a start and cannot be duplicated", arg).at(n, 0, 0)
+			}
+			cur, endWord = 1, n+1
+			continue
+		}
+
+		// A range of dates within a single argument
+		// Example: @ 2024-11-01..2024-11-05
+		if a, b, ok := strings.Cut(arg, ".."); ok && a != "" && b != "" {
+			if cur == 1 || !sides[0].empty() {
+				return nil, 0, parseErr(CodeTimeRange, "date range cannot follow another date or time: %s", arg).at(n, 0, 0)
+			}
+			d1, err := parseDate(a)
+			if err != nil {
+				return nil, 0, timeWordErr(a, err).at(n, 0, len(a))
+			}
+			d2, err := parseDate(b)
+			if err != nil {
+				return nil, 0, timeWordErr(b, err).at(n, len(a)+2, 0)
+			}
+			sides[0].date, sides[1].date = d1, d2
+			sides[1].weekday = isWeekday(b)
+			cur, endWord, endOff = 1, n, len(a)+2
+			continue
+		}
+
+		// Try to parse the longest date phrase starting here, then a time
+		// Example: "next fri", "in 3 days", "first mon of next month"
+		var d *time.Time
+		var ts *timeStamp
+		var err error
+		at := n
+		if dd, w, errD := parseDatePhrase(args[n:]); errD == nil {
+			d = dd
+			n += w - 1 // Skip the remaining words of the phrase
+		} else {
+			_, ts, err = parseTime(arg)
+		}
+		if err != nil {
+			// The expression must contain at least one date or time, and a word meant as a
+			// time of day is a mistake rather than the start of the description
+			if n == 0 || meantAsClock(arg) {
+				return nil, 0, shift(err, CodeTimeInvalid, n, 0)
+			}
+			// Otherwise the expression has ended
+			break
+		}
+		side := &sides[cur]
+
+		// If we got a date (like "tomorrow", "fri")
+		if d != nil {
+			// Can't set date twice
+			if side.date != nil {
+				return nil, 0, parseErr(CodeTimeDuplicate, "task date already set").at(at, 0, 0).
+					hint("give one date per side, or a range like '@ mon - wed'")
+			}
+			side.date = d
+			side.weekday = n == at && isWeekday(arg)
+		}
+
+		// If we got a timestamp (like "2pm", "2-4pm")
+		if ts != nil {
+			// Can't set timestamp twice
+			if side.clock != nil {
+				return nil, 0, parseErr(CodeTimeDuplicate, "task timestamp already set").at(n, 0, 0).
+					hint("write a range within a day as '2-4pm'")
+			}
+			side.clock = ts
+		}
+	}
+
+	// A single side keeps a range within one day
+	// Example: @ tomorrow 2-4pm
+	if cur == 0 {
+		s := sides[0]
+		if s.empty() {
+			return nil, 0, parseErr(CodeTimeMissing, "'@' requires a date or time")
+		}
+		if s.clock == nil {
+			return &timeStamp{start: s.date, dateOnly: true}, n, nil
+		}
+		if s.date == nil {
+			return s.clock, n, nil
+		}
+		st := onDate(*s.date, *s.clock.start)
+		ts := &timeStamp{start: &st}
+		if s.clock.end != nil {
+			// Keep the length of the range, which may end at midnight of the next day
+			// Example: @ fri 10pm-midnight
+			et := st.Add(s.clock.end.Sub(*s.clock.start))
+			ts.end = &et
+		}
+		return ts, n, nil
+	}
+
+	// Otherwise combine both sides into a range across days
+	from, to := sides[0], sides[1]
+	if to.empty() {
+		return nil, 0, parseErr(CodeTimeRange, "range requires an end after the separator").at(endWord-1, 0, 0).
+			hint("e.g. '@ mon 9am - wed 5pm'")
+	}
+	if (from.clock != nil && from.clock.end != nil) || (to.clock != nil && to.clock.end != nil) {
+		return nil, 0, parseErr(CodeTimeRange, "time ranges within a day cannot be combined with a range across days").
+			hint("write one range, e.g. '@ mon 9am - wed 5pm'")
+	}
+
+	// Without a date, the start falls on today and the end on the start's day
+	now := timeNow()
+	startDate := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if from.date != nil {
+		startDate = *from.date
+	}
+	endDate := startDate
+	if to.weekday {
+		// Example: @ mon - wed, on a Tuesday, is next Monday to the Wednesday after it
+		endDate = startDate.AddDate(0, 0, int(to.date.Weekday()-startDate.Weekday()+7)%7)
+	} else if to.date != nil {
+		endDate = *to.date
+	}
+
+	// Without a time, the start is the beginning of its day and the end the end of its day
+	st := time.Date(startDate.Year(), startDate.Month(), startDate.Day(), 0, 0, 0, 0, time.UTC)
+	if from.clock != nil {
+		st = onDate(startDate, *from.clock.start)
+	}
+	et := time.Date(endDate.Year(), endDate.Month(), endDate.Day(), 23, 59, 0, 0, time.UTC)
+	if to.clock != nil {
+		et = onDate(endDate, *to.clock.start)
+		// An end time without a date that isn't after the start runs overnight, from the
+		// afternoon into the morning; otherwise it's taken as a mistake
+		// Example: @ 10pm - 2am, but not @ 5pm - 2pm
+		if to.date == nil && !et.After(st) && st.Hour() >= 12 && et.Hour() < 12 {
+			et = et.AddDate(0, 0, 1)
+		}
+	}
+
+	// Validate that the end comes after the start
+	if !et.After(st) {
+		return nil, 0, parseErr(CodeTimeOrder, "end of the time range must come after its start: %s", strings.Join(args[:n], " ")).
+			at(endWord, endOff, 0)
+	}
+	return &timeStamp{start: &st, end: &et}, n, nil
+}
+
+// isWeekday reports whether a word is the name of a day of the week (e.g., "wed", "Friday")
+func isWeekday(s string) bool {
+	_, ok := weekdays[strings.ToLower(s)]
+	return ok
+}
+
+// isShift reports whether a word is a signed duration that moves a task's time (e.g., "+1h", "-30m")
+func isShift(s string) bool {
+	if len(s) < 2 || (s[0] != '+' && s[0] != '-') {
+		return false
+	}
+	_, err := parseDuration(s)
+	return err == nil
+}
+
+// isBareShift reports whether a word after '@' moves a task's time by itself: a signed duration
+// that isn't also a date, e.g. "+1h" or "-1h30m" but not "+1m" (a month from today) or "+2d"
+func isBareShift(s string) bool {
+	return isShift(s) && !readsAsDate(s)
+}
+
+// readsAsDate reports whether a word is a date by itself (e.g., "+1m", "fri")
+func readsAsDate(s string) bool {
+	_, err := parseDate(s)
+	return err == nil
+}
+
+// isShiftWord reports whether a word starts a shift rather than a time expression
+func isShiftWord(s string) bool {
+	return strings.EqualFold(s, "shift") || strings.EqualFold(s, "same-time")
+}
+
+// parseShift processes a time expression that moves a task's current time, keeping the length of
+// a range, and returns the number of words consumed; it returns nil if the expression sets a time.
+// A signed duration that is also a date needs the 'shift' keyword, so that '@ +1m' is a month
+// from today, as when adding a task.
+//
+// Example inputs:
+//   - "+1h", "-1h30m"                        -> moved by the duration
+//   - "shift +1h", "shift -30m", "shift +2d" -> moved by the duration ("+1m" is a minute)
+//   - "same-time fri"                        -> moved to Friday at the same time of day
+func parseShift(args []string) (*timeShift, int, error) {
+	if isBareShift(args[0]) {
+		d, _ := parseDuration(args[0])
+		return &timeShift{by: d}, 1, nil
+	}
+	if strings.EqualFold(args[0], "shift") {
+		if len(args) == 1 {
+			return nil, 0, parseErr(CodeTimeMissing, "'shift' requires a signed duration").at(0, len(args[0]), 0).
+				hint("e.g. '@ shift +1h' or '@ shift -2d'")
+		}
+		if !isShift(args[1]) {
+			return nil, 0, parseErr(CodeTimeInvalid, "invalid shift: %s", args[1]).at(1, 0, 0).
+				hint("give a signed duration like +2d, -30m or +1w")
+		}
+		d, _ := parseDuration(args[1])
+		return &timeShift{by: d}, 2, nil
+	}
+	if !strings.EqualFold(args[0], "same-time") {
+		return nil, 0, nil
+	}
+	if len(args) == 1 {
+		return nil, 0, parseErr(CodeTimeMissing, "'same-time' requires a date").at(0, len(args[0]), 0).
+			hint("e.g. '@ same-time fri'")
+	}
+	d, n, err := parseDatePhrase(args[1:])
+	if err != nil {
+		return nil, 0, timeWordErr(args[1], err).at(1, 0, 0)
+	}
+	// A time of day would be dropped, so it can't follow silently
+	if len(args) > n+1 && meantAsClock(args[n+1]) {
+		return nil, 0, parseErr(CodeTimeInvalid, "'same-time' keeps the time of day: %s", args[n+1]).at(n+1, 0, 0).
+			hint("to set a new time, write '@ %s %s'", strings.Join(args[1:n+1], " "), args[n+1])
+	}
+	return &timeShift{date: d}, n + 1, nil
+}
+
+// onDate combines the date of d with the time of day of clock
+// Example: d is "2024-01-20", clock is "2:30pm" -> "2024-01-20 14:30:00"
+func onDate(d time.Time, clock time.Time) time.Time {
+	return time.Date(d.Year(), d.Month(), d.Day(), clock.Hour(), clock.Minute(), 0, 0, time.UTC)
 }
 
 // parseTime is the main time parsing function that handles both dates and times
@@ -333,8 +1166,8 @@ func parseTime(s string) (*time.Time, *timeStamp, error) {
 		// If it's not a date, try to parse as a time
 		t1, t2, errT := parseTimeStamp(s)
 		if errT != nil {
-			// If both date and time parsing fail, return error
-			return nil, nil, errors.New("attempts to use invalid time statement")
+			// If both date and time parsing fail, explain the likelier of the two
+			return nil, nil, timeWordErr(s, errT)
 		}
 		// Successfully parsed as time, return as timeStamp
 		ts := &timeStamp{start: t1, end: t2}
@@ -345,255 +1178,202 @@ func parseTime(s string) (*time.Time, *timeStamp, error) {
 	}
 }
 
-// parseDate processes date expressions and returns a time.Time
-// This function handles various date formats and keywords
+// clockLike matches words meant as a time of day or a range of them, even if invalid (e.g. "13pm")
+var clockLike = regexp.MustCompile(`(?i)^\d[\d:h]*(am|pm)?(-\d[\d:h]*(am|pm)?)?$`)
+
+// meantAsClock reports whether a word is clearly meant as a time of day, having 'am'/'pm', ':' or 'h'
+func meantAsClock(s string) bool {
+	return clockLike.MatchString(s) && strings.ContainsAny(strings.ToLower(s), ":hap")
+}
+
+// timeWordErr explains why a word is neither a date nor a time: the time error errT if it looks
+// like a time of day, otherwise an invalid date with the closest keywords as a suggestion
+func timeWordErr(s string, errT error) *ParseError {
+	var pe *ParseError
+	if clockLike.MatchString(s) && errors.As(errT, &pe) {
+		return pe
+	}
+	e := parseErr(CodeDateInvalid, "invalid date or time: %s", s)
+	if sug := suggestDate(s); sug != nil {
+		return e.hint("%s", didYouMean(sug))
+	}
+	return e.hint("e.g. tmrw, fri, next mon, %s, 5pm or 17:00", timeNow().Format(dateFormats[0]))
+}
+
+// Forms of a single time of day accepted by parseClock
+var (
+	clock12     = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?(am|pm)$`) // 2pm, 2:30pm
+	clockColon  = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?$`)        // 14, 14:00, 9:30
+	clockH      = regexp.MustCompile(`^(\d{1,2})h(\d{2})?$`)            // 9h, 9h30
+	clockDigits = regexp.MustCompile(`^(\d{2})(\d{2})$`)                // 1400, 0930
+)
+
+// clockTime is a time of day as written, before ranges are resolved
+type clockTime struct {
+	hour, minute int
+	meridiem     string // "am" or "pm" when given in 12-hour format
+	explicit     bool   // unambiguous without a meridiem (24-hour forms, noon, midnight)
+}
+
+// parseClock processes a single time of day
 //
-// Example inputs:
-//  1. Keywords:
-//     - "eod" (end of day) -> today at 23:59
-//     - "now" -> current time
-//     - "tmrw" -> tomorrow
-//     - "yest" -> yesterday
-//  2. Days of week:
-//     - "mon", "tue", "wed", "thu", "fri"
-//     - "sat" or "eow" (end of week)
-//     - "sun"
-//  3. Custom date formats (defined in dateFormats)
-func parseDate(arg string) (*time.Time, error) {
-	// Get current time and end of today (23:59)
-	now := time.Now()
-	today := time.Date(now.Year(), now.Month(), now.Day(), 23, 59, 0, 0, time.UTC)
-
-	// Process different date keywords
-	switch arg {
-	case "eod": // End of Day
-		return &today, nil
-	case "now": // Current time
-		return &now, nil
-	case "tmrw": // Tomorrow
-		d := today.AddDate(0, 0, 1)
-		return &d, nil
-	case "yest": // Yesterday
-		d := today.AddDate(0, 0, -1)
-		return &d, nil
-	case "eow", "sat": // End of Week (Saturday)
-		// Calculate days until next Saturday
-		// Example: If today is Wednesday (3), then Saturday (6) - Wednesday (3) = 3 days
-		d := today.AddDate(0, 0, int(time.Saturday-today.Weekday()+7)%7)
-		return &d, nil
-	case "sun":
-		d := today.AddDate(0, 0, int(time.Sunday-today.Weekday()+7)%7)
-		return &d, nil
-	case "mon":
-		d := today.AddDate(0, 0, int(time.Monday-today.Weekday()+7)%7)
-		return &d, nil
-	case "tue":
-		d := today.AddDate(0, 0, int(time.Tuesday-today.Weekday()+7)%7)
-		return &d, nil
-	case "wed":
-		d := today.AddDate(0, 0, int(time.Wednesday-today.Weekday()+7)%7)
-		return &d, nil
-	case "thu":
-		d := today.AddDate(0, 0, int(time.Thursday-today.Weekday()+7)%7)
-		return &d, nil
-	case "fri":
-		d := today.AddDate(0, 0, int(time.Friday-today.Weekday()+7)%7)
-		return &d, nil
-	default:
-		// If not a keyword, try parsing with predefined date formats
-		for _, v := range dateFormats {
-			t, err := time.Parse(v, arg)
-			if err == nil {
-				return &t, nil
-			}
+// Valid formats:
+//   - 12-hour: "2pm", "2:30pm", "11AM"
+//   - 24-hour: "14:00", "1400", "9h30", "9h", "14"
+//   - Keywords: "noon", "midnight"
+func parseClock(arg string) (clockTime, *ParseError) {
+	s := strings.ToLower(arg)
+	switch s {
+	case "noon":
+		return clockTime{hour: 12, explicit: true}, nil
+	case "midnight":
+		return clockTime{hour: 0, explicit: true}, nil
+	}
+
+	var c clockTime
+	var m []string
+	if m = clock12.FindStringSubmatch(s); m != nil {
+		c.meridiem = m[3]
+	} else if m = clockColon.FindStringSubmatch(s); m != nil {
+		// A leading zero or an hour past 12 can only be 24-hour, otherwise a range's end
+		// may still take its meridiem from the start (e.g., "10am-4:15")
+		c.explicit = len(m[1]) == 2 && m[1][0] == '0'
+	} else if m = clockH.FindStringSubmatch(s); m != nil {
+		c.explicit = true
+	} else if m = clockDigits.FindStringSubmatch(s); m != nil {
+		c.explicit = true
+	} else {
+		return c, parseErr(CodeTimeInvalid, "invalid time: %s", arg).hint("e.g. 5pm, 5:30pm, 17:00, 1730 or 9h30")
+	}
+	c.hour, _ = strconv.Atoi(m[1])
+	if m[2] != "" {
+		c.minute, _ = strconv.Atoi(m[2])
+	}
+
+	// Validate the hour for its format
+	if c.meridiem != "" && (c.hour < 1 || c.hour > 12) {
+		e := parseErr(CodeTimeInvalid, "hours must be 1-12 with 'am'/'pm': %s", arg)
+		if c.hour < 24 {
+			e.hint("use 24h (%02d:%02d) or am/pm (%s), not both", c.hour, c.minute, clock12Of(c.hour, c.minute))
 		}
-		return nil, fmt.Errorf("invalid date format: %s", arg)
+		return c, e
+	}
+	if c.hour > 23 {
+		return c, parseErr(CodeTimeInvalid, "hours cannot be greater than 23: %s", arg)
 	}
+	if c.hour > 12 {
+		c.explicit = true
+	}
+	if c.minute > 59 {
+		return c, parseErr(CodeTimeInvalid, "minutes cannot be greater than 59: %s", arg)
+	}
+	return c, nil
 }
 
-// parseTimeStamp processes time expressions in 12-hour format
-// This function handles complex time formats including ranges
+// clock12Of formats a time of day on a 12-hour clock, e.g. 13:00 as "1pm"
+func clock12Of(h, m int) string {
+	suffix := "am"
+	if h >= 12 {
+		suffix = "pm"
+	}
+	if h %= 12; h == 0 {
+		h = 12
+	}
+	if m == 0 {
+		return fmt.Sprintf("%d%s", h, suffix)
+	}
+	return fmt.Sprintf("%d:%02d%s", h, m, suffix)
+}
+
+// hour24 returns the hour on a 24-hour clock, reading a missing meridiem as meridiem
+func (c clockTime) hour24(meridiem string) int {
+	if c.meridiem != "" {
+		meridiem = c.meridiem
+	}
+	if c.explicit && c.meridiem == "" {
+		return c.hour
+	}
+	switch {
+	case meridiem == "pm" && c.hour != 12:
+		return c.hour + 12 // 2pm becomes 14
+	case meridiem == "am" && c.hour == 12:
+		return 0 // 12am becomes 00
+	}
+	return c.hour
+}
+
+// parseTimeStamp processes time expressions in 12 or 24-hour format
+// This function handles single times and ranges within a day
 //
 // Valid formats:
 //  1. Single time:
 //     - "2pm", "2:30pm", "11am"
+//     - "14:00", "1400", "9h30"
+//     - "noon", "midnight"
 //  2. Time ranges:
-//     - "2pm-4pm"
-//     - "2:30pm-4:30pm"
+//     - "2pm-4pm", "2:30pm-4:30pm"
 //     - "2-4pm" (implicitly means 2pm-4pm)
+//     - "14:00-16:30", "9-17"
+//     - "10pm-midnight" (ends at midnight of the next day)
 //
 // Rules:
-//   - Hours must be 1-12
-//   - Minutes must be 00-59
+//   - Hours must be 1-12 with 'am'/'pm', 0-23 otherwise
+//   - Minutes must be 00-59 and have 2 digits
 //   - AM/PM is case-insensitive
-//   - Minutes must have 2 digits
-//   - Colons must come between hours and minutes
+//   - A range's start without 'am'/'pm' takes the end's, and an end without one falls after the start
+//   - The end must come after the start; ranges past midnight are written '@ 10pm - 2am'
 func parseTimeStamp(arg string) (*time.Time, *time.Time, error) {
-	// Flags to track what we've seen
-	hour, colon, minute, am, dash := false, false, false, false, false
-	// Values to store parsed time components
-	startHour, endHour, startMinute, endMinute := -1, -1, -1, -1
-	// Store AM/PM format for start and end times
-	startFormat, endFormat := "", ""
-
-	// Parse the time string character by character
-	// Example: "2:30pm-4:45pm"
-	for i := 0; i < len(arg); i++ {
-		switch arg[i] {
-		case ':':
-			// Handle colon between hours and minutes (e.g., "2:30")
-			if !hour || minute {
-				return nil, nil, fmt.Errorf("colon is expected after hour, not minute: %s", arg)
-			} else if am {
-				return nil, nil, fmt.Errorf("colon can never occur after 'am' or 'pm': %s", arg)
-			} else if colon {
-				return nil, nil, fmt.Errorf("colons cannot be duplicated for same hour, minute combination: %s", arg)
-			}
-			colon = true
-
-		case '-':
-			// Handle dash between start and end times (e.g., "2-4")
-			if !hour || dash {
-				return nil, nil, fmt.Errorf("dashes require an hour and cannot be duplicated: %s", arg)
-			}
-			// Reset flags for parsing end time
-			dash = true
-			hour = false
-			colon = false
-			minute = false
-			am = false
-
-		case 'a', 'A', 'p', 'P':
-			// Handle AM/PM indicators
-			if !hour || am {
-				return nil, nil, fmt.Errorf("'am'/'pm' cannot occur without an hour nor can there be duplicates: %s", arg)
-			}
-			// Check for 'm' or 'M' after 'a' or 'p'
-			if i+1 < len(arg) && (arg[i+1] == 'm' || arg[i+1] == 'M') {
-				s := arg[i : i+2] // Get "am" or "pm"
-				if !dash {
-					startFormat = s // Store format for start time
-				} else {
-					endFormat = s // Store format for end time
-				}
-			} else {
-				return nil, nil, fmt.Errorf("provided time tag besides valid 'am'/'pm': %s", arg)
-			}
-			am = true
-			i++ // Skip the 'm' we just processed
-
-		case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
-			// Handle numeric components (hours and minutes)
-			if hour && !colon {
-				// If we've seen an hour but no colon, we're in an invalid state
-				return nil, nil, fmt.Errorf("minutes must be separated by colon from hours: %s", arg)
-			} else if minute {
-				// Can't have more than one set of minutes
-				return nil, nil, fmt.Errorf("minutes cannot be duplicated: %s", arg)
-			} else if am {
-				// Numbers can't come after AM/PM
-				return nil, nil, fmt.Errorf("hours and minutes cannot come after 'am'/'pm' signature: %s", arg)
-			} else if hour {
-				// Processing minutes (must be two digits)
-				minute = true
-				if i+1 < len(arg) && arg[i+1] >= '0' && arg[i+1] <= '9' {
-					// Parse two-digit minutes
-					x, _ := strconv.Atoi(arg[i : i+2])
-					if x > 59 {
-						return nil, nil, fmt.Errorf("minutes cannot be greater than 59: %s", arg)
-					}
-					// Store minutes for start or end time
-					if !dash {
-						startMinute = x
-					} else {
-						endMinute = x
-					}
-					i++ // Skip the second digit we just processed
-				} else {
-					return nil, nil, fmt.Errorf("minutes require 2 digits: %s", arg)
-				}
-			} else {
-				// Processing hours (can be one or two digits)
-				hour = true
-				if i+1 >= len(arg) || arg[i+1] < '0' || arg[i+1] > '9' {
-					// Single-digit hour
-					x, _ := strconv.Atoi(arg[i : i+1])
-					if x > 12 {
-						return nil, nil, fmt.Errorf("hours cannot be greater than 12: %s", arg)
-					}
-					if !dash {
-						startHour = x
-					} else {
-						endHour = x
-					}
-				} else if i+1 < len(arg) && arg[i+1] >= '0' && arg[i+1] <= '9' {
-					// Two-digit hour
-					x, _ := strconv.Atoi(arg[i : i+2])
-					if x > 12 {
-						return nil, nil, fmt.Errorf("hours cannot be greater than 12: %s", arg)
-					}
-					if !dash {
-						startHour = x
-					} else {
-						endHour = x
-					}
-					i++ // Skip the second digit we just processed
-				} else {
-					return nil, nil, fmt.Errorf("minutes require 2 digits: %s", arg)
-				}
-			}
-		default:
-			// Any other character is invalid
-			return nil, nil, fmt.Errorf("invalid time format: %s", arg)
-		}
+	t := timeNow()
+	at := func(h, m int) *time.Time {
+		d := time.Date(t.Year(), t.Month(), t.Day(), h, m, 0, 0, time.UTC)
+		return &d
 	}
 
-	// Convert times from 12-hour to 24-hour format
+	startArg, endArg, isRange := strings.Cut(arg, "-")
+	start, err := parseClock(startArg)
+	if err != nil {
+		return nil, nil, err.at(0, 0, len(startArg))
+	}
 
-	// Handle start time AM/PM conversion
-	if (startFormat == "pm" || startFormat == "PM") && startHour != 12 {
-		startHour += 12 // 2pm becomes 14
-	} else if (startFormat == "am" || startFormat == "AM") && startHour == 12 {
-		startHour = 0 // 12am becomes 00
+	// If we only have a start time (no range)
+	if !isRange {
+		return at(start.hour24(""), start.minute), nil, nil
 	}
 
-	// Set default minutes if not specified
-	if startMinute == -1 {
-		startMinute = 0
+	end, err := parseClock(endArg)
+	if err != nil {
+		return nil, nil, err.at(0, len(startArg)+1, 0)
 	}
 
-	// If we only have a start time (no range)
-	if endHour == -1 {
-		// Create time object for the start time only
-		t := time.Now()
-		st := time.Date(t.Year(), t.Month(), t.Day(), startHour, startMinute, 0, 0, time.UTC)
-		return &st, nil, nil
+	// The start takes the end's 'am'/'pm' when that keeps it before the end
+	// Example: "2-4pm" means 2pm-4pm, "11-1pm" means 11am-1pm
+	sh := start.hour24("")
+	if start.meridiem == "" && !start.explicit && end.meridiem != "" {
+		if h := start.hour24(end.meridiem); h*60+start.minute < end.hour24("")*60+end.minute {
+			sh = h
+		}
 	}
+	st := at(sh, start.minute)
 
-	// Handle end time AM/PM conversion
-	if (endFormat == "pm" || endFormat == "PM") && endHour != 12 {
-		endHour += 12 // 4pm becomes 16
-	} else if (endFormat == "am" || endFormat == "AM") && endHour == 12 {
-		endHour = 0 // 12am becomes 00
-	} else if endFormat == "" && endHour < startHour {
-		// If no AM/PM specified for end time and it's less than start,
-		// assume PM (e.g., "2-4" means "2pm-4pm")
-		endHour += 12
+	// The end falls after the start when it has no 'am'/'pm'
+	// Example: "2pm-4" means 2pm-4pm
+	eh := end.hour24("")
+	if end.meridiem == "" && !end.explicit && eh*60+end.minute <= sh*60+start.minute && eh < 12 {
+		eh += 12
 	}
+	et := at(eh, end.minute)
 
-	// Set default end minutes if not specified
-	if endMinute == -1 {
-		endMinute = 0
+	// An end at midnight closes the day
+	if eh == 0 && end.minute == 0 {
+		d := et.AddDate(0, 0, 1)
+		et = &d
 	}
 
 	// Validate that end time is after start time
-	if startHour > endHour || (startHour == endHour && startMinute >= endMinute) {
-		return nil, nil, fmt.Errorf("starting time must be earlier than ending time: %s", arg)
+	if !et.After(*st) {
+		return nil, nil, parseErr(CodeTimeOrder, "starting time must be earlier than ending time: %s", arg).
+			at(0, len(startArg)+1, 0).
+			hint("use 24h or add am/pm; for ranges past midnight write '@ %s - %s'", startArg, endArg)
 	}
-
-	// Create time objects for both start and end times
-	t := time.Now()
-	st := time.Date(t.Year(), t.Month(), t.Day(), startHour, startMinute, 0, 0, time.UTC)
-	et := time.Date(t.Year(), t.Month(), t.Day(), endHour, endMinute, 0, 0, time.UTC)
-	return &st, &et, nil
+	return st, et, nil
 }
@@ -0,0 +1,516 @@
+package cobra
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EvoSched/gotask/internal/types"
+)
+
+func TestParseTimeStamp(t *testing.T) {
+	withClock(t, fixedNow)
+	at := func(h, m int) time.Time { return time.Date(2024, 10, 14, h, m, 0, 0, time.UTC) }
+	tests := []struct {
+		in         string
+		start, end time.Time
+	}{
+		{"2pm", at(14, 0), time.Time{}},
+		{"2:30PM", at(14, 30), time.Time{}},
+		{"12am", at(0, 0), time.Time{}},
+		{"12pm", at(12, 0), time.Time{}},
+		{"14:00", at(14, 0), time.Time{}},
+		{"1400", at(14, 0), time.Time{}},
+		{"0930", at(9, 30), time.Time{}},
+		{"9h30", at(9, 30), time.Time{}},
+		{"9h", at(9, 0), time.Time{}},
+		{"noon", at(12, 0), time.Time{}},
+		{"midnight", at(0, 0), time.Time{}},
+		{"2pm-4pm", at(14, 0), at(16, 0)},
+		{"2-4pm", at(14, 0), at(16, 0)},
+		{"11-1pm", at(11, 0), at(13, 0)},
+		{"2pm-4", at(14, 0), at(16, 0)},
+		{"10am-4:15", at(10, 0), at(16, 15)},
+		{"2pm-4:30", at(14, 0), at(16, 30)},
+		{"09:00-17:30", at(9, 0), at(17, 30)},
+		{"11-3", at(11, 0), at(15, 0)},
+		{"9-17", at(9, 0), at(17, 0)},
+		{"14:00-16:30", at(14, 0), at(16, 30)},
+		{"9h30-12h", at(9, 30), at(12, 0)},
+		{"noon-2pm", at(12, 0), at(14, 0)},
+		{"10pm-midnight", at(22, 0), at(24, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			st, et, err := parseTimeStamp(tt.in)
+			if err != nil {
+				t.Fatalf("parseTimeStamp(%q) error: %v", tt.in, err)
+			}
+			if !st.Equal(tt.start) {
+				t.Errorf("parseTimeStamp(%q) start = %s, want %s", tt.in, st, tt.start)
+			}
+			if tt.end.IsZero() != (et == nil) || (et != nil && !et.Equal(tt.end)) {
+				t.Errorf("parseTimeStamp(%q) end = %v, want %s", tt.in, et, tt.end)
+			}
+		})
+	}
+}
+
+func TestParseTimeStampInvalid(t *testing.T) {
+	withClock(t, fixedNow)
+	for _, in := range []string{"13pm", "0am", "24:00", "9:60", "9:5", "4pm-2pm", "10pm-2am", "2pm-", "abc", "2pm-4pm-6pm"} {
+		if st, _, err := parseTimeStamp(in); err == nil {
+			t.Errorf("parseTimeStamp(%q) = %s, want error", in, st)
+		}
+	}
+}
+
+func TestParseTimeExpr(t *testing.T) {
+	tue := fixedNow.AddDate(0, 0, 1)
+	at := func(d, h, m int) time.Time { return time.Date(2024, 10, d, h, m, 0, 0, time.UTC) }
+	tests := []struct {
+		now        time.Time
+		in         string
+		start, end time.Time
+	}{
+		{fixedNow, "mon 9am - wed 5pm", at(21, 9, 0), at(23, 17, 0)},
+		{fixedNow, "mon - wed", at(21, 0, 0), at(23, 23, 59)},
+		{fixedNow, "mon..wed", at(21, 0, 0), at(23, 23, 59)},
+		{tue, "mon 9am - wed 5pm", at(21, 9, 0), at(23, 17, 0)},
+		{tue, "mon - wed", at(21, 0, 0), at(23, 23, 59)},
+		{fixedNow, "wed - mon", at(16, 0, 0), at(21, 23, 59)},
+		{fixedNow, "fri 10pm - sat 2am", at(18, 22, 0), at(19, 2, 0)},
+		{fixedNow, "fri 10pm - 2am", at(18, 22, 0), at(19, 2, 0)},
+		{fixedNow, "10pm - 2am", at(14, 22, 0), at(15, 2, 0)},
+		{fixedNow, "fri 23:00 - 01:00", at(18, 23, 0), at(19, 1, 0)},
+		{fixedNow, "9am - wed 5pm", at(14, 9, 0), at(16, 17, 0)},
+		{fixedNow, "2024-10-20 .. 2024-10-22", at(20, 0, 0), at(22, 23, 59)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.now.Weekday().String()+" "+tt.in, func(t *testing.T) {
+			withClock(t, tt.now)
+			ts, _, err := parseTimeExpr(strings.Fields(tt.in))
+			if err != nil {
+				t.Fatalf("parseTimeExpr(%q) error: %v", tt.in, err)
+			}
+			if !ts.start.Equal(tt.start) || ts.end == nil || !ts.end.Equal(tt.end) {
+				t.Errorf("parseTimeExpr(%q) = %s - %v, want %s - %s", tt.in, ts.start, ts.end, tt.start, tt.end)
+			}
+		})
+	}
+}
+
+func TestParseTimeExprInvalid(t *testing.T) {
+	withClock(t, fixedNow)
+	for _, in := range []string{"fri 5pm - 2pm", "fri 2am - 1am", "fri 23:00-01:00", "mon 9am -", "- wed"} {
+		if ts, _, err := parseTimeExpr(strings.Fields(in)); err == nil {
+			t.Errorf("parseTimeExpr(%q) = %s - %v, want error", in, ts.start, ts.end)
+		}
+	}
+}
+
+func TestSplitLine(t *testing.T) {
+	type word struct {
+		text    string
+		literal bool
+	}
+	tests := []struct {
+		in   string
+		want []word
+	}{
+		{"Buy milk +home", []word{{"Buy", false}, {"milk", false}, {"+home", false}}},
+		{`"Call +1 555" %2`, []word{{"Call +1 555", true}, {"%2", false}}},
+		{`\+1 reviewer`, []word{{"+1", true}, {"reviewer", false}}},
+		{`don't  panic`, []word{{"don't", false}, {"panic", false}}},
+		{`'a \b' "a \"b\" \c"`, []word{{`a \b`, true}, {`a "b" \c`, true}}},
+		{`+a\&b sprint:week\ 12`, []word{{"+a&b", false}, {"sprint:week 12", false}}},
+		{"\"\\$5 \\`x\\`\"", []word{{"$5 `x`", true}}},
+		{`   `, nil},
+	}
+	for _, tt := range tests {
+		got, err := splitLine(tt.in)
+		if err != nil {
+			t.Errorf("splitLine(%q) error: %v", tt.in, err)
+			continue
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("splitLine(%q) = %v, want %v", tt.in, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if (word{got[i].text, got[i].literal}) != tt.want[i] {
+				t.Errorf("splitLine(%q) = %v, want %v", tt.in, got, tt.want)
+				break
+			}
+		}
+	}
+	for _, in := range []string{`"unterminated`, `trailing\`} {
+		if _, err := splitLine(in); err == nil {
+			t.Errorf("splitLine(%q) succeeded, want error", in)
+		}
+	}
+}
+
+func TestParseQuickAdd(t *testing.T) {
+	withClock(t, fixedNow)
+	tests := []struct {
+		args []string
+		line string
+		desc string
+		tags int
+		prio int
+	}{
+		// arguments split by the shell, old and new styles
+		{args: []string{"Buy milk", "+home", "%3"}, desc: "Buy milk", tags: 1, prio: 3},
+		{args: []string{"Buy", "milk", "+home", "@", "tmrw", "5pm", "%3"}, desc: "Buy milk", tags: 1, prio: 3},
+		{args: []string{"Review", "@tmrw", "with", "team"}, desc: "Review with team"},
+		{args: []string{`\+1`, "the", "review"}, desc: "+1 the review"},
+		{args: []string{"Fix +1 bug"}, desc: "Fix +1 bug"},
+		{args: []string{"Fix", "100%", "of", "bugs", "+"}, desc: "Fix 100% of bugs +"},
+		// whole lines
+		{line: "Buy milk +home @ tmrw 5pm %3", desc: "Buy milk", tags: 1, prio: 3},
+		{line: `"+1" the review %2`, desc: "+1 the review", prio: 2},
+		{line: `Email about \%2 raise`, desc: "Email about %2 raise"},
+	}
+	for _, tt := range tests {
+		var ti *taskInfo
+		var err error
+		if tt.line != "" {
+			ti, err = parseLine(tt.line)
+		} else {
+			ti, err = parseTask(tt.args, true)
+		}
+		in := tt.line + strings.Join(tt.args, " ")
+		if err != nil {
+			t.Errorf("parse %q error: %v", in, err)
+			continue
+		}
+		if ti.desc == nil || *ti.desc != tt.desc {
+			t.Errorf("parse %q desc = %v, want %q", in, ti.desc, tt.desc)
+		}
+		if len(ti.addTags) != tt.tags {
+			t.Errorf("parse %q tags = %v, want %d", in, ti.addTags, tt.tags)
+		}
+		if (ti.priority == nil) != (tt.prio == 0) || (ti.priority != nil && *ti.priority != tt.prio) {
+			t.Errorf("parse %q priority = %v, want %d", in, ti.priority, tt.prio)
+		}
+	}
+
+	for _, line := range []string{"+home @ tmrw", "a %1 %2", "a @ tmrw @ fri", `a "b`} {
+		if _, err := parseLine(line); err == nil {
+			t.Errorf("parseLine(%q) succeeded, want error", line)
+		}
+	}
+}
+
+func TestParseDiagnostics(t *testing.T) {
+	withClock(t, fixedNow)
+	tests := []struct {
+		line string
+		code string
+		col  int // byte offset of the caret in the line
+		hint string
+	}{
+		{"Buy milk @ tommorow 5pm", CodeDateInvalid, 11, "'tomorrow' or 'tmrw'"},
+		{"Call @ fri 13pm", CodeTimeInvalid, 11, "use 24h (13:00) or am/pm (1pm)"},
+		{"Call @fri 13pm", CodeTimeInvalid, 10, "use 24h"},
+		{"Call @13pm", CodeTimeInvalid, 6, "use 24h"},
+		{"Meet @ 4pm-2pm", CodeTimeOrder, 11, "use 24h or add am/pm"},
+		{"Meet @ 2-3:75pm", CodeTimeInvalid, 9, ""},
+		{"Meet @ 9am 10am", CodeTimeDuplicate, 11, ""},
+		{"Meet @ tmrw 5pm 3:75", CodeTimeInvalid, 16, ""},
+		{"Trip @ 2024-11-05..2024-11-01", CodeTimeOrder, 19, ""},
+		{"Trip @ mon -", CodeTimeRange, 11, ""},
+		{"a @", CodeTimeMissing, 3, ""},
+		{"a @ tmrw @ fri", CodeTimeDuplicate, 9, "escape"},
+		{"a %9", CodePriorityInvalid, 3, "priority must be"},
+		{"a %1 %2", CodePriorityDuplicate, 5, ""},
+		{"a remind:soon", CodeReminderInvalid, 9, ""},
+		{"+home", CodeDescMissing, 5, ""},
+		{`a "b`, CodeQuoteUnterminated, 2, ""},
+		{`a b\`, CodeEscapeTrailing, 3, ""},
+	}
+	for _, tt := range tests {
+		_, err := parseLine(tt.line)
+		pe, ok := err.(*ParseError)
+		if !ok {
+			t.Errorf("parseLine(%q) error = %v, want *ParseError", tt.line, err)
+			continue
+		}
+		if pe.Code != tt.code || pe.Col != tt.col || !strings.Contains(pe.Hint, tt.hint) {
+			t.Errorf("parseLine(%q) = %s at %d (hint %q), want %s at %d (hint %q)",
+				tt.line, pe.Code, pe.Col, pe.Hint, tt.code, tt.col, tt.hint)
+		}
+	}
+}
+
+func TestParseDiagnosticsArgs(t *testing.T) {
+	_, err := parseTask([]string{"9x", "Fix bug"}, false)
+	pe, ok := err.(*ParseError)
+	if !ok || pe.Code != CodeRefInvalid || pe.Input != "9x 'Fix bug'" || pe.Col != 0 {
+		t.Errorf("parseTask ref error = %#v", err)
+	}
+	_, err = parseTask([]string{"Report", "@", "fri", "25pm"}, true)
+	if pe, ok = err.(*ParseError); !ok || pe.Col != 13 || pe.Length != 4 {
+		t.Errorf("parseTask time error = %#v", err)
+	}
+	_, err = parseFilter([]string{"+a", "@", "before", "tommorow"})
+	if pe, ok = err.(*ParseError); !ok || pe.Code != CodeFilterInvalid || pe.Col != 12 || pe.Hint == "" {
+		t.Errorf("parseFilter error = %#v", err)
+	}
+}
+
+func TestParseGet(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"1", "3"}, "1 3"},
+		{[]string{"3-5,9"}, "3 4 5 9"},
+		{[]string{"2,2-3"}, "2 2 3"},
+		{[]string{"LAST", "3F2A9C"}, "last 3f2a9c"},
+		{[]string{"~fix, then test"}, "~fix, then test"},
+		{[]string{"3f2a9c1e-8b7d"}, "3f2a9c1e-8b7d"},
+	}
+	for _, tt := range tests {
+		refs, err := parseGet(tt.args)
+		if err != nil {
+			t.Errorf("parseGet(%q) error: %v", tt.args, err)
+			continue
+		}
+		if got := strings.Join(refs, " "); got != tt.want {
+			t.Errorf("parseGet(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestParseGetInvalid(t *testing.T) {
+	tests := []struct {
+		args   []string
+		col    int
+		length int
+		msg    string
+	}{
+		{[]string{"1", "7-3"}, 2, 3, "ends before it starts"},
+		{[]string{"1,,2"}, 2, 1, "empty task reference"},
+		{[]string{"4,x!,5"}, 2, 2, "invalid task reference"},
+		{[]string{"1-5000"}, 0, 6, "too large"},
+		{[]string{"~"}, 0, 1, "missing description"},
+	}
+	for _, tt := range tests {
+		_, err := parseGet(tt.args)
+		pe, ok := err.(*ParseError)
+		if !ok || pe.Code != CodeRefInvalid || pe.Col != tt.col || pe.Length != tt.length || !strings.Contains(pe.Msg, tt.msg) {
+			t.Errorf("parseGet(%q) error = %#v, want %q at %d", tt.args, err, tt.msg, tt.col)
+		}
+	}
+
+	_, err := parseTask([]string{"3-5", "+x"}, false)
+	if pe, ok := err.(*ParseError); !ok || !strings.Contains(pe.Hint, "gt mod 3-5 --") {
+		t.Errorf("parseTask with a range = %#v", err)
+	}
+}
+
+func TestSuggestDate(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"tommorow", "tomorrow tmrw"},
+		{"tomorow", "tomorrow tmrw"},
+		{"yesterdy", "yesterday yest"},
+		{"frday", "friday"},
+		{"wendsday", "wednesday"},
+		{"tomorrow", ""},
+		{"milk", ""},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(suggestDate(tt.in), " "); got != tt.want {
+			t.Errorf("suggestDate(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	e := parseErr(CodeDateInvalid, "invalid date or time: tommorow").hint("did you mean 'tomorrow'?")
+	e.Input, e.Col, e.Length = "Buy milk @ tommorow", 11, 8
+	want := "error[date-invalid]: invalid date or time: tommorow\n" +
+		"  Buy milk @ tommorow\n" +
+		"             ^^^^^^^^\n" +
+		"  hint: did you mean 'tomorrow'?\n"
+	if got := e.Render(); got != want {
+		t.Errorf("Render() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestParseShift(t *testing.T) {
+	withClock(t, fixedNow)
+	fri := time.Date(2024, 10, 18, 23, 59, 0, 0, time.UTC)
+	tests := []struct {
+		args []string
+		by   time.Duration
+		date *time.Time
+	}{
+		{[]string{"5", "@", "+1h"}, time.Hour, nil},
+		{[]string{"5", "@", "-1h30m", "+work"}, -90 * time.Minute, nil},
+		{[]string{"5", "@", "shift", "+1h"}, time.Hour, nil},
+		{[]string{"5", "@shift", "+2d"}, 48 * time.Hour, nil},
+		{[]string{"5", "@", "shift", "-1d12h", "+work"}, -36 * time.Hour, nil},
+		{[]string{"5", "@", "shift", "+1m"}, time.Minute, nil},
+		{[]string{"5", "@", "same-time", "fri"}, 0, &fri},
+	}
+	for _, tt := range tests {
+		ti, err := parseTask(tt.args, false)
+		if err != nil {
+			t.Errorf("parseTask(%q) error: %v", tt.args, err)
+			continue
+		}
+		sh := ti.shift
+		if sh == nil || sh.by != tt.by || (sh.date == nil) != (tt.date == nil) || (sh.date != nil && !sh.date.Equal(*tt.date)) {
+			t.Errorf("parseTask(%q) shift = %+v, want %v %v", tt.args, sh, tt.by, tt.date)
+		}
+	}
+
+	// without 'shift', offsets that are also dates are dates as in add, and shifts only apply to
+	// existing tasks
+	for _, tt := range []struct {
+		word string
+		want time.Time
+	}{
+		{"+3", time.Date(2024, 10, 17, 23, 59, 0, 0, time.UTC)},
+		{"+3d", time.Date(2024, 10, 17, 23, 59, 0, 0, time.UTC)},
+		{"+1m", time.Date(2024, 11, 14, 23, 59, 0, 0, time.UTC)},
+	} {
+		for _, isAdd := range []bool{true, false} {
+			ti, err := parseTask([]string{"5", "@", tt.word}, isAdd)
+			if err != nil || ti.shift != nil || ti.startAt == nil || !ti.startAt.Equal(tt.want) {
+				t.Errorf("parseTask(5 @ %s, add %v) = %+v, %v, want %s", tt.word, isAdd, ti, err, tt.want)
+			}
+		}
+	}
+	for _, args := range [][]string{{"x", "@", "+1h"}, {"x", "@", "shift", "+1h"}, {"x", "@", "same-time", "fri"}} {
+		if _, err := parseTask(args, true); err == nil {
+			t.Errorf("parseTask(%q, add) succeeded, want error", args)
+		}
+	}
+	for _, args := range [][]string{{"5", "@", "+1x"}, {"5", "@", "shift"}, {"5", "@", "shift", "fri"},
+		{"5", "@", "same-time"}, {"5", "@", "same-time", "fri", "9am"}, {"5", "@", "same-time", "someday"}} {
+		if _, err := parseTask(args, false); err == nil {
+			t.Errorf("parseTask(%q) succeeded, want error", args)
+		}
+	}
+}
+
+func TestParsePostpone(t *testing.T) {
+	withClock(t, fixedNow)
+	sel, sh, err := parsePostpone([]string{"+work", "@", "before", "tmrw", "+1d"})
+	if err != nil || strings.Join(sel, " ") != "+work @ before tmrw" || sh.by != 24*time.Hour {
+		t.Errorf("parsePostpone = %q, %+v, %v", sel, sh, err)
+	}
+	sel, sh, err = parsePostpone([]string{"3", "shift", "-30m"})
+	if err != nil || strings.Join(sel, " ") != "3" || sh.by != -30*time.Minute {
+		t.Errorf("parsePostpone = %q, %+v, %v", sel, sh, err)
+	}
+	sel, sh, err = parsePostpone([]string{"3", "4", "same-time", "next", "mon"})
+	if err != nil || strings.Join(sel, " ") != "3 4" || sh.date == nil || sh.date.Day() != 21 {
+		t.Errorf("parsePostpone = %q, %+v, %v", sel, sh, err)
+	}
+	sel, sh, err = parsePostpone([]string{"3", "+1h30m"})
+	if err != nil || strings.Join(sel, " ") != "3" || sh.by != 90*time.Minute {
+		t.Errorf("parsePostpone = %q, %+v, %v", sel, sh, err)
+	}
+	// '+30m' is a month after '@', so it needs 'shift' to be minutes here too
+	for _, args := range [][]string{{"+1d"}, {"5", "+2x"}, {"5", "+30m"}, {"5", "same-time", "fri", "extra"}} {
+		if _, _, err := parsePostpone(args); err == nil {
+			t.Errorf("parsePostpone(%q) succeeded, want error", args)
+		}
+	}
+}
+
+func TestApplyModsTime(t *testing.T) {
+	withClock(t, fixedNow)
+	at := func(d, h, m int) *time.Time {
+		v := time.Date(2024, 10, d, h, m, 0, 0, time.UTC)
+		return &v
+	}
+	tests := []struct {
+		args       []string
+		start, end *time.Time
+	}{
+		// a new time of day keeps the length of the range, a date alone doesn't
+		{[]string{"1", "@", "fri", "2pm"}, at(18, 14, 0), at(18, 15, 30)},
+		{[]string{"1", "@", "fri", "2-3pm"}, at(18, 14, 0), at(18, 15, 0)},
+		{[]string{"1", "@", "fri"}, at(18, 23, 59), nil},
+		// shifts move both ends
+		{[]string{"1", "@", "shift", "+1h"}, at(15, 11, 0), at(15, 12, 30)},
+		{[]string{"1", "@", "shift", "-1d"}, at(14, 10, 0), at(14, 11, 30)},
+		{[]string{"1", "@", "same-time", "fri"}, at(18, 10, 0), at(18, 11, 30)},
+	}
+	for _, tt := range tests {
+		task := &types.Task{StartAt: at(15, 10, 0), EndAt: at(15, 11, 30)}
+		ti, err := parseTask(tt.args, false)
+		if err != nil {
+			t.Fatalf("parseTask(%q) error: %v", tt.args, err)
+		}
+		applyMods(task, ti)
+		sameTime := func(x, y *time.Time) bool { return (x == nil) == (y == nil) && (x == nil || x.Equal(*y)) }
+		if !sameTime(task.StartAt, tt.start) || !sameTime(task.EndAt, tt.end) {
+			t.Errorf("mod %q time = %v - %v, want %v - %v", tt.args, task.StartAt, task.EndAt, tt.start, tt.end)
+		}
+	}
+
+	// tasks without a time are given one from today
+	for _, tt := range []struct {
+		args  []string
+		start *time.Time
+	}{
+		{[]string{"1", "@", "+2d"}, at(16, 23, 59)},
+		{[]string{"1", "@", "shift", "+2d"}, at(16, 23, 59)},
+		{[]string{"1", "@", "shift", "+1h"}, at(14, 11, 30)},
+		{[]string{"1", "@", "same-time", "fri"}, at(18, 23, 59)},
+	} {
+		task := &types.Task{}
+		ti, err := parseTask(tt.args, false)
+		if err != nil {
+			t.Fatalf("parseTask(%q) error: %v", tt.args, err)
+		}
+		if applyMods(task, ti); task.StartAt == nil || !task.StartAt.Equal(*tt.start) || task.EndAt != nil {
+			t.Errorf("mod %q of a task without a time = %v - %v, want %v", tt.args, task.StartAt, task.EndAt, tt.start)
+		}
+	}
+}
+
+func TestParseItemArgs(t *testing.T) {
+	parsers := map[string]func([]string) error{
+		"note":      func(args []string) error { _, _, err := parseNote(args); return err },
+		"attach":    func(args []string) error { _, _, err := parseAttach(args); return err },
+		"check add": func(args []string) error { _, _, err := parseCheckAdd(args); return err },
+		"item":      func(args []string) error { _, _, err := parseItemRef(args); return err },
+	}
+	tests := []struct {
+		parser string
+		args   []string
+		code   string
+		col    int
+	}{
+		{"note", []string{"abc!", "Remember tests"}, CodeRefInvalid, 0},
+		{"attach", []string{"1-3", "notes.txt"}, CodeRefInvalid, 0},
+		{"check add", []string{"x!", "Write tests"}, CodeRefInvalid, 0},
+		{"check add", []string{"5", " "}, CodeItemMissing, 2},
+		{"item", []string{"5", "0"}, CodeItemInvalid, 2},
+		{"item", []string{"5", "two"}, CodeItemInvalid, 2},
+	}
+	for _, tt := range tests {
+		var pe *ParseError
+		err := parsers[tt.parser](tt.args)
+		if !errors.As(err, &pe) || pe.Code != tt.code || pe.Col != tt.col || pe.Input == "" {
+			t.Errorf("%s %q error = %#v, want %s at column %d", tt.parser, tt.args, err, tt.code, tt.col)
+		}
+	}
+	for name, parse := range parsers {
+		if err := parse([]string{"5", "2"}); err != nil {
+			t.Errorf("%s error: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,16 @@
+//go:build !(linux || darwin || dragonfly || freebsd || netbsd || openbsd)
+
+package cobra
+
+import (
+	"errors"
+	"os"
+)
+
+// rawMode isn't supported here, so 'gt tui' isn't either
+func rawMode(f *os.File) (func(), error) {
+	return nil, errors.New("the interactive interface needs a Unix terminal")
+}
+
+// notifyResize never sends; the size is read again on each key instead
+func notifyResize(ch chan<- os.Signal) {}
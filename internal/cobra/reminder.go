@@ -0,0 +1,140 @@
+package cobra
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/EvoSched/gotask/internal/config"
+	"github.com/EvoSched/gotask/internal/notify"
+	"github.com/EvoSched/gotask/internal/types"
+	"github.com/spf13/cobra"
+)
+
+func (c *Cmd) DaemonCmd() *cobra.Command {
+	daemonCmd := &cobra.Command{
+		Use:   "daemon",
+		Short: "Run the reminder daemon",
+		Long: `Runs in the foreground and fires reminders through the notifiers configured under 'reminders.notifiers'
+(notify-send, bell, file or exec). The database is checked whenever it changes and at the configured interval.
+Reminders that came due while the daemon wasn't running fire as soon as it starts.`,
+		Example: "gt daemon",
+		Args:    cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			cfgs := c.cfg.Reminders.Notifiers
+			if len(cfgs) == 0 {
+				cfgs = []config.Notifier{{Type: config.NotifierBell}}
+			}
+			var notifiers []notify.Notifier
+			for _, nc := range cfgs {
+				n, err := notify.New(nc)
+				if err != nil {
+					log.Fatal(err)
+				}
+				notifiers = append(notifiers, n)
+			}
+			notify.FormatTime = formatDateTime
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			c.printf("Watching for reminders with %d notifier(s). Press Ctrl+C to stop.\n", len(notifiers))
+			d := notify.NewDaemon(c.repo, notifiers, c.cfg.Reminders.Interval, c.cfg.SQLite.Database)
+			if err := d.Run(ctx); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	return daemonCmd
+}
+
+func (c *Cmd) SnoozeCmd() *cobra.Command {
+	snoozeCmd := &cobra.Command{
+		Use:         "snooze",
+		Annotations: changesTasks,
+		Short:       "Snooze the reminders of a task by ID",
+		Long: `Re-arms the reminders of a task that have fired or are due, so they fire again after the given duration.
+
+Required:
+- id        Id referencing task.
+
+Optional:
+- duration  How long to snooze for (e.g. 10m, 1h, 1d), defaults to 'reminders.snooze' in config.`,
+		Example: "gt snooze 1\ngt snooze 1 1h",
+		Args:    cobra.RangeArgs(1, 2),
+		Run: func(cmd *cobra.Command, args []string) {
+			ref, d, err := parseSnooze(args)
+			if err != nil {
+				fatal(err)
+			}
+			if d == 0 {
+				d = c.cfg.Reminders.Snooze
+			}
+			id, err := c.resolveID(ref)
+			if err != nil {
+				log.Fatal(err)
+			}
+			t, err := c.repo.GetTask(id)
+			if err != nil {
+				log.Fatal(err)
+			}
+			now := wallNow()
+			until := now.Add(d)
+			n := 0
+			for _, r := range t.Reminders {
+				due := r.Due(t)
+				if r.FiredAt == nil && (due == nil || due.After(now)) {
+					continue
+				}
+				err = c.repo.SnoozeReminder(r.ID, until)
+				if err != nil {
+					log.Fatal(err)
+				}
+				n++
+			}
+			if n == 0 {
+				log.Fatalf("task %d has no reminders that have fired or are due", t.ShortID)
+			}
+			c.printf("Snoozed %d reminder(s) of task %d '%s' until %s.\n", n, t.ShortID, t.Desc, formatDateTime(until))
+			c.changedTasks(t)
+		},
+	}
+	return snoozeCmd
+}
+
+// checkReminders ensures reminders relative to the task's time have a time to be relative to
+func checkReminders(t *types.Task) error {
+	for _, r := range t.Reminders {
+		if r.Offset != nil && t.StartAt == nil {
+			return errors.New("relative reminders require the task to have a time, set with '@'")
+		}
+	}
+	return nil
+}
+
+func formatReminder(r types.Reminder, t *types.Task) string {
+	var s string
+	if r.Offset != nil {
+		switch {
+		case *r.Offset < 0:
+			s = fmt.Sprintf("%s before start", formatDuration(-*r.Offset))
+		case *r.Offset > 0:
+			s = fmt.Sprintf("%s after start", formatDuration(*r.Offset))
+		default:
+			s = "at start"
+		}
+		if due := r.Due(t); due != nil && r.SnoozedUntil == nil {
+			s += fmt.Sprintf(" (%s)", formatDateTime(*due))
+		}
+	} else {
+		s = formatDateTime(*r.At)
+	}
+	if r.FiredAt != nil {
+		s += ", fired"
+	} else if r.SnoozedUntil != nil {
+		s += fmt.Sprintf(", snoozed until %s", formatDateTime(*r.SnoozedUntil))
+	}
+	return s
+}
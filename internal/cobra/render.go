@@ -0,0 +1,216 @@
+package cobra
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+	"unicode/utf8"
+
+	"github.com/EvoSched/gotask/internal/config"
+	"github.com/EvoSched/gotask/internal/types"
+)
+
+// taskView is a task as seen by --format and --template. Times are nil when unset, and are all
+// wall-clock times like the task's own (see types.WallClock) so they compare with now.
+type taskView struct {
+	ID            int
+	UUID          string
+	Desc          string
+	Status        string // open or done
+	Done          bool
+	Priority      int
+	PriorityLabel string
+	Tags          []string
+	Attrs         map[string]string
+	Notes         []string
+	Checklist     []checkOutput
+	Start         *time.Time
+	End           *time.Time
+	Updated       *time.Time
+	Completed     *time.Time
+}
+
+// documentView is the data of a --template file
+type documentView struct {
+	Tasks []taskView
+	Now   time.Time
+}
+
+func newTaskView(t *types.Task) taskView {
+	o := newTaskOutput(t)
+	return taskView{
+		ID:            o.ID,
+		UUID:          o.UUID,
+		Desc:          o.Desc,
+		Status:        o.Status,
+		Done:          t.Finished,
+		Priority:      o.Priority,
+		PriorityLabel: o.PriorityLabel,
+		Tags:          o.Tags,
+		Attrs:         o.Attrs,
+		Notes:         o.Notes,
+		Checklist:     o.Checklist,
+		Start:         t.StartAt,
+		End:           t.EndAt,
+		Updated:       wallClock(t.UpdatedAt),
+		Completed:     wallClock(t.CompletedAt),
+	}
+}
+
+// wallClock returns the wall-clock time of an instant in the local zone, nil if it's unset
+func wallClock(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	w := types.WallClock(t.Local())
+	return &w
+}
+
+// templateFuncs returns the functions available to --format and --template; color only
+// colors text when useColor is set
+func templateFuncs(useColor bool) template.FuncMap {
+	return template.FuncMap{
+		"trunc": func(n int, s string) string {
+			if utf8.RuneCountInString(s) <= n {
+				return s
+			}
+			if n <= 2 {
+				return string([]rune(s)[:max(n, 0)])
+			}
+			return string([]rune(s)[:n-2]) + ".."
+		},
+		"pad": func(n int, s string) string {
+			if n < 0 {
+				return fmt.Sprintf("%*s", -n, s)
+			}
+			return fmt.Sprintf("%-*s", n, s)
+		},
+		"join":     func(list []string, sep string) string { return strings.Join(list, sep) },
+		"upper":    strings.ToUpper,
+		"lower":    strings.ToLower,
+		"check":    formatCheck,
+		"now":      wallNow,
+		"date":     timeFunc(formatDate),
+		"time":     timeFunc(func(t time.Time) string { return t.Format(clockFmt) }),
+		"datetime": timeFunc(formatDateTime),
+		"iso":      timeFunc(func(t time.Time) string { return t.Format(time.DateOnly) }),
+		"layout": func(layout string, v any) string {
+			return timeFunc(func(t time.Time) string { return formatLocal(t, layout) })(v)
+		},
+		"since": func(v any) time.Duration {
+			if t, ok := timeArg(v); ok {
+				return wallNow().Sub(t).Truncate(time.Minute)
+			}
+			return 0
+		},
+		"until": func(v any) time.Duration {
+			if t, ok := timeArg(v); ok {
+				return t.Sub(wallNow()).Truncate(time.Minute)
+			}
+			return 0
+		},
+		"duration": formatDuration,
+		"color": func(style, s string) (string, error) {
+			if _, err := config.StyleCodes(style); err != nil {
+				return "", err
+			}
+			if !useColor {
+				return s, nil
+			}
+			return paint(style, s), nil
+		},
+	}
+}
+
+// timeArg accepts a time or a task's time, which is nil when unset
+func timeArg(v any) (time.Time, bool) {
+	switch t := v.(type) {
+	case time.Time:
+		return t, true
+	case *time.Time:
+		if t != nil {
+			return *t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// timeFunc makes a template function formatting a time, giving "" for an unset one
+func timeFunc(format func(time.Time) string) func(any) string {
+	return func(v any) string {
+		if t, ok := timeArg(v); ok {
+			return format(t)
+		}
+		return ""
+	}
+}
+
+// formatEscapes turns '\t' and '\n' typed in a --format argument into tabs and newlines
+var formatEscapes = strings.NewReplacer(`\\`, `\`, `\t`, "\t", `\n`, "\n")
+
+// taskFormat returns the template for --format, which is a named format from config or
+// template text applied to each task
+func (c *Cmd) taskFormat(useColor bool) (*template.Template, error) {
+	text := c.format
+	if !strings.Contains(text, "{{") {
+		named, ok := c.cfg.Display.Formats[text]
+		if !ok {
+			names := sortedKeys(c.cfg.Display.Formats)
+			if len(names) == 0 {
+				return nil, fmt.Errorf("unknown format %q, no formats are named in config", text)
+			}
+			return nil, fmt.Errorf("unknown format %q, expected a template or one of %s", text, strings.Join(names, ", "))
+		}
+		text = named
+	} else {
+		text = formatEscapes.Replace(text)
+	}
+	tmpl, err := template.New("format").Option("missingkey=error").Funcs(templateFuncs(useColor)).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid format: %w", err)
+	}
+	return tmpl, nil
+}
+
+// renderTasks prints tasks with --format, once per task, or with the --template file, once
+// for all of them
+func (c *Cmd) renderTasks(w io.Writer, tasks []*types.Task) {
+	f, ok := w.(*os.File)
+	useColor := ok && c.useColor(f) || !ok && c.color == "always"
+	views := make([]taskView, 0, len(tasks))
+	for _, t := range tasks {
+		views = append(views, newTaskView(t))
+	}
+
+	if c.templateFile != "" {
+		b, err := os.ReadFile(c.templateFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		tmpl, err := template.New(filepath.Base(c.templateFile)).Option("missingkey=error").
+			Funcs(templateFuncs(useColor)).Parse(string(b))
+		if err != nil {
+			log.Fatalf("invalid template: %v", err)
+		}
+		if err := tmpl.Execute(w, documentView{views, wallNow()}); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	tmpl, err := c.taskFormat(useColor)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, v := range views {
+		if err := tmpl.Execute(w, v); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Fprintln(w)
+	}
+}
@@ -0,0 +1,137 @@
+package cobra
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/EvoSched/gotask/internal/config"
+	"github.com/EvoSched/gotask/internal/types"
+)
+
+// colorModes are the values accepted by --color
+var colorModes = []string{"auto", "always", "never"}
+
+// useColor reports whether output to f is colored: with --color=auto, only when f is a terminal
+// and NO_COLOR isn't set
+func (c *Cmd) useColor(f *os.File) bool {
+	switch c.color {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+	return os.Getenv("NO_COLOR") == "" && isTerminal(f)
+}
+
+// paint wraps s in the escape codes of a style, leaving it as is when the style is empty
+func paint(style, s string) string {
+	codes, err := config.StyleCodes(style)
+	if err != nil || codes == "" || s == "" {
+		return s
+	}
+	return "\x1b[" + codes + "m" + s + "\x1b[0m"
+}
+
+// taskStyles picks the styles of the theme for tasks in a table
+type taskStyles struct {
+	theme   config.Theme
+	blocked map[int]bool // open tasks following up on an open task
+}
+
+// newTaskStyles returns the styles of the theme from config, or nil when output isn't colored
+func (c *Cmd) newTaskStyles() *taskStyles {
+	if !c.useColor(os.Stdout) {
+		return nil
+	}
+	theme, err := c.cfg.Theme()
+	if err != nil {
+		log.Fatal(err)
+	}
+	blocked, err := c.repo.BlockedIDs()
+	if err != nil {
+		log.Fatal(err)
+	}
+	return &taskStyles{theme, blocked}
+}
+
+// row returns the style of a task's state. A task is active while now is within its span,
+// unless the span is whole days, which are styled as due today.
+func (s *taskStyles) row(t *types.Task) string {
+	if t.Finished {
+		return s.theme.Done
+	}
+	if t.StartAt == nil {
+		if s.blocked[t.ID] {
+			return s.theme.Blocked
+		}
+		return ""
+	}
+	now := wallNow()
+	due := *t.StartAt
+	if t.EndAt != nil {
+		due = *t.EndAt
+	}
+	wholeDays := t.EndAt != nil && t.StartAt.Hour() == 0 && t.StartAt.Minute() == 0 &&
+		t.EndAt.Hour() == 23 && t.EndAt.Minute() == 59
+	switch {
+	case due.Before(now):
+		return s.theme.Overdue
+	case t.EndAt != nil && !wholeDays && !t.StartAt.After(now):
+		return s.theme.Active
+	case s.blocked[t.ID]:
+		return s.theme.Blocked
+	case sameDay(*t.StartAt, now) || !t.StartAt.After(now):
+		return s.theme.Today
+	}
+	return ""
+}
+
+func sameDay(a, b time.Time) bool {
+	return a.Year() == b.Year() && a.YearDay() == b.YearDay()
+}
+
+// priority returns the style of a priority: by its label, or "highest" or "lowest"
+func (s *taskStyles) priority(v int) string {
+	if style, ok := s.theme.Priority[strings.ToLower(priorities.Format(v))]; ok {
+		return style
+	}
+	if style, ok := s.theme.Priority[strconv.Itoa(v)]; ok {
+		return style
+	}
+	switch priorities.Rank(v) {
+	case 0:
+		return s.theme.Priority["highest"]
+	case priorities.Count() - 1:
+		return s.theme.Priority["lowest"]
+	}
+	return ""
+}
+
+// cell styles a line of a table cell. Priorities and tags take their own style, falling back
+// to the row's.
+func (s *taskStyles) cell(t *types.Task, col, line string) string {
+	row := s.row(t)
+	switch col {
+	case "priority":
+		if style := s.priority(t.Priority); style != "" {
+			return paint(style, line)
+		}
+	case "tags":
+		// tags are joined by ", " and the last may be cut short
+		parts := strings.Split(line, ", ")
+		for i, p := range parts {
+			style := row
+			if i < len(t.Tags) {
+				if ts, ok := s.theme.Tags[strings.ToLower(t.Tags[i])]; ok {
+					style = ts
+				}
+			}
+			parts[i] = paint(style, p)
+		}
+		return strings.Join(parts, paint(row, ", "))
+	}
+	return paint(row, line)
+}
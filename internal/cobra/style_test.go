@@ -0,0 +1,97 @@
+package cobra
+
+import (
+	"testing"
+	"time"
+
+	"github.com/EvoSched/gotask/internal/config"
+	"github.com/EvoSched/gotask/internal/types"
+)
+
+func TestPaint(t *testing.T) {
+	tests := []struct {
+		style, want string
+	}{
+		{"bold red", "\x1b[1;31mx\x1b[0m"},
+		{"bright-blue on-gray", "\x1b[94;100mx\x1b[0m"},
+		{"208 underline", "\x1b[38;5;208;4mx\x1b[0m"},
+		{"#ff8800", "\x1b[38;2;255;136;0mx\x1b[0m"},
+		{"", "x"},
+	}
+	for _, tt := range tests {
+		if got := paint(tt.style, "x"); got != tt.want {
+			t.Errorf("paint(%q) = %q, want %q", tt.style, got, tt.want)
+		}
+	}
+	for _, style := range []string{"pink", "256", "#ff88"} {
+		if _, err := config.StyleCodes(style); err == nil {
+			t.Errorf("StyleCodes(%q) gave no error", style)
+		}
+	}
+}
+
+func TestTaskStyles(t *testing.T) {
+	withClock(t, fixedNow)
+	at := func(d time.Duration) *time.Time {
+		v := fixedNow.Add(d)
+		return &v
+	}
+	day := func(d int) (*time.Time, *time.Time) {
+		start := time.Date(2024, time.October, 14+d, 0, 0, 0, 0, time.UTC)
+		end := start.Add(24*time.Hour - time.Minute)
+		return &start, &end
+	}
+	todayStart, todayEnd := day(0)
+	tomorrowStart, tomorrowEnd := day(1)
+	st := &taskStyles{
+		theme: config.Theme{Overdue: "red", Today: "yellow", Active: "green", Blocked: "dim", Done: "gray",
+			Priority: map[string]string{"highest": "bold", "3": "blue"}, Tags: map[string]string{"work": "cyan"}},
+		blocked: map[int]bool{9: true},
+	}
+	tests := []struct {
+		name string
+		task types.Task
+		want string
+	}{
+		{"overdue", types.Task{StartAt: at(-2 * time.Hour)}, "red"},
+		{"active", types.Task{StartAt: at(-time.Hour), EndAt: at(time.Hour)}, "green"},
+		{"today", types.Task{StartAt: at(3 * time.Hour)}, "yellow"},
+		{"whole day today", types.Task{StartAt: todayStart, EndAt: todayEnd}, "yellow"},
+		{"tomorrow", types.Task{StartAt: tomorrowStart, EndAt: tomorrowEnd}, ""},
+		{"blocked", types.Task{ID: 9}, "dim"},
+		{"done", types.Task{ID: 9, Finished: true, StartAt: at(-48 * time.Hour)}, "gray"},
+	}
+	for _, tt := range tests {
+		if got := st.row(&tt.task); got != tt.want {
+			t.Errorf("row style of %s task = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	// task times are wall-clock times, so 9:30 has passed at 10:30 in any zone
+	withClock(t, time.Date(2024, time.October, 14, 10, 30, 0, 0, time.FixedZone("UTC+2", 2*60*60)))
+	for _, tt := range []struct {
+		name string
+		task types.Task
+		want string
+	}{
+		{"overdue", types.Task{StartAt: at(-time.Hour)}, "red"},
+		{"active", types.Task{StartAt: at(-time.Minute), EndAt: at(time.Hour)}, "green"},
+		{"later today", types.Task{StartAt: at(time.Hour)}, "yellow"},
+	} {
+		if got := st.row(&tt.task); got != tt.want {
+			t.Errorf("row style of %s task in UTC+2 = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	task := &types.Task{Priority: 1, Tags: []string{"WORK", "HOME"}}
+	if got, want := st.cell(task, "priority", "1"), paint("bold", "1"); got != want {
+		t.Errorf("highest priority cell = %q, want %q", got, want)
+	}
+	task.Priority = 3
+	if got, want := st.cell(task, "priority", "3"), paint("blue", "3"); got != want {
+		t.Errorf("priority 3 cell = %q, want %q", got, want)
+	}
+	if got, want := st.cell(task, "tags", "WORK, HOME"), paint("cyan", "WORK")+", HOME"; got != want {
+		t.Errorf("tags cell = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,608 @@
+package cobra
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/EvoSched/gotask/internal/config"
+	"github.com/EvoSched/gotask/internal/types"
+	"github.com/spf13/cobra"
+	"golang.org/x/text/width"
+)
+
+// tableOptions are the flags of the commands listing tasks in a table
+type tableOptions struct {
+	columns  string
+	sort     string
+	groupBy  string
+	relative bool
+}
+
+// addFlags declares the table flags on cmd, with the columns it shows by default
+func (o *tableOptions) addFlags(cmd *cobra.Command, columns string) {
+	cmd.Flags().StringVar(&o.columns, "columns", columns, "columns to show: id, uuid, status, desc, priority, tags, due, completed, updated or an attribute")
+	cmd.Flags().StringVar(&o.sort, "sort", "", "columns to sort by, '-' first for descending, e.g. due,-priority")
+	cmd.Flags().StringVar(&o.groupBy, "group-by", "", "group by tag, day, status, priority or an attribute")
+	cmd.Flags().BoolVar(&o.relative, "relative", false, "show times relative to now, e.g. 'in 2d'")
+}
+
+// column is a column of the task table
+type column struct {
+	name   string // as given to --columns
+	header string
+	cell   func(t *types.Task) string
+	min    int  // narrowest the column shrinks to when the table is too wide, 0 if it doesn't
+	wrap   bool // wrap the cell onto more lines rather than cutting it
+}
+
+// tableColumns returns the columns named in a --columns list
+func tableColumns(list string, relative bool) ([]column, error) {
+	when := func(t *time.Time) string {
+		switch {
+		case t == nil:
+			return "-"
+		case relative:
+			// completion and update times are instants rather than wall-clock times
+			return formatRelative(*wallClock(t))
+		}
+		return formatDateTime(*t)
+	}
+	var cols []column
+	for _, name := range strings.Split(list, ",") {
+		var col column
+		switch name = strings.TrimSpace(name); name {
+		case "id":
+			col = column{header: "ID", cell: func(t *types.Task) string { return strconv.Itoa(t.ShortID) }}
+		case "uuid":
+			col = column{header: "UUID", cell: func(t *types.Task) string { return t.UUID }, min: 8}
+		case "status":
+			col = column{header: "Status", cell: func(t *types.Task) string {
+				s := formatCheck(t.Finished)
+				if done, total := t.Progress(); total > 0 {
+					s += fmt.Sprintf(" %d/%d", done, total)
+				}
+				return s
+			}}
+		case "desc":
+			col = column{header: "Desc", cell: func(t *types.Task) string { return t.Desc }, min: 12, wrap: true}
+		case "priority":
+			col = column{header: "Priority", cell: func(t *types.Task) string { return priorities.Format(t.Priority) }}
+		case "tags":
+			col = column{header: "Tags", cell: func(t *types.Task) string { return strings.Join(t.Tags, ", ") }, min: 6}
+		case "due":
+			col = column{header: "Due", cell: func(t *types.Task) string {
+				switch {
+				case t.StartAt == nil:
+					return "-"
+				case relative:
+					return formatRelative(*t.StartAt)
+				}
+				return formatSpan(t.StartAt, t.EndAt, clockFmt)
+			}, min: 10, wrap: true}
+		case "completed":
+			col = column{header: "Completed", cell: func(t *types.Task) string { return when(t.CompletedAt) }}
+		case "updated":
+			col = column{header: "Updated", cell: func(t *types.Task) string { return when(t.UpdatedAt) }}
+		default:
+			if _, ok := udaDefs[name]; !ok {
+				return nil, fmt.Errorf("unknown column %q, expected id, uuid, status, desc, priority, tags, due, completed, updated or an attribute declared in config", name)
+			}
+			n := name
+			col = column{header: n, cell: func(t *types.Task) string { return t.Attrs[n] }, min: 6}
+		}
+		col.name = name
+		cols = append(cols, col)
+	}
+	return cols, nil
+}
+
+// sortKey orders tasks by one column; tasks missing the value come last either way
+type sortKey struct {
+	cmp     func(a, b *types.Task) int
+	missing func(t *types.Task) bool
+	desc    bool
+}
+
+// sortTasks sorts tasks by a --sort list such as "due,-priority", keeping the order of ties
+func sortTasks(tasks []*types.Task, list string) error {
+	if list == "" {
+		return nil
+	}
+	var keys []sortKey
+	for _, name := range strings.Split(list, ",") {
+		name, desc := strings.CutPrefix(strings.TrimSpace(name), "-")
+		k, err := taskOrder(name)
+		if err != nil {
+			return err
+		}
+		k.desc = desc
+		keys = append(keys, k)
+	}
+	slices.SortStableFunc(tasks, func(a, b *types.Task) int {
+		for _, k := range keys {
+			ma, mb := k.missing != nil && k.missing(a), k.missing != nil && k.missing(b)
+			switch {
+			case ma && mb:
+				continue
+			case ma != mb:
+				if ma {
+					return 1
+				}
+				return -1
+			}
+			r := k.cmp(a, b)
+			if k.desc {
+				r = -r
+			}
+			if r != 0 {
+				return r
+			}
+		}
+		return 0
+	})
+	return nil
+}
+
+// taskOrder returns the order of tasks by a column name, or by an attribute according to its type
+func taskOrder(name string) (sortKey, error) {
+	byTime := func(get func(t *types.Task) *time.Time) sortKey {
+		return sortKey{
+			cmp:     func(a, b *types.Task) int { return get(a).Compare(*get(b)) },
+			missing: func(t *types.Task) bool { return get(t) == nil },
+		}
+	}
+	switch name {
+	case "id":
+		return sortKey{cmp: func(a, b *types.Task) int { return a.ShortID - b.ShortID }}, nil
+	case "uuid":
+		return sortKey{cmp: func(a, b *types.Task) int { return strings.Compare(a.UUID, b.UUID) }}, nil
+	case "desc":
+		return sortKey{cmp: func(a, b *types.Task) int { return strings.Compare(strings.ToLower(a.Desc), strings.ToLower(b.Desc)) }}, nil
+	case "priority":
+		// most important first
+		return sortKey{cmp: func(a, b *types.Task) int { return priorities.Rank(a.Priority) - priorities.Rank(b.Priority) }}, nil
+	case "status":
+		return sortKey{cmp: func(a, b *types.Task) int { return boolOrder(a.Finished) - boolOrder(b.Finished) }}, nil
+	case "tags":
+		return sortKey{
+			cmp: func(a, b *types.Task) int {
+				return strings.Compare(strings.ToLower(strings.Join(a.Tags, ",")), strings.ToLower(strings.Join(b.Tags, ",")))
+			},
+			missing: func(t *types.Task) bool { return len(t.Tags) == 0 },
+		}, nil
+	case "due":
+		return byTime(func(t *types.Task) *time.Time { return t.StartAt }), nil
+	case "completed":
+		return byTime(func(t *types.Task) *time.Time { return t.CompletedAt }), nil
+	case "updated":
+		return byTime(func(t *types.Task) *time.Time { return t.UpdatedAt }), nil
+	}
+	u, ok := udaDefs[name]
+	if !ok {
+		return sortKey{}, fmt.Errorf("unknown sort column %q, expected id, uuid, desc, priority, status, tags, due, completed, updated or an attribute declared in config", name)
+	}
+	return sortKey{
+		cmp:     func(a, b *types.Task) int { return compareAttr(u, a.Attrs[name], b.Attrs[name]) },
+		missing: func(t *types.Task) bool { return t.Attrs[name] == "" },
+	}, nil
+}
+
+func boolOrder(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// compareAttr compares two values of an attribute: numbers and durations by size, enum values
+// in the order declared and others as text
+func compareAttr(u config.UDA, a, b string) int {
+	switch u.Type {
+	case config.UDANumber:
+		x, errX := strconv.ParseFloat(a, 64)
+		y, errY := strconv.ParseFloat(b, 64)
+		if errX == nil && errY == nil {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		}
+	case config.UDADuration:
+		x, errX := time.ParseDuration(a)
+		y, errY := time.ParseDuration(b)
+		if errX == nil && errY == nil {
+			return int((x - y) / max(abs(x-y), 1))
+		}
+	case config.UDAEnum:
+		if x, y := slices.Index(u.Values, a), slices.Index(u.Values, b); x >= 0 && y >= 0 {
+			return x - y
+		}
+	}
+	return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+}
+
+func abs(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+// taskGroup is a titled part of the table
+type taskGroup struct {
+	title string
+	tasks []*types.Task
+}
+
+// groupTasks splits sorted tasks into groups by tag, day, status, priority or an attribute.
+// A task with several tags is listed under each; tasks without a value come last.
+func groupTasks(tasks []*types.Task, by string) ([]taskGroup, error) {
+	if by == "" {
+		return []taskGroup{{tasks: tasks}}, nil
+	}
+	var keyOf func(t *types.Task) []string
+	var order func(a, b string) int
+	none := "(none)"
+	switch by {
+	case "tag":
+		none = "(no tag)"
+		keyOf = func(t *types.Task) []string { return t.Tags }
+		order = func(a, b string) int { return strings.Compare(strings.ToLower(a), strings.ToLower(b)) }
+	case "day":
+		none = "(no date)"
+		days := make(map[string]time.Time)
+		keyOf = func(t *types.Task) []string {
+			if t.StartAt == nil {
+				return nil
+			}
+			d := formatDate(*t.StartAt)
+			days[d] = *t.StartAt
+			return []string{d}
+		}
+		order = func(a, b string) int { return days[a].Compare(days[b]) }
+	case "status":
+		keyOf = func(t *types.Task) []string {
+			if t.Finished {
+				return []string{"done"}
+			}
+			return []string{"open"}
+		}
+		order = func(a, b string) int { return strings.Compare(b, a) }
+	case "priority":
+		ranks := make(map[string]int)
+		keyOf = func(t *types.Task) []string {
+			p := priorities.Format(t.Priority)
+			ranks[p] = priorities.Rank(t.Priority)
+			return []string{p}
+		}
+		order = func(a, b string) int { return ranks[a] - ranks[b] }
+	default:
+		u, ok := udaDefs[by]
+		if !ok {
+			return nil, fmt.Errorf("unknown group %q, expected tag, day, status, priority or an attribute declared in config", by)
+		}
+		keyOf = func(t *types.Task) []string {
+			if v := t.Attrs[by]; v != "" {
+				return []string{v}
+			}
+			return nil
+		}
+		order = func(a, b string) int { return compareAttr(u, a, b) }
+	}
+
+	byKey := make(map[string][]*types.Task)
+	var keys []string
+	var rest []*types.Task
+	for _, t := range tasks {
+		ks := keyOf(t)
+		if len(ks) == 0 {
+			rest = append(rest, t)
+		}
+		for _, k := range ks {
+			if _, ok := byKey[k]; !ok {
+				keys = append(keys, k)
+			}
+			byKey[k] = append(byKey[k], t)
+		}
+	}
+	slices.SortStableFunc(keys, order)
+	groups := make([]taskGroup, 0, len(keys)+1)
+	for _, k := range keys {
+		groups = append(groups, taskGroup{k, byKey[k]})
+	}
+	if len(rest) > 0 {
+		groups = append(groups, taskGroup{none, rest})
+	}
+	return groups, nil
+}
+
+// formatRelative formats a wall-clock time relative to now in its largest unit, e.g. "in 2d" or
+// "3h ago"
+func formatRelative(t time.Time) string {
+	d := t.Sub(wallNow())
+	past := d < 0
+	if past {
+		d = -d
+	}
+	var s string
+	switch {
+	case d < time.Minute:
+		return "now"
+	case d < time.Hour:
+		s = fmt.Sprintf("%dm", d/time.Minute)
+	case d < 24*time.Hour:
+		s = fmt.Sprintf("%dh", d/time.Hour)
+	case d < 14*24*time.Hour:
+		s = fmt.Sprintf("%dd", d/(24*time.Hour))
+	default:
+		s = fmt.Sprintf("%dw", d/(7*24*time.Hour))
+	}
+	if past {
+		return s + " ago"
+	}
+	return "in " + s
+}
+
+// renderTable lays out groups of tasks in columns fitting within maxWidth, or at their natural
+// width when maxWidth is 0. Columns that can shrink are narrowed, wrapping or cutting their cells.
+// Cells are styled by st, unless it's nil.
+func renderTable(cols []column, groups []taskGroup, maxWidth int, st *taskStyles) string {
+	const gap = "  "
+	cells := make(map[*types.Task][]string)
+	widths := make([]int, len(cols))
+	for i, col := range cols {
+		widths[i] = displayWidth(col.header)
+	}
+	for _, g := range groups {
+		for _, t := range g.tasks {
+			if _, ok := cells[t]; ok {
+				continue
+			}
+			row := make([]string, len(cols))
+			for i, col := range cols {
+				row[i] = strings.Map(func(r rune) rune {
+					if unicode.IsControl(r) {
+						return ' '
+					}
+					return r
+				}, col.cell(t))
+				widths[i] = max(widths[i], displayWidth(row[i]))
+			}
+			cells[t] = row
+		}
+	}
+
+	total := fitWidths(cols, widths, len(gap), maxWidth)
+
+	var b strings.Builder
+	writeLine := func(parts []string, style func(i int, p string) string) {
+		var line strings.Builder
+		for i, p := range parts {
+			if i > 0 {
+				line.WriteString(gap)
+			}
+			line.WriteString(style(i, p))
+			line.WriteString(strings.Repeat(" ", widths[i]-displayWidth(p)))
+		}
+		b.WriteString(strings.TrimRight(line.String(), " "))
+		b.WriteByte('\n')
+	}
+	headers := make([]string, len(cols))
+	for i, col := range cols {
+		headers[i] = truncateWidth(col.header, widths[i])
+	}
+	header := func(_ int, p string) string { return p }
+	if st != nil {
+		header = func(_ int, p string) string { return paint(st.theme.Header, p) }
+	}
+	writeLine(headers, header)
+	b.WriteString(strings.Repeat("-", total))
+	b.WriteByte('\n')
+
+	for gi, g := range groups {
+		if g.title != "" {
+			if gi > 0 {
+				b.WriteByte('\n')
+			}
+			b.WriteString(header(0, fmt.Sprintf("%s (%d)", g.title, len(g.tasks))))
+			b.WriteByte('\n')
+		}
+		for _, t := range g.tasks {
+			style := func(_ int, p string) string { return p }
+			if st != nil {
+				style = func(i int, p string) string { return st.cell(t, cols[i].name, p) }
+			}
+			lines := make([][]string, len(cols))
+			height := 1
+			for i, col := range cols {
+				if col.wrap {
+					lines[i] = wrapWidth(cells[t][i], widths[i])
+				} else {
+					lines[i] = []string{truncateWidth(cells[t][i], widths[i])}
+				}
+				height = max(height, len(lines[i]))
+			}
+			for n := 0; n < height; n++ {
+				parts := make([]string, len(cols))
+				for i := range cols {
+					if n < len(lines[i]) {
+						parts[i] = lines[i][n]
+					}
+				}
+				writeLine(parts, style)
+			}
+		}
+	}
+	return b.String()
+}
+
+// fitWidths narrows the widths of columns separated by gap cells to fit within maxWidth,
+// returning the width of the table. Wrapping columns shrink first, then those that are cut,
+// each no further than its minimum.
+func fitWidths(cols []column, widths []int, gap, maxWidth int) int {
+	total := gap * (len(cols) - 1)
+	for _, w := range widths {
+		total += w
+	}
+	for _, wrap := range []bool{true, false} {
+		for i, col := range cols {
+			if maxWidth <= 0 || total <= maxWidth {
+				break
+			}
+			if col.min == 0 || col.wrap != wrap || widths[i] <= col.min {
+				continue
+			}
+			cut := min(total-maxWidth, widths[i]-col.min)
+			widths[i] -= cut
+			total -= cut
+		}
+	}
+	return total
+}
+
+// runeWidth returns the number of terminal cells a rune takes: 2 for wide East Asian characters
+// and most emoji, 0 for combining marks and other zero-width characters
+func runeWidth(r rune) int {
+	if unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf) || unicode.IsControl(r) {
+		return 0
+	}
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2
+	}
+	return 1
+}
+
+// displayWidth returns the number of terminal cells s takes
+func displayWidth(s string) int {
+	n := 0
+	for _, r := range s {
+		n += runeWidth(r)
+	}
+	return n
+}
+
+// truncateWidth cuts s to at most w cells, marking the cut with ".."
+func truncateWidth(s string, w int) string {
+	if displayWidth(s) <= w {
+		return s
+	}
+	mark := ".."
+	if w < 4 {
+		mark = ""
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range s {
+		rw := runeWidth(r)
+		if n+rw > w-len(mark) {
+			break
+		}
+		b.WriteRune(r)
+		n += rw
+	}
+	return b.String() + mark
+}
+
+// wrapWidth breaks s into lines of at most w cells at spaces, splitting words that don't fit
+func wrapWidth(s string, w int) []string {
+	var lines []string
+	var line strings.Builder
+	n := 0
+	flush := func() {
+		lines = append(lines, line.String())
+		line.Reset()
+		n = 0
+	}
+	for _, word := range strings.Fields(s) {
+		ww := displayWidth(word)
+		if n > 0 && n+1+ww > w {
+			flush()
+		}
+		if n > 0 {
+			line.WriteByte(' ')
+			n++
+		}
+		for _, r := range word {
+			rw := runeWidth(r)
+			if n > 0 && n+rw > w {
+				flush()
+			}
+			line.WriteRune(r)
+			n += rw
+		}
+	}
+	if n > 0 || len(lines) == 0 {
+		flush()
+	}
+	return lines
+}
+
+// displayTable prints tasks in a table fitted to the terminal, through a pager when it's
+// longer than the terminal
+func (c *Cmd) displayTable(tasks []*types.Task, o tableOptions) {
+	cols, err := tableColumns(o.columns, o.relative || c.cfg.Display.RelativeDates)
+	if err != nil {
+		fatal(err)
+	}
+	if err := sortTasks(tasks, o.sort); err != nil {
+		fatal(err)
+	}
+	groups, err := groupTasks(tasks, o.groupBy)
+	if err != nil {
+		fatal(err)
+	}
+	width, rows, _ := screenSize()
+	c.page(renderTable(cols, groups, width, c.newTaskStyles()), rows)
+}
+
+// screenSize returns the size of the terminal stdout is shown on, 0 when unknown. COLUMNS and
+// LINES take precedence, so a width can be given when output is piped.
+func screenSize() (cols, rows int, ok bool) {
+	if isTerminal(os.Stdout) {
+		cols, rows, ok = terminalSize(os.Stdout)
+	}
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 0 {
+		cols, ok = n, true
+	}
+	if n, err := strconv.Atoi(os.Getenv("LINES")); err == nil && n > 0 {
+		rows = n
+	}
+	return cols, rows, ok
+}
+
+// page prints out, through the pager from config or $PAGER when stdout is a terminal with fewer
+// than out's lines. A pager that can't be run is skipped.
+func (c *Cmd) page(out string, rows int) {
+	pager := c.cfg.Display.Pager
+	if pager == "" {
+		pager = os.Getenv("PAGER")
+	}
+	if pager == "" {
+		pager = "less -FRX"
+	}
+	args := strings.Fields(pager)
+	if pager == "off" || len(args) == 0 || rows == 0 || strings.Count(out, "\n") < rows || !isTerminal(os.Stdout) {
+		c.print(out)
+		return
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = strings.NewReader(out)
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			c.print(out)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package cobra
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/EvoSched/gotask/internal/types"
+)
+
+func TestDisplayWidth(t *testing.T) {
+	tests := []struct {
+		s    string
+		want int
+	}{
+		{"milk", 4},
+		{"日本語", 6},
+		{"café", 4},
+		{"café", 4},
+		{"🎉 party", 8},
+	}
+	for _, tt := range tests {
+		if got := displayWidth(tt.s); got != tt.want {
+			t.Errorf("displayWidth(%q) = %d, want %d", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestTruncateWidth(t *testing.T) {
+	tests := []struct {
+		s    string
+		w    int
+		want string
+	}{
+		{"Buy milk", 10, "Buy milk"},
+		{"Buy milk today", 10, "Buy milk.."},
+		{"日本語のタスク", 9, "日本語.."},
+		{"Ünïcödé wörds", 6, "Ünïc.."},
+	}
+	for _, tt := range tests {
+		got := truncateWidth(tt.s, tt.w)
+		if got != tt.want || !utf8.ValidString(got) || displayWidth(got) > tt.w {
+			t.Errorf("truncateWidth(%q, %d) = %q, want %q", tt.s, tt.w, got, tt.want)
+		}
+	}
+}
+
+func TestWrapWidth(t *testing.T) {
+	tests := []struct {
+		s    string
+		w    int
+		want []string
+	}{
+		{"Call about the invoice", 10, []string{"Call about", "the", "invoice"}},
+		{"supercalifragilistic", 8, []string{"supercal", "ifragili", "stic"}},
+		{"日本語 タスク", 4, []string{"日本", "語", "タス", "ク"}},
+		{"", 5, []string{""}},
+	}
+	for _, tt := range tests {
+		if got := wrapWidth(tt.s, tt.w); strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("wrapWidth(%q, %d) = %q, want %q", tt.s, tt.w, got, tt.want)
+		}
+	}
+}
+
+func tableTasks() []*types.Task {
+	at := func(d int) *time.Time {
+		v := time.Date(2024, time.October, d, 9, 0, 0, 0, time.UTC)
+		return &v
+	}
+	return []*types.Task{
+		{ShortID: 1, Desc: "Buy milk", Priority: 3, Tags: []string{"HOME"}, StartAt: at(16), Attrs: map[string]string{"size": "L"}},
+		{ShortID: 2, Desc: "Write report", Priority: 1, Tags: []string{"WORK"}, Attrs: map[string]string{"size": "S"}},
+		{ShortID: 3, Desc: "Call about invoice", Priority: 2, Tags: []string{"WORK", "HOME"}, StartAt: at(15)},
+		{ShortID: 4, Desc: "日本語のタスク", Priority: 1, StartAt: at(16), Attrs: map[string]string{"size": "M"}},
+	}
+}
+
+func shortIDs(tasks []*types.Task) []int {
+	ids := make([]int, 0, len(tasks))
+	for _, t := range tasks {
+		ids = append(ids, t.ShortID)
+	}
+	return ids
+}
+
+func TestSortTasks(t *testing.T) {
+	withUDAs(t)
+	tests := []struct {
+		sort string
+		want []int
+	}{
+		{"due,-priority", []int{3, 1, 4, 2}},
+		{"-due", []int{1, 4, 3, 2}},
+		{"priority,id", []int{2, 4, 3, 1}},
+		{"size", []int{2, 4, 1, 3}},
+		{"-size", []int{1, 4, 2, 3}},
+	}
+	for _, tt := range tests {
+		tasks := tableTasks()
+		if err := sortTasks(tasks, tt.sort); err != nil {
+			t.Fatal(err)
+		}
+		if got := shortIDs(tasks); !slices.Equal(got, tt.want) {
+			t.Errorf("sortTasks %q = %v, want %v", tt.sort, got, tt.want)
+		}
+	}
+	if err := sortTasks(tableTasks(), "due,nope"); err == nil {
+		t.Error("sortTasks with an unknown column gave no error")
+	}
+}
+
+func TestGroupTasks(t *testing.T) {
+	withUDAs(t)
+	tests := []struct {
+		by   string
+		want string
+	}{
+		{"tag", "HOME:1,3 WORK:2,3 (no tag):4"},
+		{"day", "Tue, 15 Oct 2024:3 Wed, 16 Oct 2024:1,4 (no date):2"},
+		{"priority", "1:2,4 2:3 3:1"},
+		{"size", "S:2 M:4 L:1 (none):3"},
+	}
+	for _, tt := range tests {
+		groups, err := groupTasks(tableTasks(), tt.by)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var parts []string
+		for _, g := range groups {
+			ids := strings.Trim(strings.Join(strings.Fields(fmt.Sprint(shortIDs(g.tasks))), ","), "[]")
+			parts = append(parts, g.title+":"+ids)
+		}
+		if got := strings.Join(parts, " "); got != tt.want {
+			t.Errorf("groupTasks %q = %q, want %q", tt.by, got, tt.want)
+		}
+	}
+	if _, err := groupTasks(tableTasks(), "project"); err == nil {
+		t.Error("groupTasks by an undeclared attribute gave no error")
+	}
+}
+
+func TestFormatRelative(t *testing.T) {
+	withClock(t, fixedNow)
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{30 * time.Second, "now"},
+		{45 * time.Minute, "in 45m"},
+		{-5 * time.Hour, "5h ago"},
+		{50 * time.Hour, "in 2d"},
+		{-20 * 24 * time.Hour, "2w ago"},
+	}
+	for _, tt := range tests {
+		if got := formatRelative(fixedNow.Add(tt.d)); got != tt.want {
+			t.Errorf("formatRelative(now%+v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+
+	// task times are wall-clock times, so the clock's zone doesn't shift them
+	withClock(t, time.Date(2024, time.October, 14, 10, 30, 0, 0, time.FixedZone("UTC-5", -5*60*60)))
+	if got := formatRelative(fixedNow.Add(45 * time.Minute)); got != "in 45m" {
+		t.Errorf("formatRelative(now+45m) in UTC-5 = %q, want %q", got, "in 45m")
+	}
+}
+
+func TestRenderTable(t *testing.T) {
+	cols, err := tableColumns("id,desc,tags", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tasks := tableTasks()
+	want := strings.Join([]string{
+		"ID  Desc                Tags",
+		"----------------------------------",
+		"1   Buy milk            HOME",
+		"2   Write report        WORK",
+		"3   Call about invoice  WORK, HOME",
+		"4   日本語のタスク",
+		"",
+	}, "\n")
+	if got := renderTable(cols, []taskGroup{{tasks: tasks}}, 0, nil); got != want {
+		t.Errorf("renderTable =\n%s\nwant\n%s", got, want)
+	}
+
+	// fitted to 24 cells, the description wraps and the tags are cut
+	want = strings.Join([]string{
+		"ID  Desc          Tags",
+		"------------------------",
+		"1   Buy milk      HOME",
+		"2   Write report  WORK",
+		"3   Call about    WORK..",
+		"    invoice",
+		"4   日本語のタス",
+		"    ク",
+		"",
+	}, "\n")
+	got := renderTable(cols, []taskGroup{{tasks: tasks}}, 24, nil)
+	if got != want {
+		t.Errorf("renderTable at 24 cells =\n%s\nwant\n%s", got, want)
+	}
+	for _, line := range strings.Split(got, "\n") {
+		if displayWidth(line) > 24 {
+			t.Errorf("line %q is wider than 24 cells", line)
+		}
+	}
+}
@@ -1,35 +1,48 @@
 package cobra
 
 import (
+	"bufio"
 	"fmt"
+	"github.com/EvoSched/gotask/internal/filter"
+	"github.com/EvoSched/gotask/internal/service"
 	"github.com/EvoSched/gotask/internal/types"
+	"io"
 	"log"
+	"maps"
+	"os"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 )
 
-const (
-	DateFmtDMY = "02-01-2006"
-)
-
-var dateFormats = []string{DateFmtDMY, time.DateOnly}
-
 func (c *Cmd) RootCmd() *cobra.Command {
 	rootCmd := &cobra.Command{
 		Use:   "gt",
 		Short: "GoTask",
 		Long: `GoTask is a comprehensive cli application for managing your tasks both intuitively and efficiently. 
 It allows you to add, list, mod, get, complete, import, export, and prioritize your tasks with ease.`,
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			c.setupOutput(cmd)
+		},
+		PersistentPostRun: func(cmd *cobra.Command, args []string) {
+			c.reportResult(cmd)
+		},
 	}
+	rootCmd.PersistentFlags().StringVarP(&c.output, "output", "o", "", "print tasks, or the IDs of tasks changed, as json, yaml, csv, tsv or ndjson")
+	rootCmd.PersistentFlags().StringVar(&c.format, "format", "", "print each task with a Go template, or a format named in config")
+	rootCmd.PersistentFlags().StringVar(&c.templateFile, "template", "", "print the tasks with a Go template file")
+	rootCmd.PersistentFlags().StringVar(&c.color, "color", "auto", "color output: auto (on a terminal unless NO_COLOR is set), always or never")
 	return rootCmd
 }
 
 func (c *Cmd) AddCmd() *cobra.Command {
 	addCmd := &cobra.Command{
-		Use:   "add",
-		Short: "Add a new task",
+		Use:         "add",
+		Annotations: changesTasks,
+		Short:       "Add a new task",
 		Long: `Adds a new task with the provided description. Additional options include specifying time expression, tags, and priority.
 
 Required:
@@ -38,146 +51,565 @@ Required:
 Optional:
 - time      '@' marks the beginning of the time expression (halts when encountering non-time token).
 - tag       Tag for categorizing the task, prefixed with '+'.
-- priority  Priority level for the task from 1 to 10 (min-max), prefixed with '%'.`,
+- priority  Priority level for the task as defined in config (default 1 to 5, where 1 is highest), prefixed with '%'.
+- attribute User-defined attribute declared in config, given as 'name:value'.
+- reminder  'remind:' followed by an offset from the task's time (-30m) or a date or time (tmrw, 9am). Repeatable.
+
+Words that aren't one of the above make up the description, so it needs no quotes unless a word
+would be read as one of them; quote or escape such words, e.g. '\+1 reviewer'. With '-', one task
+is added per line read from stdin, all or none of them.
+
+Writing '#id' in the description links the task to the referenced task.`,
 		Example: `gt add 'Write up ReadMe'
-gt add 'Finish documentation' +work %8 @ 11-01-2024 10am-4:15
-gt add "Setup database" @ 11-3 +project`,
+gt add 'Finish documentation' +work %2 @ 11-01-2024 10am-4:15
+gt add "Setup database" @ 11-3 +project
+gt add "Fix invoice export" customer:acme estimate:2h
+gt add "Standup" @ tmrw 9:30am remind:-10m
+gt add Buy milk +home @ tmrw 5pm %3
+gt add '\+1' the design review
+gt add - < tasks.txt`,
 		Args: cobra.MinimumNArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) == 1 && args[0] == "-" {
+				c.addLines(os.Stdin)
+				return
+			}
 			ti, err := parseTask(args, true)
 			if err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
-			if ti.priority == nil {
-				p := 5
-				ti.priority = &p
+			t, err := newTask(ti)
+			if err != nil {
+				log.Fatal(err)
 			}
-			t := types.NewTask(*ti.desc, *ti.priority, ti.addTags, nil, ti.startAt, ti.endAt)
-			i, err := c.repo.AddTask(t)
+			_, err = c.repo.AddTask(t)
 			if err != nil {
 				log.Fatal(err)
 			}
-			fmt.Printf("Added task %d.\n", i)
+			c.printf("Added task %d.\n", t.ShortID)
+			c.autoLink(t, t.Desc)
+			c.changedTasks(t)
 		},
 	}
 
 	return addCmd
 }
 
+// newTask creates a task from parsed input, filling in the default priority and attributes
+func newTask(ti *taskInfo) (*types.Task, error) {
+	if ti.priority == nil {
+		p := priorities.DefaultValue()
+		ti.priority = &p
+	}
+	t := types.NewTask(*ti.desc, *ti.priority, ti.addTags, nil, ti.startAt, ti.endAt)
+	attrs, err := attrDefaults(ti.attrs)
+	if err != nil {
+		return nil, err
+	}
+	t.Attrs = attrs
+	t.Reminders = ti.reminders
+	if err := checkReminders(t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// addLines adds a task for each line read from r, skipping blank lines and '#' comments.
+// Every line is parsed before any task is added, and the tasks are added together.
+func (c *Cmd) addLines(r io.Reader) {
+	var tasks []*types.Task
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		ti, err := parseLine(line)
+		if err != nil {
+			fatal(atLine(n, err))
+		}
+		t, err := newTask(ti)
+		if err != nil {
+			log.Fatalf("line %d: %v", n, err)
+		}
+		tasks = append(tasks, t)
+	}
+	if err := sc.Err(); err != nil {
+		log.Fatal(err)
+	}
+	err := c.repo.Transaction(func(tx *service.TaskRepo) error {
+		for _, t := range tasks {
+			if _, err := tx.AddTask(t); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, t := range tasks {
+		c.printf("Added task %d.\n", t.ShortID)
+		c.autoLink(t, t.Desc)
+	}
+	c.printf("Added %s.\n", plural(len(tasks)))
+	c.changedTasks(tasks...)
+}
+
 func (c *Cmd) GetCmd() *cobra.Command {
+	var asCommand bool
 	getCmd := &cobra.Command{
-		Use:   "get",
-		Short: "Get tasks by ID",
+		Use:         "get",
+		Annotations: readsTasks,
+		Aliases:     []string{"show"},
+		Short:       "Get tasks by ID",
 		Long: `Retrieves a task/s from the provided ids. Supports multiple retrievals in a single command.
+With --as-command each task is printed as the 'gt add' command that recreates it, for sharing or scripts.
 
 Required:
-- id  Id referencing task.`,
+- id  Id referencing task. Lists and ranges (3-7,10), 'last' and '~words' of the description are accepted.`,
 		Example: `gt get 1
-gt get 1 3`,
+gt get 1 3
+gt get 3-5,9
+gt get last
+gt get ~readme
+gt show 1 --as-command`,
 		Args: cobra.MinimumNArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			ids, err := parseGet(args)
+			refs, err := parseGet(args)
+			if err != nil {
+				fatal(err)
+			}
+			ids, err := c.resolveIDs(refs)
 			if err != nil {
 				log.Fatal(err)
 			}
+			if c.stdout != nil {
+				if asCommand {
+					log.Fatal("--as-command can't be used with --output, --format or --template")
+				}
+				var tasks []*types.Task
+				for _, i := range ids {
+					t, err := c.repo.GetTask(i)
+					if err != nil {
+						log.Fatal(err)
+					}
+					tasks = append(tasks, t)
+				}
+				c.printTasks(tasks)
+				return
+			}
 			for _, i := range ids {
 				t, err := c.repo.GetTask(i)
 				if err != nil {
 					log.Fatal(err)
 				}
-				displayTask(t)
-				fmt.Println()
+				if asCommand {
+					c.println(formatCommand(t))
+					continue
+				}
+				c.displayTask(t)
+				c.displayLinks(t)
+				c.println()
 			}
 		},
 	}
+	getCmd.Flags().BoolVar(&asCommand, "as-command", false, "print the 'gt add' command that recreates the task")
 	return getCmd
 }
 
+func (c *Cmd) CopyCmd() *cobra.Command {
+	copyCmd := &cobra.Command{
+		Use:         "copy <id> [changes]",
+		Annotations: changesTasks,
+		Short:       "Copy a task with changes",
+		Long: `Adds a new task with the description, tags, time, priority, attributes and reminders of an existing task, changed
+by the arguments provided as with 'mod'. Notes, checklist items, attachments and links aren't copied, and the copy is open.
+
+Required:
+- id  Id referencing the task to copy.`,
+		Example: `gt copy 1
+gt copy 1 @ next fri
+gt copy 2 'Review chapter 4' !draft +review %2`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			ti, err := parseTask(args, false)
+			if err != nil {
+				fatal(err)
+			}
+			id, err := c.resolveID(*ti.ref)
+			if err != nil {
+				log.Fatal(err)
+			}
+			t, err := c.repo.GetTask(id)
+			if err != nil {
+				log.Fatal(err)
+			}
+			cp := copyTask(t)
+			applyMods(cp, ti)
+			cp.Tags = mergeTags(cp.Tags, ti.remTags)
+			if err := checkReminders(cp); err != nil {
+				log.Fatal(err)
+			}
+			_, err = c.repo.AddTask(cp)
+			if err != nil {
+				log.Fatal(err)
+			}
+			c.printf("Copied task %d to task %d.\n", t.ShortID, cp.ShortID)
+			c.autoLink(cp, cp.Desc)
+			c.changedTasks(cp)
+		},
+	}
+	return copyCmd
+}
+
+// copyTask returns a new open task with the fields of t that 'gt add' sets, and fresh reminders
+func copyTask(t *types.Task) *types.Task {
+	cp := types.NewTask(t.Desc, t.Priority, slices.Clone(t.Tags), nil, t.StartAt, t.EndAt)
+	cp.Attrs = maps.Clone(t.Attrs)
+	for _, r := range t.Reminders {
+		cp.Reminders = append(cp.Reminders, types.Reminder{Offset: r.Offset, At: r.At})
+	}
+	return cp
+}
+
+// mergeTags returns tags without duplicates or those in remove, ignoring case
+func mergeTags(tags, remove []string) []string {
+	var out []string
+	for _, tg := range tags {
+		dup := func(s string) bool { return strings.EqualFold(s, tg) }
+		if !slices.ContainsFunc(out, dup) && !slices.ContainsFunc(remove, dup) {
+			out = append(out, tg)
+		}
+	}
+	return out
+}
+
 func (c *Cmd) ModCmd() *cobra.Command {
+	var flags func([]string) ([]string, bool)
 	editCmd := &cobra.Command{
-		Use:   "mod",
-		Short: "Modify task by ID",
+		Use:         "mod <id> <changes> | mod <ids or filter> -- <changes>",
+		Annotations: changesTasks,
+		Short:       "Modify tasks by ID or filter",
 		Long: `Modifies an existing task given the arguments provided. Mod allows reorganization of arguments, but duplicates are invalid.
+Several tasks can be changed at once by giving IDs or a filter, then '--' and the changes. The changes to each task are
+previewed and, above the configured number of tasks, confirmed before they are applied together.
 
 Optional:
 - description  Description of the task to be modified. Must be surrounded by ' or " if description spans more than 1 word.
-- time         '@' marks the beginning of the time expression (halts when encountering non-time token).
+- time         '@' marks the beginning of the time expression (halts when encountering non-time token). A new time of day
+               keeps the length of a range. '@ +1h', '@ shift +30m' or '@ same-time fri' moves the current time instead.
 - tag          Tag for categorizing the task, prefixed with '+'.
-- priority     Priority level for the task from 1 to 10 (min-max), prefixed with '%'.`,
+- priority     Priority level for the task as defined in config (default 1 to 5, where 1 is highest), prefixed with '%'.
+- attribute    User-defined attribute declared in config, given as 'name:value'. 'name:' removes it.
+- reminder     'remind:' followed by an offset from the task's time (-30m) or a date or time. 'remind:' removes all reminders.`,
 		Example: `gt mod 1 'Reorganize structure of ReadMe'
-gt mod 2 'Finish documentation for cobra commands' @ 11-01-2024 10am-4:15 +work %8
-gt mod 3 +project "Setup database" @ 11-3`,
+gt mod 2 'Finish documentation for cobra commands' @ 11-01-2024 10am-4:15 +work %2
+gt mod 3 +project "Setup database" @ 11-3
+gt mod 4 sprint:s12 ticket:
+gt mod 5 remind: remind:-1h
+gt mod 5 @ +1h
+gt mod 6 7 9 -- +review
+gt mod 3-7,10 -- %2
+gt mod ~readme +docs
+gt mod +sprint12 status:open -- %4 +carryover`,
 		Args: cobra.MinimumNArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			ti, err := parseTask(args, false)
-			if err != nil {
-				log.Fatal(err)
-			}
-			t, err := c.repo.GetTask(*ti.id)
-			if err != nil {
+			args, help := flags(args)
+			if help {
 				return
 			}
-
-			fmt.Printf("Task %d '%s' has been updated:\n", t.ID, t.Desc)
-			if ti.desc != nil {
-				fmt.Printf("  - Description updated to '%s'\n", *ti.desc)
-				t.Desc = *ti.desc
+			var tasks []*types.Task
+			var ti *taskInfo
+			var err error
+			if sel, mods, ok := splitMods(args); ok {
+				ti, err = parseMods(mods)
+				if err != nil {
+					fatal(err)
+				}
+				tasks, _, err = c.selectTasks(sel)
+				if err != nil {
+					fatal(err)
+				}
+				if len(tasks) == 0 {
+					c.println("No tasks match the filter.")
+					return
+				}
+			} else {
+				ti, err = parseTask(args, false)
+				if err != nil {
+					fatal(err)
+				}
+				id, err := c.resolveID(*ti.ref)
+				if err != nil {
+					log.Fatal(err)
+				}
+				t, err := c.repo.GetTask(id)
+				if err != nil {
+					log.Fatal(err)
+				}
+				tasks = []*types.Task{t}
 			}
-			if ti.priority != nil {
-				fmt.Printf("  - Priority updated from %d to %d\n", t.Priority, *ti.priority)
-				t.Priority = *ti.priority
+			c.modifyTasks(tasks, ti)
+		},
+	}
+	flags = c.filterCmd(editCmd)
+	return editCmd
+}
+
+func (c *Cmd) PostponeCmd() *cobra.Command {
+	var flags func([]string) ([]string, bool)
+	postponeCmd := &cobra.Command{
+		Use:         "postpone <ids or filter> <shift>",
+		Annotations: changesTasks,
+		Short:       "Move the times of tasks by ID or filter",
+		Long: `Moves the times of tasks, keeping the length of each. The shift is a signed duration (+2d, -1h, +1w; 'shift +30m'
+for minutes, as +30m reads as months) or 'same-time' and a date, which keeps the time of day. Tasks without a time are skipped. Changes are
+previewed and confirmed as with 'mod'.`,
+		Example: `gt postpone 5 +2d
+gt postpone 3 4 shift -30m
+gt postpone +work @ before tmrw +1d
+gt postpone 7 same-time next mon`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			args, help := flags(args)
+			if help {
+				return
 			}
-			if ti.addTags != nil {
-				for _, tg := range ti.addTags {
-					fmt.Printf("  - Tag added: %s\n", tg)
-				}
-				t.Tags = append(t.Tags, ti.addTags...)
+			sel, sh, err := parsePostpone(args)
+			if err != nil {
+				fatal(err)
 			}
-			if ti.startAt != nil {
-				t.StartAt = ti.startAt
-				t.EndAt = nil
+			tasks, byFilter, err := c.selectTasks(sel)
+			if err != nil {
+				fatal(err)
 			}
-			if ti.endAt != nil {
-				t.EndAt = ti.endAt
+			var timed []*types.Task
+			for _, t := range tasks {
+				if t.StartAt == nil {
+					c.printf("Task %d has no time, skipped.\n", t.ShortID)
+					continue
+				}
+				timed = append(timed, t)
 			}
-			if ti.startAt != nil {
-				fmt.Printf("  - Time updated to ")
-				if t.StartAt != nil && t.EndAt != nil {
-					fmt.Printf("%s %s - %s\n", t.StartAt.Format("Mon, 02 Jan 2006"), t.StartAt.Format(time.Kitchen), t.EndAt.Format(time.Kitchen))
+			if len(timed) == 0 {
+				if byFilter && len(tasks) == 0 {
+					c.println("No tasks match the filter.")
 				} else {
-					fmt.Printf("%s %s\n", t.StartAt.Format("Mon, 02 Jan 2006"), t.StartAt.Format(time.Kitchen))
+					c.println("No tasks postponed.")
 				}
+				return
 			}
-			curr := time.Now()
+			c.modifyTasks(timed, &taskInfo{shift: sh})
+		},
+	}
+	flags = c.filterCmd(postponeCmd)
+	return postponeCmd
+}
+
+// modifyTasks applies parsed changes to tasks and saves them together, previewing the changes
+// and asking first above the configured number of tasks
+func (c *Cmd) modifyTasks(tasks []*types.Task, ti *taskInfo) {
+	headers := make([]string, len(tasks))
+	changes := make([][]string, len(tasks))
+	for i, t := range tasks {
+		headers[i] = fmt.Sprintf("Task %d '%s'", t.ShortID, t.Desc)
+		changes[i] = applyMods(t, ti)
+		if err := checkReminders(t); err != nil {
+			log.Fatalf("task %d: %v", t.ShortID, err)
+		}
+	}
+	preview := len(tasks) > c.cfg.Bulk.Confirm
+	if preview {
+		for i := range tasks {
+			c.printChanges(headers[i]+" will be updated:", changes[i])
+		}
+		if !c.confirm(fmt.Sprintf("Apply these changes to %s?", plural(len(tasks)))) {
+			c.println("No tasks modified.")
+			return
+		}
+	}
+
+	if err := c.saveModified(tasks, ti); err != nil {
+		log.Fatal(err)
+	}
+	for i, t := range tasks {
+		if !preview {
+			c.printChanges(headers[i]+" has been updated:", changes[i])
+		}
+		if ti.desc != nil {
+			c.autoLink(t, t.Desc)
+		}
+	}
+	c.printf("Update complete. %s modified.\n", plural(len(tasks)))
+	c.changedTasks(tasks...)
+}
+
+// saveModified saves tasks changed by applyMods together
+func (c *Cmd) saveModified(tasks []*types.Task, ti *taskInfo) error {
+	curr := time.Now()
+	return c.repo.Transaction(func(tx *service.TaskRepo) error {
+		for _, t := range tasks {
 			t.UpdatedAt = &curr
-			err = c.repo.UpdateTask(t)
-			if err != nil {
-				log.Fatal(err)
+			if err := saveMods(tx, t, ti); err != nil {
+				return err
 			}
-			fmt.Println("Update complete. 1 task modified.")
-		},
+		}
+		return nil
+	})
+}
+
+// applyMods applies parsed changes to a task, returning a description of each change
+func applyMods(t *types.Task, ti *taskInfo) []string {
+	var lines []string
+	if ti.desc != nil {
+		lines = append(lines, fmt.Sprintf("Description updated to '%s'", *ti.desc))
+		t.Desc = *ti.desc
+	}
+	if ti.priority != nil {
+		lines = append(lines, fmt.Sprintf("Priority updated from %s to %s", priorities.Format(t.Priority), priorities.Format(*ti.priority)))
+		t.Priority = *ti.priority
+	}
+	for _, tg := range ti.addTags {
+		lines = append(lines, fmt.Sprintf("Tag added: %s", tg))
+		t.Tags = append(t.Tags, tg)
+	}
+	for _, n := range sortedKeys(ti.attrs) {
+		v := ti.attrs[n]
+		if v == "" {
+			lines = append(lines, fmt.Sprintf("Attribute removed: %s", n))
+			delete(t.Attrs, n)
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("Attribute %s updated to '%s'", n, v))
+		if t.Attrs == nil {
+			t.Attrs = make(map[string]string)
+		}
+		t.Attrs[n] = v
+	}
+	if ti.startAt != nil {
+		old := formatTaskTime(t)
+		// A new start without an end keeps the length of a range, unless only a date is given
+		// Example: a task at 10-11am given '@ fri 2pm' runs 2-3pm on Friday
+		var length time.Duration
+		if t.StartAt != nil && t.EndAt != nil && ti.endAt == nil && !ti.dateOnly {
+			length = t.EndAt.Sub(*t.StartAt)
+		}
+		t.StartAt, t.EndAt = ti.startAt, ti.endAt
+		if length > 0 {
+			end := t.StartAt.Add(length)
+			t.EndAt = &end
+		}
+		lines = append(lines, fmt.Sprintf("Time updated from %s to %s", old, formatTaskTime(t)))
+	}
+	if ti.shift != nil {
+		if t.StartAt == nil {
+			moveTime(t, ti.shift)
+			lines = append(lines, fmt.Sprintf("Time set to %s", formatTaskTime(t)))
+		} else {
+			old := formatTaskTime(t)
+			moveTime(t, ti.shift)
+			lines = append(lines, fmt.Sprintf("Time moved from %s to %s", old, formatTaskTime(t)))
+		}
+	}
+	if ti.clearReminders {
+		lines = append(lines, fmt.Sprintf("Reminders removed: %d", len(t.Reminders)))
+		t.Reminders = nil
+	}
+	for _, r := range ti.reminders {
+		lines = append(lines, fmt.Sprintf("Reminder added: %s", formatReminder(r, t)))
+		t.Reminders = append(t.Reminders, r)
+	}
+	return lines
+}
+
+// moveTime moves a task's time by a shift, keeping the length of a range. A task without a time
+// is moved from today: due on the day a shift by whole days or to a date lands on, otherwise at
+// the time a shift lands on from now.
+func moveTime(t *types.Task, sh *timeShift) {
+	if t.StartAt == nil {
+		now := wallNow()
+		start := time.Date(now.Year(), now.Month(), now.Day(), 23, 59, 0, 0, time.UTC).Add(sh.by)
+		switch {
+		case sh.date != nil:
+			start = *sh.date
+		case sh.by%(24*time.Hour) != 0:
+			start = now.Add(sh.by).Truncate(time.Minute)
+		}
+		t.StartAt = &start
+		return
+	}
+	start := t.StartAt.Add(sh.by)
+	if sh.date != nil {
+		start = onDate(*sh.date, *t.StartAt)
+	}
+	if t.EndAt != nil {
+		end := start.Add(t.EndAt.Sub(*t.StartAt))
+		t.EndAt = &end
+	}
+	t.StartAt = &start
+}
+
+// formatTaskTime formats a task's time for a list of changes, "none" if it has none
+func formatTaskTime(t *types.Task) string {
+	if t.StartAt == nil {
+		return "none"
+	}
+	return formatSpan(t.StartAt, t.EndAt, clockFmtLong)
+}
+
+// saveMods stores a task changed by applyMods
+func saveMods(repo *service.TaskRepo, t *types.Task, ti *taskInfo) error {
+	err := repo.UpdateTask(t)
+	if err != nil {
+		return err
+	}
+	if ti.clearReminders {
+		err = repo.DeleteReminders(t.ID)
+		if err != nil {
+			return err
+		}
+	}
+	for _, r := range ti.reminders {
+		r.TaskID = t.ID
+		err = repo.AddReminder(&r)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Cmd) printChanges(header string, lines []string) {
+	c.println(header)
+	for _, l := range lines {
+		c.printf("  - %s\n", l)
 	}
-	return editCmd
 }
 
 func (c *Cmd) NoteCmd() *cobra.Command {
 	comCmd := &cobra.Command{
-		Use:   "note",
-		Short: "Note task by ID",
+		Use:         "note",
+		Annotations: changesTasks,
+		Short:       "Note task by ID",
 		Long: `Attaches a note to a given task provided the task id. Notes are immutable and cannot be edited once created.
 
 Required:
-- id    Id referencing task.
+- id    Id referencing task, 'last' or '~words' of the description.
 - note  Note providing additional clarification for given task`,
 		Example: `gt note 1 "Provide short gif demonstrating GoTask CLI and TUI"
 gt note 2 "Finish writing up man docs from cobra commands"`,
 		Args: cobra.ExactArgs(2),
 		Run: func(cmd *cobra.Command, args []string) {
-			id, n, err := parseNote(args)
+			ref, n, err := parseNote(args)
+			if err != nil {
+				fatal(err)
+			}
+			id, err := c.resolveID(ref)
 			if err != nil {
 				log.Fatal(err)
 			}
-			d, err := c.repo.GetDesc(id)
+			t, err := c.repo.GetTask(id)
 			if err != nil {
 				log.Fatal(err)
 			}
@@ -185,369 +617,441 @@ gt note 2 "Finish writing up man docs from cobra commands"`,
 			if err != nil {
 				log.Fatal(err)
 			}
-			fmt.Printf("Task %d '%s' has been updated with a new note:\n", id, d)
-			fmt.Printf("  - Note: \"%s\"\n", n)
-			fmt.Println("1 task updated with a note.")
+			c.printf("Task %d '%s' has been updated with a new note:\n", t.ShortID, t.Desc)
+			c.printf("  - Note: \"%s\"\n", n)
+			c.autoLink(t, n)
+			c.println("1 task updated with a note.")
+			c.changedTasks(t)
 		},
 	}
 	return comCmd
 }
 
 func (c *Cmd) ListCmd() *cobra.Command {
+	var table tableOptions
+	var flags func([]string) ([]string, bool)
 	listCmd := &cobra.Command{
-		Use:     "list",
-		Short:   "List all tasks",
-		Long:    "Displays a list of all tasks created both new, overdue, and archived.",
-		Example: "gt list",
-		Args:    cobra.NoArgs,
+		Use:         "list [filter]",
+		Annotations: readsTasks,
+		Short:       "List all tasks",
+		Long:        "Displays a list of all tasks created both new, overdue, and archived, or of those matching a filter.",
+		Example:     "gt list\ngt list +work %<3\ngt list \"(+work or +home) and not status:done\"\ngt list @ before fri has:notes",
 		Run: func(cmd *cobra.Command, args []string) {
-			t, err := c.repo.GetTasks()
+			args, help := flags(args)
+			if help {
+				return
+			}
+			f, err := parseFilter(args)
+			if err != nil {
+				fatal(err)
+			}
+			t, err := c.repo.FindTasks(f)
 			if err != nil {
 				log.Fatal(err)
 			}
-			displayTasks(t)
+			if c.stdout != nil {
+				c.writeTasks(t)
+				return
+			}
+			c.displayTable(t, table)
 		},
 	}
+	table.addFlags(listCmd, "id,status,desc,priority,tags,due")
+	flags = c.filterCmd(listCmd)
 	return listCmd
 }
 
 func (c *Cmd) DueCmd() *cobra.Command {
+	var table tableOptions
+	var flags func([]string) ([]string, bool)
 	dueCmd := &cobra.Command{
-		Use:     "due",
-		Short:   "List all due and overdue tasks",
-		Long:    "Displays a list of all tasks due and overdue.",
-		Example: "gt due",
-		Args:    cobra.NoArgs,
+		Use:         "due [filter]",
+		Annotations: readsTasks,
+		Short:       "List all due and overdue tasks",
+		Long:        "Displays a list of all tasks due and overdue, or those matching a filter.",
+		Example:     "gt due\ngt due +work\ngt due @ by fri or %1",
 		Run: func(cmd *cobra.Command, args []string) {
-			t, err := c.repo.GetTasksDue()
+			args, help := flags(args)
+			if help {
+				return
+			}
+			f, err := parseFilter(args)
+			if err != nil {
+				fatal(err)
+			}
+			t, err := c.repo.FindTasks(filter.All(filter.Status{Done: false}, f))
 			if err != nil {
 				log.Fatal(err)
 			}
-			displayDueTasks(t)
+			if c.stdout != nil {
+				c.writeTasks(t)
+				return
+			}
+			c.displayTable(t, table)
 		},
 	}
+	table.addFlags(dueCmd, "id,desc,priority,tags,due")
+	flags = c.filterCmd(dueCmd)
 	return dueCmd
 }
 
 func (c *Cmd) ArchivedCmd() *cobra.Command {
+	var table tableOptions
+	var flags func([]string) ([]string, bool)
 	dueCmd := &cobra.Command{
-		Use:     "archived",
-		Short:   "List all archived tasks",
-		Long:    "Displays a list of all tasks archived.",
-		Example: "gt archived",
-		Args:    cobra.NoArgs,
+		Use:         "archived [filter]",
+		Annotations: readsTasks,
+		Short:       "List all archived tasks",
+		Long:        "Displays a list of all tasks archived, or those matching a filter.",
+		Example:     "gt archived\ngt archived +work\ngt archived @ by fri or %1",
 		Run: func(cmd *cobra.Command, args []string) {
-			t, err := c.repo.GetTasksArchived()
+			args, help := flags(args)
+			if help {
+				return
+			}
+			f, err := parseFilter(args)
+			if err != nil {
+				fatal(err)
+			}
+			t, err := c.repo.FindTasks(filter.All(filter.Status{Done: true}, f))
 			if err != nil {
 				log.Fatal(err)
 			}
-			displayArchivedTasks(t)
+			if c.stdout != nil {
+				c.writeTasks(t)
+				return
+			}
+			c.displayTable(t, table)
 		},
 	}
+	table.addFlags(dueCmd, "id,desc,priority,tags,completed")
+	flags = c.filterCmd(dueCmd)
 	return dueCmd
 }
 
 func (c *Cmd) DoneCmd() *cobra.Command {
+	var flags func([]string) ([]string, bool)
 	doneCmd := &cobra.Command{
-		Use:     "done",
-		Short:   "Mark task as complete by ID",
-		Long:    "Marks all tasks provided by ID as complete. This updates the lists that the tasks will now appear in (e.g. due, archived)",
-		Example: "gt done 2\ngt done 1 3",
-		Args:    cobra.MinimumNArgs(1),
+		Use:         "done <ids or filter>",
+		Annotations: changesTasks,
+		Short:       "Mark tasks as complete by ID or filter",
+		Long:        "Marks all tasks provided by ID, or matching a filter, as complete. This updates the lists that the tasks will now appear in (e.g. due, archived)",
+		Example:     "gt done 2\ngt done 1 3\ngt done 3-7,10\ngt done last\ngt done +cleanup",
+		Args:        cobra.MinimumNArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			ids, err := parseDone(args)
-			if err != nil {
-				log.Fatal(err)
-			}
-			for _, i := range ids {
-				t, err := c.repo.GetTask(i)
-				if err != nil {
-					log.Fatal(err)
-				}
-				if t.Finished {
-					fmt.Printf("Task %d already finished.\n", i)
-					return
-				} else {
-					err = c.repo.UpdateStatus(i, true)
-					if err != nil {
-						log.Fatal(err)
-					}
-					fmt.Printf("Finished task %d '%s'.\n", i, t.Desc)
-				}
-			}
-			if len(ids) > 1 {
-				fmt.Printf("Finished %d tasks.\n", len(ids))
-			} else {
-				fmt.Printf("Finished 1 task.\n")
+			args, help := flags(args)
+			if help {
+				return
 			}
+			c.setStatus(args, true)
 		},
 	}
+	flags = c.filterCmd(doneCmd)
 	return doneCmd
 }
 
 func (c *Cmd) UndoCmd() *cobra.Command {
+	var flags func([]string) ([]string, bool)
 	undoCmd := &cobra.Command{
-		Use:     "undo",
-		Short:   "Mark task as incomplete by ID",
-		Long:    "Marks all tasks provided by ID as incomplete. This updates the lists that the tasks will now appear in (e.g. due, archived)",
-		Example: "gt undo 3\ngt undo 2 1",
-		Args:    cobra.MinimumNArgs(1),
+		Use:         "undo <ids or filter>",
+		Annotations: changesTasks,
+		Short:       "Mark tasks as incomplete by ID or filter",
+		Long:        "Marks all tasks provided by ID, or matching a filter, as incomplete. This updates the lists that the tasks will now appear in (e.g. due, archived)",
+		Example:     "gt undo 3\ngt undo 2 1\ngt undo 4-6\ngt undo +cleanup @ on today",
+		Args:        cobra.MinimumNArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			ids, err := parseDone(args)
-			if err != nil {
-				log.Fatal(err)
-			}
-			for _, i := range ids {
-				t, err := c.repo.GetTask(i)
-				if err != nil {
-					log.Fatal(err)
-				}
-				if !t.Finished {
-					fmt.Printf("Task %d already incomplete.\n", i)
-					return
-				} else {
-					err = c.repo.UpdateStatus(i, false)
-					if err != nil {
-						log.Fatal(err)
-					}
-					fmt.Printf("Reverted task %d '%s' to incomplete.\n", i, t.Desc)
-				}
-			}
-			if len(ids) > 1 {
-				fmt.Printf("Reverted %d tasks.\n", len(ids))
-			} else {
-				fmt.Printf("Reverted 1 task.\n")
+			args, help := flags(args)
+			if help {
+				return
 			}
+			c.setStatus(args, false)
 		},
 	}
+	flags = c.filterCmd(undoCmd)
 	return undoCmd
 }
 
+// setStatus finishes or reopens the tasks selected by args, skipping those already in that state
+func (c *Cmd) setStatus(args []string, done bool) {
+	tasks, byFilter, err := c.selectTasks(args)
+	if err != nil {
+		fatal(err)
+	}
+	if byFilter && len(tasks) == 0 {
+		c.println("No tasks match the filter.")
+		return
+	}
+	var change []*types.Task
+	for _, t := range tasks {
+		switch {
+		case t.Finished == done && done:
+			c.printf("Task %d already finished.\n", t.ShortID)
+		case t.Finished == done:
+			c.printf("Task %d already incomplete.\n", t.ShortID)
+		default:
+			change = append(change, t)
+		}
+	}
+	if len(change) == 0 {
+		return
+	}
+
+	verb := "Finish"
+	if !done {
+		verb = "Reopen"
+	}
+	preview := len(change) > c.cfg.Bulk.Confirm
+	if preview {
+		c.printf("Preparing to %s:\n", strings.ToLower(verb))
+		for _, t := range change {
+			c.printf("  - Task %d: '%s'\n", t.ShortID, t.Desc)
+		}
+		if !c.confirm(fmt.Sprintf("%s %s?", verb, plural(len(change)))) {
+			c.println("No tasks modified.")
+			return
+		}
+	}
+	err = c.repo.Transaction(func(tx *service.TaskRepo) error {
+		for _, t := range change {
+			if err := tx.UpdateStatus(t.ID, done); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, t := range change {
+		if done {
+			c.printf("Finished task %d '%s'.\n", t.ShortID, t.Desc)
+		} else {
+			c.printf("Reverted task %d '%s' to incomplete.\n", t.ShortID, t.Desc)
+		}
+	}
+	if done {
+		c.printf("Finished %s.\n", plural(len(change)))
+	} else {
+		c.printf("Reverted %s.\n", plural(len(change)))
+	}
+	c.changedTasks(change...)
+}
+
 func (c *Cmd) DeleteCmd() *cobra.Command {
+	var flags func([]string) ([]string, bool)
 	deleteCmd := &cobra.Command{
-		Use:     "delete",
-		Short:   "Delete tasks by ID",
-		Long:    "Deletes all tasks provided by ID. This updates the lists that the tasks will no longer appear in (e.g. due, archived, list)",
-		Example: "gt delete 1\ngt delete 2 3",
-		Args:    cobra.MinimumNArgs(1),
+		Use:         "delete <ids or filter>",
+		Annotations: changesTasks,
+		Short:       "Delete tasks by ID or filter",
+		Long:        "Deletes all tasks provided by ID, or matching a filter. This updates the lists that the tasks will no longer appear in (e.g. due, archived, list)",
+		Example:     "gt delete 1\ngt delete 2 3\ngt delete ~readme\ngt delete @ before 2023-01-01 status:done",
+		Args:        cobra.MinimumNArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			ids, err := parseGet(args)
+			args, help := flags(args)
+			if help {
+				return
+			}
+			tasks, byFilter, err := c.selectTasks(args)
 			if err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
-			var tasks []*types.Task
-			for _, i := range ids {
-				t, err := c.repo.GetTask(i)
-				if err != nil {
-					log.Fatal(err)
-				}
-				tasks = append(tasks, t)
+			if byFilter && len(tasks) == 0 {
+				c.println("No tasks match the filter.")
+				return
 			}
-			fmt.Printf("Preparing to delete tasks with ")
-			if len(ids) == 1 {
-				fmt.Printf("ID: %d\n", ids[0])
+			c.printf("Preparing to delete tasks with ")
+			if len(tasks) == 1 {
+				c.printf("ID: %d\n", tasks[0].ShortID)
 			} else {
-				fmt.Printf("IDs: %d", ids[0])
-				for j := 1; j < len(ids); j++ {
-					fmt.Printf(", %d", ids[j])
+				c.printf("IDs: %d", tasks[0].ShortID)
+				for j := 1; j < len(tasks); j++ {
+					c.printf(", %d", tasks[j].ShortID)
 				}
+				c.println()
 			}
-			fmt.Println()
 			for _, t := range tasks {
-				fmt.Printf("  - Task %d: '%s'\n", t.ID, t.Desc)
+				c.printf("  - Task %d: '%s'", t.ShortID, t.Desc)
+				if len(t.Attachments) > 0 {
+					c.printf(" (%d attachment(s) will be removed)", len(t.Attachments))
+				}
+				c.println()
 			}
-			if len(ids) == 1 {
-				fmt.Printf("\nAre you sure you want to delete this task? (y/n): ")
-			} else {
-				fmt.Printf("\nAre you sure you want to delete these tasks? (y/n): ")
+			if len(tasks) > c.cfg.Bulk.ConfirmDelete {
+				question := "Are you sure you want to delete these tasks?"
+				if len(tasks) == 1 {
+					question = "Are you sure you want to delete this task?"
+				}
+				if !c.confirm(question) {
+					c.println("No tasks deleted.")
+					return
+				}
 			}
-			var s string
-			_, err = fmt.Scanln(&s)
+			err = c.repo.Transaction(func(tx *service.TaskRepo) error {
+				for _, t := range tasks {
+					if err := tx.DeleteTask(t.ID); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
 			if err != nil {
-				return
+				log.Fatal(err)
 			}
-			s = strings.TrimSpace(s)
-			if len(s) > 0 && s[0] == 'y' || s[0] == 'Y' {
-				for _, i := range ids {
-					err = c.repo.DeleteTask(i)
-					if err != nil {
-						log.Fatal(err)
-					}
+			for _, t := range tasks {
+				for _, a := range t.Attachments {
+					removeCachedAttachment(a.ID)
 				}
 			}
+			c.printf("Deleted %s.\n", plural(len(tasks)))
+			c.changedTasks(tasks...)
 		},
 	}
+	flags = c.filterCmd(deleteCmd)
 	return deleteCmd
 }
 
-//func (c *Cmd) ImportCmd() *cobra.Command {
-//	importCmd := &cobra.Command{
-//		Use:   "import",
-//		Short: "Import tasks from a file",
-//		Args:  cobra.ExactArgs(1),
-//		Run: func(cmd *cobra.Command, args []string) {
-//			fmt.Printf("Importing tasks from '%s'...\n", args[0])
-//			fmt.Println("  - 10 tasks found in the file\n  - 9 tasks successfully imported\n  - 1 task skipped (duplicate ID: 5)\n\nImport complete. 9 tasks added.")
-//		},
-//	}
-//	return importCmd
-//}
-//
-//func (c *Cmd) ExportCmd() *cobra.Command {
-//	exportCmd := &cobra.Command{
-//		Use:   "export",
-//		Short: "Export tasks to file",
-//		Args:  cobra.ExactArgs(1),
-//		Run: func(cmd *cobra.Command, args []string) {
-//			fmt.Printf("Exporting tasks to '%s'...\n", args[0])
-//			fmt.Printf("  - 15 tasks exported\n\n")
-//			fmt.Printf("Export complete. All tasks saved to '%s'.\n", args[0])
-//		},
-//	}
-//	return exportCmd
-//}
-
-func displayTask(task *types.Task) {
+func (c *Cmd) RenumberCmd() *cobra.Command {
+	renumberCmd := &cobra.Command{
+		Use:         "renumber",
+		Annotations: changesTasks,
+		Short:       "Compact task IDs",
+		Long: `Reassigns task IDs as 1, 2, 3... keeping their current order. Only the short IDs change;
+UUIDs, notes, tags and other references to the tasks are unaffected.`,
+		Example: "gt renumber",
+		Args:    cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			before, err := c.repo.GetTasks()
+			if err != nil {
+				log.Fatal(err)
+			}
+			err = c.repo.Renumber()
+			if err != nil {
+				log.Fatal(err)
+			}
+			after, err := c.repo.GetTasks()
+			if err != nil {
+				log.Fatal(err)
+			}
+			n := 0
+			for i, t := range after {
+				if before[i].ShortID != t.ShortID {
+					c.printf("  - Task %d is now %d\n", before[i].ShortID, t.ShortID)
+					c.changedTasks(t)
+					n++
+				}
+			}
+			c.printf("Renumber complete. %d task(s) renumbered.\n", n)
+		},
+	}
+	return renumberCmd
+}
+
+func (c *Cmd) displayTask(task *types.Task) {
 	// Print header
-	fmt.Println("Task Details:")
-	fmt.Println("--------------")
-	fmt.Printf("ID             %d\n", task.ID)
-	fmt.Printf("Description    %s\n", task.Desc)
-	fmt.Printf("Priority       %d\n", task.Priority)
+	c.println("Task Details:")
+	c.println("--------------")
+	c.printf("ID             %d\n", task.ShortID)
+	c.printf("UUID           %s\n", task.UUID)
+	c.printf("Description    %s\n", task.Desc)
+	c.printf("Priority       %s\n", priorities.Format(task.Priority))
 	if len(task.Tags) > 0 {
 		t := strings.Join(task.Tags, ", ")
-		fmt.Printf("Tags           %v\n", t)
+		c.printf("Tags           %v\n", t)
+	}
+	for _, n := range sortedKeys(task.Attrs) {
+		c.printf("%-15s%s\n", n, task.Attrs[n])
 	}
 
 	// Display 'Due' with date and time
 	if task.StartAt == nil && task.EndAt == nil {
-		fmt.Println("Due            <not set>")
-	} else if task.StartAt != nil && task.EndAt != nil {
-		fmt.Printf("Due            %s %s - %s\n", task.StartAt.Format("Mon, 02 Jan 2006"), task.StartAt.Format(time.Kitchen), task.EndAt.Format(time.Kitchen))
+		c.println("Due            <not set>")
 	} else if task.StartAt != nil {
-		fmt.Printf("Due            %s %s\n", task.StartAt.Format("Mon, 02 Jan 2006"), task.StartAt.Format(time.Kitchen))
+		c.printf("Due            %s\n", formatSpan(task.StartAt, task.EndAt, clockFmtLong))
 	} else {
-		fmt.Printf("Due            %s %s\n", task.EndAt.Format("Mon, 02 Jan 2006"), task.EndAt.Format(time.Kitchen))
+		c.printf("Due            %s %s\n", formatDate(*task.EndAt), task.EndAt.Format(clockFmtLong))
 	}
 
 	// Display last modified time
-	fmt.Printf("Last modified  %s\n", task.UpdatedAt.Format(time.RFC1123))
+	c.printf("Last modified  %s\n", formatLocal(*task.UpdatedAt, dateFmt+" "+clockFmtLong))
 
-	fmt.Printf("\nNotes:\n")
+	c.printf("\nNotes:\n")
 	if task.Notes != nil {
 		for _, n := range task.Notes {
-			fmt.Printf("  - %s\n", n)
+			c.printf("  - %s\n", n)
 		}
 	}
-}
-
-// formatTask prints a task in the desired format
-func formatTask(task *types.Task) string {
-	// Format the status
-	status := "[ ]"
-	if task.Finished {
-		status = "[x]"
-	}
 
-	var d string
-	if len(task.Desc) > 27 {
-		d = task.Desc[:27]
-		d += ".."
-	} else {
-		d = task.Desc
+	if len(task.Checklist) > 0 {
+		done, total := task.Progress()
+		c.printf("\nChecklist (%d/%d):\n", done, total)
+		for i, item := range task.Checklist {
+			c.printf("  %d. %s %s\n", i+1, formatCheck(item.Done), item.Text)
+		}
 	}
 
-	// Format the tags
-	tags := strings.Join(task.Tags, ", ")
-	if len(tags) > 10 {
-		tags = tags[:10]
-		tags += ".."
+	if len(task.Reminders) > 0 {
+		c.printf("\nReminders:\n")
+		for _, r := range task.Reminders {
+			c.printf("  - %s\n", formatReminder(r, task))
+		}
 	}
 
-	// Format the due date and time
-	var due string
-	if task.StartAt != nil && task.EndAt != nil {
-		due = fmt.Sprintf("%s %s - %s",
-			task.StartAt.Format("Mon, 02 Jan 2006"),
-			task.StartAt.Format("03:04pm"),
-			task.EndAt.Format("03:04pm"))
-	} else if task.StartAt != nil {
-		due = fmt.Sprintf("%s %s",
-			task.StartAt.Format("Mon, 02 Jan 2006"),
-			task.StartAt.Format("03:04pm"))
-	} else {
-		due = "-"
+	if len(task.Attachments) > 0 {
+		c.printf("\nAttachments:\n")
+		for i, a := range task.Attachments {
+			c.printf("  %d. %s\n", i+1, formatAttachment(a))
+		}
 	}
-	// Format the output string with additional spaces for the 'Due' column
-	return fmt.Sprintf("%-6d %-7s %-30s %-10d %-13s %s   ", // Adjusted format string with extra spaces
-		task.ID, status, d, task.Priority, tags, due)
 }
 
-// displayTasks prints a list of tasks in the desired format
-func displayTasks(tasks []*types.Task) {
-	// Print header
-	fmt.Println("ID     Status  Desc                           Priority   Tags          Due   ")
-	fmt.Println("---------------------------------------------------------------------------------------------------------")
-
-	// Print each task
-	for _, task := range tasks {
-		fmt.Println(formatTask(task))
+// formatSpan formats the time of a task, giving the end's date only when the span crosses days
+// Spans covering whole days are shown as dates alone, e.g. "Fri, 01 Nov 2024 - Tue, 05 Nov 2024"
+func formatSpan(start, end *time.Time, clock string) string {
+	if end == nil {
+		return fmt.Sprintf("%s %s", formatDate(*start), start.Format(clock))
 	}
-}
-
-func formatTaskArchived(task *types.Task, archived bool) string {
-	var d string
-	if len(task.Desc) > 27 {
-		d = task.Desc[:27]
-		d += ".."
-	} else {
-		d = task.Desc
-	}
-
-	// Format the tags
-	tags := strings.Join(task.Tags, ", ")
-	if len(tags) > 10 {
-		tags = tags[:10]
-		tags += ".."
-	}
-
-	var due string
-	if !archived {
-		if task.StartAt != nil && task.EndAt != nil {
-			due = fmt.Sprintf("%s %s - %s",
-				task.StartAt.Format("Mon, 02 Jan 2006"),
-				task.StartAt.Format("03:04pm"),
-				task.EndAt.Format("03:04pm"))
-		} else if task.StartAt != nil {
-			due = fmt.Sprintf("%s %s",
-				task.StartAt.Format("Mon, 02 Jan 2006"),
-				task.StartAt.Format("03:04pm"))
-		} else {
-			due = "-"
+	sameDay := start.Year() == end.Year() && start.YearDay() == end.YearDay()
+	if start.Hour() == 0 && start.Minute() == 0 && end.Hour() == 23 && end.Minute() == 59 {
+		if sameDay {
+			return formatDate(*start)
 		}
-	} else {
-		due = task.CompletedAt.Format(time.DateTime)
+		return fmt.Sprintf("%s - %s", formatDate(*start), formatDate(*end))
 	}
-
-	// Format the output string with additional spaces for the 'Due' column
-	return fmt.Sprintf("%-6d %-30s %-10d %-13s %s   ", // Adjusted format string with extra spaces
-		task.ID, d, task.Priority, tags, due)
+	if sameDay {
+		return fmt.Sprintf("%s %s - %s", formatDate(*start), start.Format(clock), end.Format(clock))
+	}
+	return fmt.Sprintf("%s %s - %s %s", formatDate(*start), start.Format(clock), formatDate(*end), end.Format(clock))
 }
 
-func displayDueTasks(tasks []*types.Task) {
-	fmt.Println("ID     Desc                           Priority   Tags          Due   ")
-	fmt.Println("-------------------------------------------------------------------------------------------------")
-
-	for _, t := range tasks {
-		fmt.Println(formatTaskArchived(t, false))
+// sortedKeys returns the keys of an attribute map in a stable display order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
 	}
+	sort.Strings(keys)
+	return keys
 }
 
-func displayArchivedTasks(tasks []*types.Task) {
-	fmt.Println("ID     Desc                           Priority   Tags          Completed   ")
-	fmt.Println("-------------------------------------------------------------------------------------------------")
-
-	for _, t := range tasks {
-		fmt.Println(formatTaskArchived(t, true))
+// formatDuration formats a duration compactly in the units parseDuration accepts, e.g. "1d2h30m"
+func formatDuration(d time.Duration) string {
+	if d < 0 {
+		return "-" + formatDuration(-d)
+	}
+	if d < time.Minute {
+		return d.String()
+	}
+	var s string
+	if days := d / (24 * time.Hour); days > 0 {
+		s += fmt.Sprintf("%dd", days)
+		d -= days * 24 * time.Hour
+	}
+	if h := d / time.Hour; h > 0 {
+		s += fmt.Sprintf("%dh", h)
+		d -= h * time.Hour
+	}
+	if m := d / time.Minute; m > 0 {
+		s += fmt.Sprintf("%dm", m)
 	}
+	return s
 }
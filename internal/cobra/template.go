@@ -0,0 +1,304 @@
+package cobra
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/EvoSched/gotask/internal/config"
+	"github.com/EvoSched/gotask/internal/service"
+	"github.com/EvoSched/gotask/internal/types"
+	"github.com/spf13/cobra"
+)
+
+func (c *Cmd) TemplateCmd() *cobra.Command {
+	templateCmd := &cobra.Command{
+		Use:   "template",
+		Short: "Save and apply task templates",
+		Long: `Templates capture a bundle of tasks with their tags, priorities, attributes and times relative to an anchor date.
+Templates are saved in the database with 'gt template save' or declared in config under 'templates'.`,
+		Example: `gt template save release 4 5 6 --var version=1.3
+gt template apply release --var version=1.4 --anchor fri
+gt template list`,
+	}
+	templateCmd.AddCommand(c.templateSaveCmd(), c.templateApplyCmd(), c.templateListCmd(), c.templateDeleteCmd())
+	return templateCmd
+}
+
+func (c *Cmd) templateSaveCmd() *cobra.Command {
+	var vars []string
+	var force bool
+	saveCmd := &cobra.Command{
+		Use:   "save",
+		Short: "Save tasks as a template",
+		Long: `Saves the given tasks as a template. Task times are stored relative to the day of the earliest task.
+Each '--var name=value' replaces the value in descriptions with a '{{name}}' placeholder.
+
+Required:
+- name  Name of the template.
+- id    Ids referencing the tasks to save.`,
+		Example: `gt template save onboarding 7 8 9
+gt template save release 4 5 6 --var version=1.3`,
+		Args: cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			name := args[0]
+			refs, err := parseGet(args[1:])
+			if err != nil {
+				fatal(err)
+			}
+			vm, err := parseVars(vars)
+			if err != nil {
+				log.Fatal(err)
+			}
+			if _, err := c.repo.GetTemplate(name); err == nil && !force {
+				log.Fatalf("template %s already exists, use --force to replace it", name)
+			}
+			ids, err := c.resolveIDs(refs)
+			if err != nil {
+				log.Fatal(err)
+			}
+			var tasks []*types.Task
+			var anchor *time.Time
+			for _, i := range ids {
+				t, err := c.repo.GetTask(i)
+				if err != nil {
+					log.Fatal(err)
+				}
+				if t.StartAt != nil {
+					d := time.Date(t.StartAt.Year(), t.StartAt.Month(), t.StartAt.Day(), 0, 0, 0, 0, t.StartAt.Location())
+					if anchor == nil || d.Before(*anchor) {
+						anchor = &d
+					}
+				}
+				tasks = append(tasks, t)
+			}
+			var tt []config.TemplateTask
+			for _, t := range tasks {
+				tt = append(tt, newTemplateTask(t, anchor, vm))
+			}
+			err = c.repo.SaveTemplate(name, tt)
+			if err != nil {
+				log.Fatal(err)
+			}
+			c.printf("Saved template '%s' with %d task(s).\n", name, len(tt))
+		},
+	}
+	saveCmd.Flags().StringArrayVar(&vars, "var", nil, "replace a value in descriptions with a {{name}} placeholder, as name=value")
+	saveCmd.Flags().BoolVarP(&force, "force", "f", false, "replace an existing template")
+	return saveCmd
+}
+
+func (c *Cmd) templateApplyCmd() *cobra.Command {
+	var vars []string
+	var anchorArg string
+	applyCmd := &cobra.Command{
+		Use:         "apply",
+		Annotations: changesTasks,
+		Short:       "Create tasks from a template",
+		Long: `Creates the tasks of a template. Times are shifted relative to the anchor date (default today)
+and '{{name}}' placeholders are replaced with the values given by '--var name=value'.
+
+Required:
+- name  Name of the template.`,
+		Example: `gt template apply onboarding
+gt template apply release --var version=1.4 --anchor fri`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			vm, err := parseVars(vars)
+			if err != nil {
+				log.Fatal(err)
+			}
+			anchor, err := parseAnchor(anchorArg)
+			if err != nil {
+				log.Fatal(err)
+			}
+			tt, err := c.template(args[0])
+			if err != nil {
+				log.Fatal(err)
+			}
+			// build every task first and add them together so a bad template doesn't leave half
+			// of it applied
+			var tasks []*types.Task
+			for _, x := range tt {
+				t, err := instantiate(x, anchor, vm)
+				if err != nil {
+					log.Fatal(err)
+				}
+				tasks = append(tasks, t)
+			}
+			err = c.repo.Transaction(func(tx *service.TaskRepo) error {
+				for _, t := range tasks {
+					if _, err := tx.AddTask(t); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				log.Fatal(err)
+			}
+			for _, t := range tasks {
+				c.printf("Added task %d '%s'.\n", t.ShortID, t.Desc)
+				c.changedTasks(t)
+			}
+			c.printf("Applied template '%s', %d task(s) added.\n", args[0], len(tasks))
+		},
+	}
+	applyCmd.Flags().StringArrayVar(&vars, "var", nil, "set a template variable, as name=value")
+	applyCmd.Flags().StringVar(&anchorArg, "anchor", "", "date the template's times are relative to (default today)")
+	return applyCmd
+}
+
+func (c *Cmd) templateListCmd() *cobra.Command {
+	listCmd := &cobra.Command{
+		Use:     "list",
+		Short:   "List templates",
+		Long:    "Lists the templates saved in the database and declared in config.",
+		Example: "gt template list",
+		Args:    cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			names, err := c.repo.GetTemplateNames()
+			if err != nil {
+				log.Fatal(err)
+			}
+			for _, n := range names {
+				tt, err := c.repo.GetTemplate(n)
+				if err != nil {
+					log.Fatal(err)
+				}
+				c.printf("%-20s %3d task(s)  (database)\n", n, len(tt))
+			}
+			var cfgNames []string
+			for n := range c.cfg.Templates {
+				cfgNames = append(cfgNames, n)
+			}
+			sort.Strings(cfgNames)
+			for _, n := range cfgNames {
+				c.printf("%-20s %3d task(s)  (config)\n", n, len(c.cfg.Templates[n]))
+			}
+		},
+	}
+	return listCmd
+}
+
+func (c *Cmd) templateDeleteCmd() *cobra.Command {
+	deleteCmd := &cobra.Command{
+		Use:     "delete",
+		Short:   "Delete a saved template",
+		Long:    "Deletes a template saved in the database. Templates declared in config must be removed from config.",
+		Example: "gt template delete release",
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if _, err := c.repo.GetTemplate(args[0]); err != nil {
+				log.Fatalf("no template saved as '%s'", args[0])
+			}
+			err := c.repo.DeleteTemplate(args[0])
+			if err != nil {
+				log.Fatal(err)
+			}
+			c.printf("Deleted template '%s'.\n", args[0])
+		},
+	}
+	return deleteCmd
+}
+
+// template returns a template saved in the database, falling back to one declared in config
+func (c *Cmd) template(name string) ([]config.TemplateTask, error) {
+	tt, err := c.repo.GetTemplate(name)
+	if errors.Is(err, sql.ErrNoRows) {
+		if tt, ok := c.cfg.Templates[name]; ok {
+			return tt, nil
+		}
+		return nil, fmt.Errorf("no template named '%s'", name)
+	}
+	return tt, err
+}
+
+// newTemplateTask captures a task relative to anchor, replacing variable values with placeholders
+func newTemplateTask(t *types.Task, anchor *time.Time, vars map[string]string) config.TemplateTask {
+	desc := t.Desc
+	for n, v := range vars {
+		if v != "" {
+			desc = strings.ReplaceAll(desc, v, "{{"+n+"}}")
+		}
+	}
+	x := config.TemplateTask{
+		Desc:     desc,
+		Priority: priorities.Format(t.Priority),
+		Tags:     t.Tags,
+		Attrs:    t.Attrs,
+	}
+	if t.StartAt != nil {
+		x.Start = formatDuration(t.StartAt.Sub(*anchor))
+		if t.EndAt != nil {
+			x.Duration = formatDuration(t.EndAt.Sub(*t.StartAt))
+		}
+	}
+	return x
+}
+
+// instantiate creates a task from a template task at anchor with variables expanded
+func instantiate(x config.TemplateTask, anchor time.Time, vars map[string]string) (*types.Task, error) {
+	desc, err := expandVars(x.Desc, vars)
+	if err != nil {
+		return nil, err
+	}
+	p := priorities.DefaultValue()
+	if x.Priority != "" {
+		p, err = priorities.Parse(x.Priority)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var start, end *time.Time
+	if x.Start != "" {
+		d, err := parseDuration(x.Start)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template start offset: %w", err)
+		}
+		s := anchor.Add(d)
+		start = &s
+		if x.Duration != "" {
+			d, err := parseDuration(x.Duration)
+			if err != nil || d <= 0 {
+				return nil, fmt.Errorf("invalid template duration: %s", x.Duration)
+			}
+			e := s.Add(d)
+			end = &e
+		}
+	}
+	var tags []string
+	for _, tg := range x.Tags {
+		tg, err = expandVars(tg, vars)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, tg)
+	}
+	t := types.NewTask(desc, p, tags, nil, start, end)
+	for n, v := range x.Attrs {
+		v, err = expandVars(v, vars)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := udaDefs[n]; ok {
+			v, err = parseAttr(n, v)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if t.Attrs == nil {
+			t.Attrs = make(map[string]string)
+		}
+		t.Attrs[n] = v
+	}
+	t.Attrs, err = attrDefaults(t.Attrs)
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
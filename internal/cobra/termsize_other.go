@@ -0,0 +1,10 @@
+//go:build !unix
+
+package cobra
+
+import "os"
+
+// terminalSize reports no size where it can't be queried; COLUMNS and LINES still apply
+func terminalSize(f *os.File) (int, int, bool) {
+	return 0, 0, false
+}
@@ -0,0 +1,807 @@
+package cobra
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/EvoSched/gotask/internal/filter"
+	"github.com/EvoSched/gotask/internal/types"
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/cobra"
+)
+
+func (c *Cmd) TuiCmd() *cobra.Command {
+	var flags func([]string) ([]string, bool)
+	tuiCmd := &cobra.Command{
+		Use:   "tui [filter]",
+		Short: "Browse and change tasks in a full-screen interface",
+		Long: `Opens a full-screen interface listing tasks, or those matching a filter, with the details and notes of the
+selected task and a sidebar of tags. The list refreshes whenever the database changes.
+
+Keys:
+- j, k, arrows  Move through the list, or the tags when the sidebar has focus (tab switches)
+- a, m          Add a task, or modify the selected one, written as with 'gt add' and 'gt mod'
+- x, space      Mark the selected task done, or open again
+- n             Add a note to the selected task
+- /, s          Filter as with 'gt list', or sort as with --sort (e.g. due,-priority)
+- t             Show or hide the tag sidebar
+- r, q          Reload, or quit`,
+		Example: "gt tui\ngt tui +work status:open",
+		Run: func(cmd *cobra.Command, args []string) {
+			args, help := flags(args)
+			if help {
+				return
+			}
+			if !isTerminal(os.Stdin) || !isTerminal(os.Stdout) {
+				log.Fatal("'gt tui' needs a terminal")
+			}
+			f, err := parseFilter(args)
+			if err != nil {
+				fatal(err)
+			}
+			quoted := make([]string, len(args))
+			for i, a := range args {
+				quoted[i] = shellQuote(a)
+			}
+			u := &tui{c: c, filter: f, filterText: strings.Join(quoted, " "), sidebar: true}
+			if err := u.run(); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	flags = c.filterCmd(tuiCmd)
+	return tuiCmd
+}
+
+// tuiFocus is the pane keys move through
+type tuiFocus int
+
+const (
+	focusList tuiFocus = iota
+	focusTags
+)
+
+// tuiHelp is shown on the status line when there's nothing else to show
+const tuiHelp = "a add  m modify  x done/undo  n note  / filter  s sort  t tags  tab focus  r reload  q quit"
+
+// tui is the state of the full-screen interface. It changes tasks through the same parser and
+// service calls as the commands, showing errors on its status line rather than exiting.
+type tui struct {
+	c      *Cmd
+	out    *bufio.Writer
+	styles *taskStyles // nil without color
+
+	filter     filter.Expr
+	filterText string
+	sort       string
+	all        []*types.Task // tasks matching the filter
+	tasks      []*types.Task // those listed, with the tag picked in the sidebar
+	tags       []string      // tags of all, for the sidebar
+	tag        string        // tag picked in the sidebar, "" for all
+
+	focus      tuiFocus
+	sidebar    bool
+	cursor     int // index in tasks
+	offset     int // first task shown
+	tagCursor  int // index in the sidebar, 0 being all tasks
+	tagOffset  int
+	detail     *types.Task // the selected task read in full
+	detailLink []string    // its links, described
+
+	prompt  string // label of the line being read, empty when not reading
+	input   []rune
+	onInput func(string) error
+	message string
+}
+
+// run shows the interface until it's quit, restoring the terminal afterwards
+func (u *tui) run() error {
+	restore, err := rawMode(os.Stdin)
+	if err != nil {
+		return err
+	}
+	defer restore()
+	u.out = bufio.NewWriter(os.Stdout)
+	u.out.WriteString("\x1b[?1049h\x1b[?25l")
+	defer func() {
+		u.out.WriteString("\x1b[?25h\x1b[?1049l")
+		u.out.Flush()
+	}()
+	if u.c.useColor(os.Stdout) {
+		theme, err := u.c.cfg.Theme()
+		if err != nil {
+			return err
+		}
+		u.styles = &taskStyles{theme: theme}
+	}
+
+	// sqlite replaces journal files next to the database, so watch the directory
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+	db, err := filepath.Abs(u.c.cfg.SQLite.Database)
+	if err != nil {
+		return err
+	}
+	if err := w.Add(filepath.Dir(db)); err != nil {
+		return err
+	}
+
+	keys := make(chan string)
+	go readKeys(os.Stdin, keys)
+	resize := make(chan os.Signal, 1)
+	notifyResize(resize)
+	defer signal.Stop(resize)
+
+	u.reload()
+	var refresh <-chan time.Time
+	for {
+		u.draw()
+		select {
+		case k, ok := <-keys:
+			if !ok {
+				return nil
+			}
+			for _, key := range splitKeys(k) {
+				if !u.handle(key) {
+					return nil
+				}
+			}
+		case <-resize:
+		case ev := <-w.Events:
+			// changes come in bursts, so reload once they settle
+			if strings.HasPrefix(filepath.Base(ev.Name), filepath.Base(db)) && refresh == nil {
+				refresh = time.After(200 * time.Millisecond)
+			}
+		case <-refresh:
+			refresh = nil
+			u.reload()
+		case err := <-w.Errors:
+			u.message = err.Error()
+		}
+	}
+}
+
+// readKeys sends what's read from r, a key or a paste at a time, until it fails
+func readKeys(r io.Reader, keys chan<- string) {
+	buf := make([]byte, 1024)
+	for {
+		n, err := r.Read(buf)
+		if err != nil {
+			close(keys)
+			return
+		}
+		keys <- string(buf[:n])
+	}
+}
+
+// escKeys names the keys sent as escape sequences, after "\x1b[" or "\x1bO"
+var escKeys = map[string]string{
+	"A": "up", "B": "down", "C": "right", "D": "left", "H": "home", "F": "end",
+	"1~": "home", "4~": "end", "3~": "delete", "5~": "pgup", "6~": "pgdn",
+}
+
+// splitKeys splits input read from a raw terminal into keys: printable characters as themselves,
+// others by name such as "enter", "up" or "ctrl-c". Unknown sequences are dropped.
+func splitKeys(s string) []string {
+	var keys []string
+	for len(s) > 0 {
+		if s[0] == 0x1b {
+			if len(s) > 2 && (s[1] == '[' || s[1] == 'O') {
+				end := 2
+				for end < len(s) && (s[end] < 0x40 || s[end] > 0x7e) {
+					end++
+				}
+				if end < len(s) {
+					if k, ok := escKeys[s[2:end+1]]; ok {
+						keys = append(keys, k)
+					}
+					s = s[end+1:]
+					continue
+				}
+			}
+			keys = append(keys, "esc")
+			s = s[1:]
+			continue
+		}
+		r, n := utf8.DecodeRuneInString(s)
+		s = s[n:]
+		switch {
+		case r == '\r' || r == '\n':
+			keys = append(keys, "enter")
+		case r == 0x7f || r == 0x08:
+			keys = append(keys, "backspace")
+		case r == '\t':
+			keys = append(keys, "tab")
+		case r == 0x03:
+			keys = append(keys, "ctrl-c")
+		case r == 0x15:
+			keys = append(keys, "ctrl-u")
+		case r >= 0x20 && r != utf8.RuneError:
+			keys = append(keys, string(r))
+		}
+	}
+	return keys
+}
+
+// reload reads the tasks matching the filter again, keeping the selected task selected
+func (u *tui) reload() {
+	var id int
+	if t := u.selected(); t != nil {
+		id = t.ID
+	}
+	tasks, err := u.c.repo.FindTasks(u.filter)
+	if err != nil {
+		u.message = err.Error()
+		return
+	}
+	if err := sortTasks(tasks, u.sort); err != nil {
+		u.message = err.Error()
+	}
+	if u.styles != nil {
+		if u.styles.blocked, err = u.c.repo.BlockedIDs(); err != nil {
+			u.message = err.Error()
+		}
+	}
+	u.all = tasks
+	u.tags = nil
+	for _, t := range tasks {
+		for _, tg := range t.Tags {
+			if !slices.Contains(u.tags, tg) {
+				u.tags = append(u.tags, tg)
+			}
+		}
+	}
+	slices.SortFunc(u.tags, func(a, b string) int { return strings.Compare(strings.ToLower(a), strings.ToLower(b)) })
+	if !slices.Contains(u.tags, u.tag) {
+		u.tag = ""
+	}
+	u.tagCursor = slices.Index(u.tags, u.tag) + 1
+	u.pickTag()
+	u.selectID(id)
+}
+
+// pickTag lists the tasks with the tag picked in the sidebar
+func (u *tui) pickTag() {
+	u.tasks = u.all
+	if u.tag != "" {
+		u.tasks = nil
+		for _, t := range u.all {
+			if slices.Contains(t.Tags, u.tag) {
+				u.tasks = append(u.tasks, t)
+			}
+		}
+	}
+	u.detail = nil
+	u.cursor = min(u.cursor, max(len(u.tasks)-1, 0))
+}
+
+// selectID moves the cursor to the task with id, if it's listed
+func (u *tui) selectID(id int) {
+	for i, t := range u.tasks {
+		if t.ID == id {
+			u.cursor = i
+		}
+	}
+	u.detail = nil
+}
+
+func (u *tui) selected() *types.Task {
+	if u.cursor < len(u.tasks) {
+		return u.tasks[u.cursor]
+	}
+	return nil
+}
+
+// current returns the selected task read in full, with its notes, checklist and links
+func (u *tui) current() *types.Task {
+	t := u.selected()
+	if t == nil || u.detail != nil && u.detail.ID == t.ID {
+		return u.detail
+	}
+	d, err := u.c.repo.GetTask(t.ID)
+	if err != nil {
+		u.message = err.Error()
+		return nil
+	}
+	u.detail, u.detailLink = d, nil
+	for _, l := range d.Links {
+		phrase, other := l.Kind.Outgoing(), l.ToID
+		if l.ToID == d.ID {
+			phrase, other = l.Kind.Incoming(), l.FromID
+		}
+		if o, err := u.c.repo.GetTask(other); err == nil {
+			u.detailLink = append(u.detailLink, fmt.Sprintf("%s %s %d %s", phrase, formatCheck(o.Finished), o.ShortID, o.Desc))
+		}
+	}
+	return d
+}
+
+// handle acts on a key, reporting false when the interface should close
+func (u *tui) handle(key string) bool {
+	if u.prompt != "" {
+		u.edit(key)
+		return true
+	}
+	u.message = ""
+	step := 1
+	switch key {
+	case "q", "ctrl-c":
+		return false
+	case "k", "up":
+		step = -1
+		fallthrough
+	case "j", "down":
+		u.move(step)
+	case "pgup":
+		u.move(-u.pageSize())
+	case "pgdn":
+		u.move(u.pageSize())
+	case "g", "home":
+		u.move(-len(u.all) - 1)
+	case "G", "end":
+		u.move(len(u.all) + 1)
+	case "tab":
+		if u.sidebar && u.focus == focusList {
+			u.focus = focusTags
+		} else {
+			u.focus = focusList
+		}
+	case "enter", "esc":
+		u.focus = focusList
+	case "t":
+		u.sidebar = !u.sidebar
+		u.focus = focusList
+	case "r":
+		u.reload()
+	case "/":
+		u.ask("filter: ", u.filterText, u.setFilter)
+	case "s":
+		u.ask("sort: ", u.sort, u.setSort)
+	case "a":
+		u.ask("add: ", "", u.add)
+	case "m":
+		if t := u.selected(); t != nil {
+			u.ask(fmt.Sprintf("mod %d: ", t.ShortID), "", u.modify)
+		}
+	case "n":
+		if t := u.selected(); t != nil {
+			u.ask(fmt.Sprintf("note %d: ", t.ShortID), "", u.note)
+		}
+	case "x", " ":
+		u.toggle()
+	case "?":
+		u.message = tuiHelp
+	}
+	return true
+}
+
+// move moves the cursor of the pane with focus by n rows
+func (u *tui) move(n int) {
+	if u.focus == focusTags {
+		u.tagCursor = max(0, min(u.tagCursor+n, len(u.tags)))
+		u.tag = ""
+		if u.tagCursor > 0 {
+			u.tag = u.tags[u.tagCursor-1]
+		}
+		u.cursor = 0
+		u.pickTag()
+		return
+	}
+	u.cursor = max(0, min(u.cursor+n, len(u.tasks)-1))
+}
+
+func (u *tui) pageSize() int {
+	_, h := u.size()
+	return max(h/2, 1)
+}
+
+// ask reads a line on the status line, starting from text, and passes it to fn. When fn fails
+// the line is shown again with the error.
+func (u *tui) ask(label, text string, fn func(string) error) {
+	u.prompt, u.input, u.onInput = label, []rune(text), fn
+}
+
+func (u *tui) edit(key string) {
+	switch key {
+	case "esc", "ctrl-c":
+		u.prompt = ""
+	case "enter":
+		label, text := u.prompt, string(u.input)
+		u.prompt = ""
+		if err := u.onInput(strings.TrimSpace(text)); err != nil {
+			u.ask(label, text, u.onInput)
+			u.message = err.Error()
+		}
+	case "backspace":
+		if len(u.input) > 0 {
+			u.input = u.input[:len(u.input)-1]
+		}
+	case "ctrl-u":
+		u.input = nil
+	default:
+		if utf8.RuneCountInString(key) == 1 {
+			u.input = append(u.input, []rune(key)...)
+		}
+	}
+}
+
+func (u *tui) setFilter(text string) error {
+	words, err := splitLine(text)
+	if err != nil {
+		return err
+	}
+	f, err := parseFilter(cliArgs(words))
+	if err != nil {
+		return err
+	}
+	u.filter, u.filterText = f, text
+	u.cursor = 0
+	u.reload()
+	return nil
+}
+
+func (u *tui) setSort(text string) error {
+	if err := sortTasks(nil, text); err != nil {
+		return err
+	}
+	u.sort = text
+	u.reload()
+	return nil
+}
+
+func (u *tui) add(text string) error {
+	if text == "" {
+		return nil
+	}
+	ti, err := parseLine(text)
+	if err != nil {
+		return err
+	}
+	t, err := newTask(ti)
+	if err != nil {
+		return err
+	}
+	if _, err := u.c.repo.AddTask(t); err != nil {
+		return err
+	}
+	linked, err := u.c.linkMentions(t, t.Desc)
+	u.message = fmt.Sprintf("Added task %d.%s", t.ShortID, linkedNote(linked))
+	u.reload()
+	u.selectID(t.ID)
+	return err
+}
+
+func (u *tui) modify(text string) error {
+	t := u.current()
+	if text == "" || t == nil {
+		return nil
+	}
+	ti, err := parseModLine(text)
+	if err != nil {
+		return err
+	}
+	// changes are applied to the task read in full, which is read again either way
+	defer func() { u.detail = nil }()
+	changes := applyMods(t, ti)
+	if err := checkReminders(t); err != nil {
+		return err
+	}
+	if err := u.c.saveModified([]*types.Task{t}, ti); err != nil {
+		return err
+	}
+	var linked []*types.Task
+	if ti.desc != nil {
+		linked, err = u.c.linkMentions(t, t.Desc)
+	}
+	u.message = fmt.Sprintf("Task %d: %s.%s", t.ShortID, strings.Join(changes, "; "), linkedNote(linked))
+	u.reload()
+	return err
+}
+
+func (u *tui) note(text string) error {
+	t := u.selected()
+	if text == "" || t == nil {
+		return nil
+	}
+	if err := u.c.repo.AddNote(t.ID, text); err != nil {
+		return err
+	}
+	linked, err := u.c.linkMentions(t, text)
+	u.message = fmt.Sprintf("Noted task %d.%s", t.ShortID, linkedNote(linked))
+	u.reload()
+	return err
+}
+
+// linkedNote describes the tasks linked by mentions, for the status line
+func linkedNote(linked []*types.Task) string {
+	s := ""
+	for _, o := range linked {
+		s += fmt.Sprintf(" Linked to task %d.", o.ShortID)
+	}
+	return s
+}
+
+// toggle marks the selected task done, or open again
+func (u *tui) toggle() {
+	t := u.selected()
+	if t == nil {
+		return
+	}
+	if err := u.c.repo.UpdateStatus(t.ID, !t.Finished); err != nil {
+		u.message = err.Error()
+		return
+	}
+	if t.Finished {
+		u.message = fmt.Sprintf("Reverted task %d '%s' to incomplete.", t.ShortID, t.Desc)
+	} else {
+		u.message = fmt.Sprintf("Finished task %d '%s'.", t.ShortID, t.Desc)
+	}
+	u.reload()
+}
+
+func (u *tui) size() (int, int) {
+	w, h, ok := terminalSize(os.Stdout)
+	if !ok {
+		return 80, 24
+	}
+	return w, h
+}
+
+// fit cuts or pads s to exactly w cells
+func fit(s string, w int) string {
+	s = truncateWidth(s, max(w, 0))
+	return s + strings.Repeat(" ", max(w-displayWidth(s), 0))
+}
+
+// rule fits a pane title to w cells, drawn as a line
+func rule(title string, w int) string {
+	s := truncateWidth("─ "+title+" ", max(w, 0))
+	return s + strings.Repeat("─", max(w-displayWidth(s), 0))
+}
+
+// draw redraws the whole screen: a title, the tag sidebar and task list, the details and notes
+// of the selected task when there's room, and the status line
+func (u *tui) draw() {
+	w, h := u.size()
+	var lines []string
+	title := fmt.Sprintf(" gt  %d of %d tasks", len(u.tasks), len(u.all))
+	if u.filterText != "" {
+		title += "  filter: " + u.filterText
+	}
+	if u.sort != "" {
+		title += "  sort: " + u.sort
+	}
+	if u.tag != "" {
+		title += "  tag: " + u.tag
+	}
+	lines = append(lines, paint("reverse", fit(title, w)))
+
+	body := max(h-2, 1)
+	listH, paneH := body, 0
+	if body >= 12 {
+		listH = body * 3 / 5
+		paneH = body - listH - 1
+	}
+	sideW := 0
+	if u.sidebar && w >= 60 {
+		sideW = 20
+	}
+	list := u.listLines(w-sideW-min(sideW, 1), listH)
+	if sideW > 0 {
+		side := u.tagLines(sideW, listH)
+		for i := range list {
+			list[i] = side[i] + "│" + list[i]
+		}
+	}
+	lines = append(lines, list...)
+
+	if paneH > 0 {
+		leftW := w / 2
+		lines = append(lines, rule("Details", leftW)+"┬"+rule("Notes", w-leftW-1))
+		t := u.current()
+		details, notes := u.detailLines(t, leftW), u.noteLines(t, w-leftW-1)
+		for i := 0; i < paneH; i++ {
+			var l, r string
+			if i < len(details) {
+				l = details[i]
+			}
+			if i < len(notes) {
+				r = notes[i]
+			}
+			lines = append(lines, fit(l, leftW)+"│"+fit(r, w-leftW-1))
+		}
+	}
+
+	status := u.message
+	if u.prompt != "" {
+		status = u.prompt + string(u.input)
+		if u.message != "" {
+			status = u.message + "  " + status
+		}
+	} else if status == "" {
+		status = tuiHelp
+	}
+	lines = append(lines, fit(status, w))
+
+	var b strings.Builder
+	b.WriteString("\x1b[?25l")
+	for i, l := range lines[:min(len(lines), h)] {
+		fmt.Fprintf(&b, "\x1b[%d;1H%s\x1b[K", i+1, l)
+	}
+	if u.prompt != "" {
+		fmt.Fprintf(&b, "\x1b[%d;%dH\x1b[?25h", h, min(displayWidth(status)+1, w))
+	}
+	u.out.WriteString(b.String())
+	u.out.Flush()
+}
+
+// listLines returns the header and rows of the task list, h lines of w cells, scrolled to
+// show the cursor
+func (u *tui) listLines(w, h int) []string {
+	lines := make([]string, 0, h)
+	cols, _ := tableColumns("id,status,desc,priority,tags,due", false)
+	widths := make([]int, len(cols))
+	for i, col := range cols {
+		widths[i] = displayWidth(col.header)
+		for _, t := range u.tasks {
+			widths[i] = max(widths[i], displayWidth(col.cell(t)))
+		}
+	}
+	fitWidths(cols, widths, 2, w)
+	row := func(cell func(col column) string) string {
+		parts := make([]string, len(cols))
+		for i, col := range cols {
+			parts[i] = fit(cell(col), widths[i])
+		}
+		return fit(strings.Join(parts, "  "), w)
+	}
+	header := row(func(col column) string { return col.header })
+	if u.styles != nil {
+		header = paint(u.styles.theme.Header, header)
+	}
+	lines = append(lines, header)
+
+	rows := h - 1
+	if u.cursor < u.offset {
+		u.offset = u.cursor
+	}
+	if u.cursor >= u.offset+rows {
+		u.offset = u.cursor - rows + 1
+	}
+	u.offset = max(0, min(u.offset, len(u.tasks)-rows))
+	if len(u.tasks) == 0 {
+		lines = append(lines, fit(" No tasks match the filter.", w))
+	}
+	for i := u.offset; i < len(u.tasks) && len(lines) < h; i++ {
+		t := u.tasks[i]
+		line := row(func(col column) string { return strings.ReplaceAll(col.cell(t), "\n", " ") })
+		style := ""
+		if u.styles != nil {
+			style = u.styles.row(t)
+		}
+		if i == u.cursor && u.focus == focusList {
+			style += " reverse"
+		} else if i == u.cursor {
+			style += " underline"
+		}
+		lines = append(lines, paint(style, line))
+	}
+	for len(lines) < h {
+		lines = append(lines, fit("", w))
+	}
+	return lines
+}
+
+// tagLines returns the sidebar, h lines of w cells: all tasks, then each tag with its count
+func (u *tui) tagLines(w, h int) []string {
+	items := []string{fmt.Sprintf("All (%d)", len(u.all))}
+	for _, tg := range u.tags {
+		n := 0
+		for _, t := range u.all {
+			if slices.Contains(t.Tags, tg) {
+				n++
+			}
+		}
+		items = append(items, fmt.Sprintf("%s (%d)", tg, n))
+	}
+	rows := h - 1
+	if u.tagCursor < u.tagOffset {
+		u.tagOffset = u.tagCursor
+	}
+	if u.tagCursor >= u.tagOffset+rows {
+		u.tagOffset = u.tagCursor - rows + 1
+	}
+	lines := []string{paint("bold", fit(" Tags", w))}
+	for i := u.tagOffset; i < len(items) && len(lines) < h; i++ {
+		line := fit(" "+items[i], w)
+		switch {
+		case i == u.tagCursor && u.focus == focusTags:
+			line = paint("reverse", line)
+		case i == u.tagCursor:
+			line = paint("bold", line)
+		}
+		lines = append(lines, line)
+	}
+	for len(lines) < h {
+		lines = append(lines, fit("", w))
+	}
+	return lines
+}
+
+// detailLines describes a task in lines of at most w cells
+func (u *tui) detailLines(t *types.Task, w int) []string {
+	if t == nil {
+		return nil
+	}
+	lines := wrapWidth(fmt.Sprintf("%d %s %s", t.ShortID, formatCheck(t.Finished), t.Desc), w)
+	field := func(name, value string) {
+		lines = append(lines, hangWidth(fmt.Sprintf("%-10s", name), value, w)...)
+	}
+	field("Priority", priorities.Format(t.Priority))
+	if len(t.Tags) > 0 {
+		field("Tags", strings.Join(t.Tags, ", "))
+	}
+	if t.StartAt != nil {
+		field("Due", formatSpan(t.StartAt, t.EndAt, clockFmt))
+	}
+	if t.CompletedAt != nil {
+		field("Completed", formatDateTime(*t.CompletedAt))
+	}
+	for _, k := range sortedKeys(t.Attrs) {
+		field(k, t.Attrs[k])
+	}
+	for _, r := range t.Reminders {
+		field("Reminder", formatReminder(r, t))
+	}
+	if done, total := t.Progress(); total > 0 {
+		field("Checklist", fmt.Sprintf("%d/%d", done, total))
+		for _, item := range t.Checklist {
+			lines = append(lines, hangWidth("  "+formatCheck(item.Done)+" ", item.Text, w)...)
+		}
+	}
+	for _, l := range u.detailLink {
+		field("Link", l)
+	}
+	return lines
+}
+
+// noteLines lists the notes of a task in lines of at most w cells
+func (u *tui) noteLines(t *types.Task, w int) []string {
+	if t == nil {
+		return nil
+	}
+	if len(t.Notes) == 0 {
+		return []string{"No notes, n adds one."}
+	}
+	var lines []string
+	for _, n := range t.Notes {
+		lines = append(lines, hangWidth("- ", n, w)...)
+	}
+	return lines
+}
+
+// hangWidth wraps text to w cells after prefix, indenting the lines after the first to match
+func hangWidth(prefix, text string, w int) []string {
+	indent := displayWidth(prefix)
+	lines := wrapWidth(text, max(w-indent, 1))
+	for i := range lines {
+		if i == 0 {
+			lines[i] = prefix + lines[i]
+		} else {
+			lines[i] = strings.Repeat(" ", indent) + lines[i]
+		}
+	}
+	return lines
+}
@@ -0,0 +1,93 @@
+package cobra
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSplitKeys(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"jk", []string{"j", "k"}},
+		{"\x1b[A\x1b[B\x1bOC", []string{"up", "down", "right"}},
+		{"\x1b[5~\x1b[6~\x1b[3~", []string{"pgup", "pgdn", "delete"}},
+		{"\x1b", []string{"esc"}},
+		{"\x1bx", []string{"esc", "x"}},
+		{"\x1b[1;5A", nil},
+		{"日本\r\x7f\t\x03\x15", []string{"日", "本", "enter", "backspace", "tab", "ctrl-c", "ctrl-u"}},
+		{"a\x01b", []string{"a", "b"}},
+	}
+	for _, tt := range tests {
+		if got := splitKeys(tt.in); strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("splitKeys(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestTuiFilter(t *testing.T) {
+	u := &tui{c: testCmd(t, workTasks()...)}
+	tests := []struct {
+		text string
+		ids  []int
+	}{
+		{"+work", []int{1, 3}},
+		{"+home or desc~^Plan", []int{2, 3}},
+		{`"+home or desc~^Plan"`, []int{2, 3}}, // quoted as one argument, as on the command line
+		{"not +work", []int{2}},
+	}
+	for _, tt := range tests {
+		if err := u.setFilter(tt.text); err != nil {
+			t.Errorf("setFilter(%q) error: %v", tt.text, err)
+			continue
+		}
+		var ids []int
+		for _, task := range u.all {
+			ids = append(ids, task.ShortID)
+		}
+		if !slices.Equal(ids, tt.ids) {
+			t.Errorf("setFilter(%q) lists %v, want %v", tt.text, ids, tt.ids)
+		}
+	}
+	// an escaped word is no term, as on the command line
+	for _, text := range []string{`\+work`, `"+work`, "+work or"} {
+		if err := u.setFilter(text); err == nil {
+			t.Errorf("setFilter(%q) succeeded, want error", text)
+		}
+	}
+}
+
+func TestTuiModify(t *testing.T) {
+	u := &tui{c: testCmd(t, workTasks()...)}
+	u.reload()
+	tests := []struct {
+		text, desc string
+		tags       []string
+		prio       int
+	}{
+		{"%1 +urgent", "Write report", []string{"WORK", "URGENT"}, 1},
+		{`\+1 reviewer`, "+1 reviewer", []string{"WORK", "URGENT"}, 1},
+		{`"Call +1 555" %2`, "Call +1 555", []string{"WORK", "URGENT"}, 2},
+	}
+	for _, tt := range tests {
+		if err := u.modify(tt.text); err != nil {
+			t.Errorf("modify(%q) error: %v", tt.text, err)
+			continue
+		}
+		task, err := u.c.repo.GetTask(u.selected().ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if task.Desc != tt.desc || !slices.Equal(task.Tags, tt.tags) || task.Priority != tt.prio {
+			t.Errorf("modify(%q) gave %q %v %d, want %q %v %d", tt.text, task.Desc, task.Tags, task.Priority,
+				tt.desc, tt.tags, tt.prio)
+		}
+	}
+	var pe *ParseError
+	if err := u.modify("%9"); !errors.As(err, &pe) || pe.Input != "%9" {
+		t.Errorf("modify(%%9) error = %#v, want a ParseError located in the input", err)
+	}
+}
@@ -2,6 +2,9 @@ package config
 
 import (
 	"fmt"
+	"regexp"
+	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -15,9 +18,120 @@ type SQLite struct {
 	Database string `mapstructure:"SQLITE_DB"`
 }
 
+const (
+	UDAString   = "string"
+	UDANumber   = "number"
+	UDADate     = "date"
+	UDADuration = "duration"
+	UDAEnum     = "enum"
+)
+
+var udaName = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// reservedNames are 'name:value' keys with built-in meaning that attributes can't use
+var reservedNames = []string{"remind"}
+
+// UDA declares a user-defined attribute that can be set on tasks with 'name:value'
+type UDA struct {
+	Type    string   `mapstructure:"type"`
+	Default string   `mapstructure:"default"`
+	Values  []string `mapstructure:"values"` // allowed values for enum attributes
+}
+
+// Attachments configures how files attached to tasks are stored and opened
+type Attachments struct {
+	MaxSize int64  `mapstructure:"max_size"` // largest file in bytes that may be attached
+	Copy    bool   `mapstructure:"copy"`     // copy files into the database rather than storing their path
+	Opener  string `mapstructure:"opener"`   // command used by 'gt open', defaults to the system opener
+}
+
+const (
+	NotifierNotifySend = "notify-send"
+	NotifierBell       = "bell"
+	NotifierFile       = "file"
+	NotifierExec       = "exec"
+)
+
+// Notifier configures one way 'gt daemon' delivers reminders
+type Notifier struct {
+	Type    string   `mapstructure:"type"`
+	Path    string   `mapstructure:"path"`    // log file for the file notifier
+	Command string   `mapstructure:"command"` // program run by the exec notifier
+	Args    []string `mapstructure:"args"`
+}
+
+type Reminders struct {
+	Interval  time.Duration `mapstructure:"interval"` // how often the daemon checks for due reminders
+	Snooze    time.Duration `mapstructure:"snooze"`   // default for 'gt snooze'
+	Notifiers []Notifier    `mapstructure:"notifiers"`
+}
+
+// TemplateTask is one task of a template. Times are offsets from the anchor date given when the
+// template is applied, and '{{name}}' placeholders in the description are replaced by variables.
+type TemplateTask struct {
+	Desc     string            `mapstructure:"desc" json:"desc"`
+	Priority string            `mapstructure:"priority" json:"priority,omitempty"`
+	Tags     []string          `mapstructure:"tags" json:"tags,omitempty"`
+	Attrs    map[string]string `mapstructure:"attrs" json:"attrs,omitempty"`
+	Start    string            `mapstructure:"start" json:"start,omitempty"`       // offset from the anchor, e.g. 1d10h
+	Duration string            `mapstructure:"duration" json:"duration,omitempty"` // end time relative to start, e.g. 2h
+}
+
+type Checklist struct {
+	AutoFinish bool `mapstructure:"auto_finish"` // finish a task once every checklist item is checked
+}
+
+// Bulk configures commands that change several tasks at once
+type Bulk struct {
+	Confirm       int `mapstructure:"confirm"`        // mod, done and undo ask first when changing more tasks than this
+	ConfirmDelete int `mapstructure:"confirm_delete"` // delete asks first when removing more tasks than this
+}
+
+const (
+	Clock12h = "12h"
+	Clock24h = "24h"
+)
+
+// Display configures how tasks are shown
+type Display struct {
+	Clock   string            `mapstructure:"clock"`   // 12h (3:04PM) or 24h (15:04) times in output
+	Formats map[string]string `mapstructure:"formats"` // named templates for --format
+	// Pager shows tables longer than the terminal; empty uses $PAGER or 'less -FRX', 'off' disables it
+	Pager         string `mapstructure:"pager"`
+	RelativeDates bool   `mapstructure:"relative_dates"` // times in tables as 'in 2d' rather than dates
+	Theme         string `mapstructure:"theme"`          // a preset or one of themes, see Theme
+}
+
+const (
+	DateOrderDMY = "dmy"
+	DateOrderMDY = "mdy"
+	DateOrderYMD = "ymd"
+)
+
+// Locale configures how dates are read and written
+type Locale struct {
+	DateOrders    []string `mapstructure:"date_orders"`    // numeric date orders accepted, the first wins when ambiguous
+	WeekStart     string   `mapstructure:"week_start"`     // first day of the week, e.g. monday
+	DateFormat    string   `mapstructure:"date_format"`    // Go layout used to show dates, e.g. "Mon, 02 Jan 2006"
+	Months        []string `mapstructure:"months"`         // January to December
+	ShortMonths   []string `mapstructure:"short_months"`   // defaults to the first three letters of months
+	Weekdays      []string `mapstructure:"weekdays"`       // Sunday to Saturday
+	ShortWeekdays []string `mapstructure:"short_weekdays"` // defaults to the first three letters of weekdays
+}
+
 type Config struct {
-	Env    string `mapstructure:"APP_ENV"`
-	SQLite SQLite
+	Env         string `mapstructure:"APP_ENV"`
+	SQLite      SQLite
+	UDA         map[string]UDA            `mapstructure:"uda"`
+	Attachments Attachments               `mapstructure:"attachments"`
+	Priority    Priority                  `mapstructure:"priority"`
+	Reminders   Reminders                 `mapstructure:"reminders"`
+	Templates   map[string][]TemplateTask `mapstructure:"templates"`
+	Checklist   Checklist                 `mapstructure:"checklist"`
+	Display     Display                   `mapstructure:"display"`
+	Locale      Locale                    `mapstructure:"locale"`
+	Bulk        Bulk                      `mapstructure:"bulk"`
+	Themes      map[string]Theme          `mapstructure:"themes"`
 }
 
 func NewConfig(folder string) (*Config, error) {
@@ -25,6 +139,19 @@ func NewConfig(folder string) (*Config, error) {
 
 	viper.SetDefault("APP_ENV", EnvLocal)
 	viper.SetDefault("SQLITE_DB", "sqllite.db")
+	viper.SetDefault("attachments.max_size", 10<<20)
+	viper.SetDefault("priority.min", DefaultPriority.Min)
+	viper.SetDefault("priority.max", DefaultPriority.Max)
+	viper.SetDefault("priority.highest", DefaultPriority.Highest)
+	viper.SetDefault("reminders.interval", time.Minute)
+	viper.SetDefault("reminders.snooze", 10*time.Minute)
+	viper.SetDefault("display.clock", Clock12h)
+	viper.SetDefault("display.theme", "default")
+	viper.SetDefault("locale.date_orders", []string{DateOrderDMY})
+	viper.SetDefault("locale.week_start", "sunday")
+	viper.SetDefault("locale.date_format", "Mon, 02 Jan 2006")
+	viper.SetDefault("bulk.confirm", 3)
+	viper.SetDefault("bulk.confirm_delete", 0)
 
 	viper.SetConfigFile(".env")
 	viper.AutomaticEnv() // Automatically override with environment variables
@@ -65,5 +192,116 @@ func NewConfig(folder string) (*Config, error) {
 		return nil, err
 	}
 
+	if err := validateUDA(cfg.UDA); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Priority.validate(); err != nil {
+		return nil, err
+	}
+
+	if err := validateNotifiers(cfg.Reminders.Notifiers); err != nil {
+		return nil, err
+	}
+
+	if c := cfg.Display.Clock; c != Clock12h && c != Clock24h {
+		return nil, fmt.Errorf("invalid clock style: %s (expected %s or %s)", c, Clock12h, Clock24h)
+	}
+
+	if err := cfg.Locale.validate(); err != nil {
+		return nil, err
+	}
+
+	for name, t := range cfg.Themes {
+		if err := t.validate(); err != nil {
+			return nil, fmt.Errorf("theme %s: %w", name, err)
+		}
+	}
+	if _, err := cfg.Theme(); err != nil {
+		return nil, err
+	}
+
+	if cfg.Bulk.Confirm < 0 || cfg.Bulk.ConfirmDelete < 0 {
+		return nil, fmt.Errorf("bulk confirmation thresholds must not be negative")
+	}
+
 	return cfg, nil
 }
+
+func validateUDA(udas map[string]UDA) error {
+	for name, u := range udas {
+		if !udaName.MatchString(name) {
+			return fmt.Errorf("invalid attribute name: %s", name)
+		}
+		for _, r := range reservedNames {
+			if name == r {
+				return fmt.Errorf("attribute name is reserved: %s", name)
+			}
+		}
+		switch u.Type {
+		case UDAString, UDANumber, UDADate, UDADuration:
+		case UDAEnum:
+			if len(u.Values) == 0 {
+				return fmt.Errorf("enum attribute %s requires values", name)
+			}
+		default:
+			return fmt.Errorf("invalid type for attribute %s: %s", name, u.Type)
+		}
+	}
+	return nil
+}
+
+func validateNotifiers(notifiers []Notifier) error {
+	for _, n := range notifiers {
+		switch n.Type {
+		case NotifierNotifySend, NotifierBell:
+		case NotifierFile:
+			if n.Path == "" {
+				return fmt.Errorf("file notifier requires a path")
+			}
+		case NotifierExec:
+			if n.Command == "" {
+				return fmt.Errorf("exec notifier requires a command")
+			}
+		default:
+			return fmt.Errorf("invalid notifier type: %s", n.Type)
+		}
+	}
+	return nil
+}
+
+func (l Locale) validate() error {
+	for _, o := range l.DateOrders {
+		switch o {
+		case DateOrderDMY, DateOrderMDY, DateOrderYMD:
+		default:
+			return fmt.Errorf("invalid date order: %s (expected dmy, mdy or ymd)", o)
+		}
+	}
+	if _, err := l.FirstWeekday(); err != nil {
+		return err
+	}
+	if (len(l.ShortMonths) > 0 && len(l.Months) == 0) || (len(l.ShortWeekdays) > 0 && len(l.Weekdays) == 0) {
+		return fmt.Errorf("locale short names require the full names to be set")
+	}
+	for _, n := range []struct {
+		key   string
+		names []string
+		count int
+	}{{"months", l.Months, 12}, {"short_months", l.ShortMonths, 12}, {"weekdays", l.Weekdays, 7}, {"short_weekdays", l.ShortWeekdays, 7}} {
+		if len(n.names) != 0 && len(n.names) != n.count {
+			return fmt.Errorf("locale %s requires %d names, got %d", n.key, n.count, len(n.names))
+		}
+	}
+	return nil
+}
+
+// FirstWeekday returns the day the week starts on
+func (l Locale) FirstWeekday() (time.Weekday, error) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.EqualFold(l.WeekStart, d.String()) || strings.EqualFold(l.WeekStart, d.String()[:3]) {
+			return d, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid week start: %s", l.WeekStart)
+}
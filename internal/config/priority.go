@@ -0,0 +1,186 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	PriorityHighestMin = "min" // the lowest number is the most important, e.g. 1 of 1-5
+	PriorityHighestMax = "max" // the highest number is the most important, e.g. 10 of 1-10
+)
+
+// DefaultPriority is the scheme used when none is configured, and the fixed one stored priorities
+// used before the scheme could be configured
+var DefaultPriority = Priority{Min: 1, Max: 5, Highest: PriorityHighestMin}
+
+// Priority defines the priority scheme, either a numeric range or a list of named levels.
+// Priorities are stored as numbers; with named levels, level i (from 1) of the list is stored as i.
+//
+// Example configs:
+//
+//	priority:            priority:
+//	  min: 1               levels: [P0, P1, P2, P3]
+//	  max: 10              default: P2
+//	  highest: max
+type Priority struct {
+	Min     int      `mapstructure:"min"`
+	Max     int      `mapstructure:"max"`
+	Highest string   `mapstructure:"highest"` // "min" or "max", ignored with named levels
+	Levels  []string `mapstructure:"levels"`  // named levels, most important first
+	Default string   `mapstructure:"default"` // defaults to the middle level
+}
+
+func (p Priority) validate() error {
+	if len(p.Levels) > 0 {
+		seen := make(map[string]bool)
+		for _, l := range p.Levels {
+			k := strings.ToLower(l)
+			if l == "" || seen[k] {
+				return fmt.Errorf("priority levels must be unique and non-empty: %s", strings.Join(p.Levels, ", "))
+			}
+			// the signature stored with the database separates levels with commas
+			if strings.Contains(l, ",") {
+				return fmt.Errorf("priority levels can't contain ',': %s", l)
+			}
+			seen[k] = true
+		}
+	} else {
+		if p.Min >= p.Max {
+			return fmt.Errorf("priority min must be less than max: %d-%d", p.Min, p.Max)
+		}
+		if p.Highest != PriorityHighestMin && p.Highest != PriorityHighestMax {
+			return fmt.Errorf("priority highest must be '%s' or '%s': %s", PriorityHighestMin, PriorityHighestMax, p.Highest)
+		}
+	}
+	if p.Default != "" {
+		if _, err := p.Parse(p.Default); err != nil {
+			return fmt.Errorf("invalid default priority: %w", err)
+		}
+	}
+	return nil
+}
+
+// Count returns the number of priority levels in the scheme
+func (p Priority) Count() int {
+	if len(p.Levels) > 0 {
+		return len(p.Levels)
+	}
+	return p.Max - p.Min + 1
+}
+
+// Rank returns the importance of a stored priority, 0 being the most important
+func (p Priority) Rank(v int) int {
+	if len(p.Levels) > 0 {
+		return v - 1
+	}
+	if p.Highest == PriorityHighestMax {
+		return p.Max - v
+	}
+	return v - p.Min
+}
+
+// FromRank is the inverse of Rank
+func (p Priority) FromRank(r int) int {
+	if len(p.Levels) > 0 {
+		return r + 1
+	}
+	if p.Highest == PriorityHighestMax {
+		return p.Max - r
+	}
+	return p.Min + r
+}
+
+// Valid reports whether v is a stored priority within the scheme
+func (p Priority) Valid(v int) bool {
+	r := p.Rank(v)
+	return r >= 0 && r < p.Count()
+}
+
+// Parse converts user input, a number or a level name, to a stored priority
+func (p Priority) Parse(s string) (int, error) {
+	if len(p.Levels) > 0 {
+		for i, l := range p.Levels {
+			if strings.EqualFold(l, s) {
+				return i + 1, nil
+			}
+		}
+		return 0, fmt.Errorf("priority must be one of %s (most important first): %s", strings.Join(p.Levels, ", "), s)
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || !p.Valid(v) {
+		return 0, fmt.Errorf("priority must be a number from %s: %s", p.describeRange(), s)
+	}
+	return v, nil
+}
+
+// Format returns the name of a stored priority as it's displayed and entered
+func (p Priority) Format(v int) string {
+	if len(p.Levels) > 0 && p.Valid(v) {
+		return p.Levels[v-1]
+	}
+	return strconv.Itoa(v)
+}
+
+// DefaultValue returns the stored priority given to tasks added without one
+func (p Priority) DefaultValue() int {
+	if p.Default != "" {
+		if v, err := p.Parse(p.Default); err == nil {
+			return v
+		}
+	}
+	return p.FromRank((p.Count() - 1) / 2)
+}
+
+// Convert maps a stored priority from scheme old to the level at the same relative importance in p
+func (p Priority) Convert(old Priority, v int) int {
+	r := old.Rank(v)
+	if r < 0 {
+		r = 0
+	} else if r >= old.Count() {
+		r = old.Count() - 1
+	}
+	if old.Count() == 1 {
+		return p.FromRank(0)
+	}
+	// round to the nearest level
+	n := (r*(p.Count()-1)*2 + old.Count() - 1) / ((old.Count() - 1) * 2)
+	return p.FromRank(n)
+}
+
+// Signature identifies the levels of the scheme; schemes with equal signatures store priorities alike
+func (p Priority) Signature() string {
+	if len(p.Levels) > 0 {
+		return "levels:" + strings.Join(p.Levels, ",")
+	}
+	return fmt.Sprintf("range:%d-%d:%s", p.Min, p.Max, p.Highest)
+}
+
+// ParseSignature reconstructs the scheme identified by a signature
+func ParseSignature(sig string) (Priority, error) {
+	if l, ok := strings.CutPrefix(sig, "levels:"); ok {
+		return Priority{Levels: strings.Split(l, ",")}, nil
+	}
+	var p Priority
+	_, err := fmt.Sscanf(sig, "range:%d-%d:%s", &p.Min, &p.Max, &p.Highest)
+	if err != nil {
+		return p, fmt.Errorf("invalid priority scheme signature: %s", sig)
+	}
+	return p, p.validate()
+}
+
+// Describe summarizes the scheme for help and error messages
+func (p Priority) Describe() string {
+	if len(p.Levels) > 0 {
+		return strings.Join(p.Levels, ", ") + " (most important first)"
+	}
+	return p.describeRange()
+}
+
+func (p Priority) describeRange() string {
+	if p.Highest == PriorityHighestMax {
+		return fmt.Sprintf("%d to %d, where %d is highest", p.Min, p.Max, p.Max)
+	}
+	return fmt.Sprintf("%d to %d, where %d is highest", p.Min, p.Max, p.Min)
+}
@@ -0,0 +1,160 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"strconv"
+	"strings"
+)
+
+// Theme styles tasks in tables by their state, priority and tags. A style is a list of
+// attributes and colors separated by spaces, such as "bold red" or "underline 208 on-black".
+// Custom themes are declared under themes and start from the preset named by base.
+//
+// Example config:
+//
+//	display:
+//	  theme: mine
+//	themes:
+//	  mine:
+//	    base: colorblind
+//	    today: "bold 220"
+//	    priority:
+//	      highest: "bold #ff8800"
+//	      "2": "yellow"
+//	    tags:
+//	      work: blue
+type Theme struct {
+	Base     string            `mapstructure:"base"`     // preset the theme starts from
+	Overdue  string            `mapstructure:"overdue"`  // open tasks whose time has passed
+	Today    string            `mapstructure:"today"`    // open tasks due today
+	Active   string            `mapstructure:"active"`   // open tasks whose time span includes now
+	Blocked  string            `mapstructure:"blocked"`  // open tasks following up on an open task
+	Done     string            `mapstructure:"done"`     // finished tasks
+	Header   string            `mapstructure:"header"`   // table headers and group headings
+	Priority map[string]string `mapstructure:"priority"` // by priority as displayed, "highest" or "lowest"
+	Tags     map[string]string `mapstructure:"tags"`     // by tag
+}
+
+// ThemePresets are the themes available without declaring them
+var ThemePresets = map[string]Theme{
+	"default": {
+		Overdue: "bold red", Today: "yellow", Active: "bold green", Blocked: "dim", Done: "dim",
+		Header:   "bold",
+		Priority: map[string]string{"highest": "bold red", "lowest": "gray"},
+	},
+	// avoids telling states apart by red and green, and marks overdue tasks with more than color
+	"colorblind": {
+		Overdue: "bold underline 202", Today: "220", Active: "bold 33", Blocked: "dim italic", Done: "dim",
+		Header:   "bold",
+		Priority: map[string]string{"highest": "bold 208", "lowest": "dim"},
+	},
+	"mono": {
+		Overdue: "bold underline", Today: "bold", Active: "reverse", Blocked: "dim italic", Done: "dim",
+		Header:   "bold",
+		Priority: map[string]string{"highest": "bold"},
+	},
+	"none": {},
+}
+
+// Theme returns the theme named by display.theme, from the custom themes or the presets
+func (c *Config) Theme() (Theme, error) {
+	name := c.Display.Theme
+	t, ok := c.Themes[name]
+	if !ok {
+		if p, ok := ThemePresets[name]; ok {
+			return p, nil
+		}
+		return Theme{}, fmt.Errorf("unknown theme: %s (expected a preset or one of themes)", name)
+	}
+	base, ok := ThemePresets[t.Base]
+	if t.Base != "" && !ok {
+		return Theme{}, fmt.Errorf("theme %s: unknown base theme: %s", name, t.Base)
+	}
+	for _, f := range []struct{ v, base *string }{
+		{&t.Overdue, &base.Overdue}, {&t.Today, &base.Today}, {&t.Active, &base.Active},
+		{&t.Blocked, &base.Blocked}, {&t.Done, &base.Done}, {&t.Header, &base.Header},
+	} {
+		if *f.v == "" {
+			*f.v = *f.base
+		}
+	}
+	priority := maps.Clone(base.Priority)
+	if priority == nil {
+		priority = map[string]string{}
+	}
+	maps.Copy(priority, t.Priority)
+	t.Priority = priority
+	return t, nil
+}
+
+func (t Theme) validate() error {
+	styles := map[string]string{
+		"overdue": t.Overdue, "today": t.Today, "active": t.Active, "blocked": t.Blocked, "done": t.Done, "header": t.Header,
+	}
+	for k, s := range t.Priority {
+		styles["priority "+k] = s
+	}
+	for k, s := range t.Tags {
+		styles["tag "+k] = s
+	}
+	for k, s := range styles {
+		if _, err := StyleCodes(s); err != nil {
+			return fmt.Errorf("invalid %s style: %w", k, err)
+		}
+	}
+	return nil
+}
+
+var styleAttrs = map[string]string{
+	"bold": "1", "dim": "2", "italic": "3", "underline": "4", "reverse": "7", "strike": "9",
+}
+
+var styleColors = map[string]int{
+	"black": 0, "red": 1, "green": 2, "yellow": 3, "blue": 4, "magenta": 5, "cyan": 6, "white": 7,
+}
+
+// StyleCodes returns the ANSI SGR parameters of a style, e.g. "1;31" for "bold red". Colors are
+// named (with bright- variants, and gray), a 256-color number or #rrggbb; on- makes a color the
+// background.
+func StyleCodes(style string) (string, error) {
+	var codes []string
+	for _, word := range strings.Fields(strings.ToLower(style)) {
+		if code, ok := styleAttrs[word]; ok {
+			codes = append(codes, code)
+			continue
+		}
+		name, bg := strings.CutPrefix(word, "on-")
+		code, err := colorCode(name, bg)
+		if err != nil {
+			return "", err
+		}
+		codes = append(codes, code)
+	}
+	return strings.Join(codes, ";"), nil
+}
+
+func colorCode(name string, bg bool) (string, error) {
+	base := 30
+	if bg {
+		base = 40
+	}
+	if name == "gray" || name == "grey" {
+		return strconv.Itoa(base + 60), nil
+	}
+	if c, ok := styleColors[strings.TrimPrefix(name, "bright-")]; ok {
+		if strings.HasPrefix(name, "bright-") {
+			c += 60
+		}
+		return strconv.Itoa(base + c), nil
+	}
+	if n, err := strconv.Atoi(name); err == nil && n >= 0 && n <= 255 {
+		return fmt.Sprintf("%d;5;%d", base+8, n), nil
+	}
+	if hex, ok := strings.CutPrefix(name, "#"); ok && len(hex) == 6 {
+		if v, err := strconv.ParseUint(hex, 16, 32); err == nil {
+			return fmt.Sprintf("%d;2;%d;%d;%d", base+8, v>>16, v>>8&0xff, v&0xff), nil
+		}
+	}
+	return "", fmt.Errorf("unknown style %q (expected bold, dim, italic, underline, reverse, strike, a color such as red, bright-red or gray, a number from 0 to 255 or #rrggbb)", name)
+}
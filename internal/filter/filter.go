@@ -0,0 +1,116 @@
+// Package filter parses filter expressions that select tasks, such as '+work and %<3 or not status:done'
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Expr is a node of a parsed filter expression
+// String returns the node in a fully parenthesized form, e.g. "(and +WORK (not status:done))"
+type Expr interface {
+	String() string
+}
+
+// Op compares a task property with a value
+type Op string
+
+const (
+	OpEq Op = "="
+	OpNe Op = "!="
+	OpLt Op = "<"
+	OpLe Op = "<="
+	OpGt Op = ">"
+	OpGe Op = ">="
+)
+
+// And matches tasks matched by both L and R
+type And struct{ L, R Expr }
+
+// Or matches tasks matched by either L or R
+type Or struct{ L, R Expr }
+
+// Not matches tasks not matched by X
+type Not struct{ X Expr }
+
+// Tag matches tasks with the tag, written '+tag'; '-tag' is parsed as Not{Tag}
+type Tag struct{ Name string }
+
+// Priority compares the stored priority of tasks, written '%2' or '%>=2'
+type Priority struct {
+	Op    Op
+	Value int
+}
+
+// When selects how Time compares a task's start with a day
+type When string
+
+const (
+	Before When = "before" // starts before the day
+	On     When = "on"     // starts during the day
+	By     When = "by"     // starts before the end of the day
+	After  When = "after"  // starts after the day
+)
+
+// Time compares the start of tasks with a day, written '@ before fri'
+type Time struct {
+	When When
+	Day  time.Time // any time during the day
+}
+
+// Status matches open or finished tasks, written 'status:open' or 'status:done'
+type Status struct{ Done bool }
+
+// Desc matches tasks whose description matches a regular expression, written 'desc~regex'
+type Desc struct{ Pattern string }
+
+// Has matches tasks with at least one of something, written 'has:notes'
+type Has struct{ Field string }
+
+// Fields accepted by 'has:'
+const (
+	HasNotes       = "notes"
+	HasTags        = "tags"
+	HasAttachments = "attachments"
+	HasReminders   = "reminders"
+	HasChecklist   = "checklist"
+	HasLinks       = "links"
+	HasTime        = "time"
+)
+
+var hasFields = []string{HasNotes, HasTags, HasAttachments, HasReminders, HasChecklist, HasLinks, HasTime}
+
+// Attr matches tasks with a user-defined attribute set to a value, written 'name:value'
+type Attr struct{ Name, Value string }
+
+func (e And) String() string      { return fmt.Sprintf("(and %s %s)", e.L, e.R) }
+func (e Or) String() string       { return fmt.Sprintf("(or %s %s)", e.L, e.R) }
+func (e Not) String() string      { return fmt.Sprintf("(not %s)", e.X) }
+func (e Tag) String() string      { return "+" + e.Name }
+func (e Priority) String() string { return "%" + string(e.Op) + strconv.Itoa(e.Value) }
+func (e Time) String() string     { return fmt.Sprintf("@%s:%s", e.When, e.Day.Format(time.DateOnly)) }
+func (e Status) String() string {
+	if e.Done {
+		return "status:done"
+	}
+	return "status:open"
+}
+func (e Desc) String() string { return "desc~" + e.Pattern }
+func (e Has) String() string  { return "has:" + e.Field }
+func (e Attr) String() string { return e.Name + ":" + e.Value }
+
+// All combines exprs with 'and', skipping nil ones; it returns nil, matching every task, when all are nil
+func All(exprs ...Expr) Expr {
+	var e Expr
+	for _, x := range exprs {
+		switch {
+		case x == nil:
+		case e == nil:
+			e = x
+		default:
+			e = And{e, x}
+		}
+	}
+	return e
+}
@@ -0,0 +1,172 @@
+package filter
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// stubResolver accepts numeric priorities, 'today' and 'next fri' as dates, and any attribute
+type stubResolver struct{}
+
+var today = time.Date(2024, time.October, 14, 23, 59, 0, 0, time.UTC)
+
+func (stubResolver) Priority(s string) (int, error) {
+	v, err := strconv.Atoi(s)
+	if err != nil || v < 1 || v > 5 {
+		return 0, fmt.Errorf("priority must be a number from 1 to 5: %s", s)
+	}
+	return v, nil
+}
+
+func (stubResolver) Date(words []string) (time.Time, int, error) {
+	switch {
+	case len(words) >= 2 && words[0] == "next" && words[1] == "fri":
+		return today.AddDate(0, 0, 11), 2, nil
+	case words[0] == "today":
+		return today, 1, nil
+	}
+	return time.Time{}, 0, fmt.Errorf("invalid date format: %s", words[0])
+}
+
+func (stubResolver) Attr(name, value string) (string, error) {
+	return strings.ToLower(value), nil
+}
+
+func TestParsePrecedence(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"+work", "+work"},
+		{"-work", "(not +work)"},
+		{"+a +b", "(and +a +b)"},
+		{"+a and +b", "(and +a +b)"},
+		{"+a or +b", "(or +a +b)"},
+		// and binds tighter than or, whether written or implicit
+		{"+a or +b +c", "(or +a (and +b +c))"},
+		{"+a +b or +c", "(or (and +a +b) +c)"},
+		{"+a and +b or +c and +d", "(or (and +a +b) (and +c +d))"},
+		// not binds tighter than and
+		{"not +a +b", "(and (not +a) +b)"},
+		{"not +a or +b", "(or (not +a) +b)"},
+		{"not not +a", "(not (not +a))"},
+		// parentheses, separate or attached
+		{"( +a or +b ) +c", "(and (or +a +b) +c)"},
+		{"(+a or +b) +c", "(and (or +a +b) +c)"},
+		{"not (+a or +b)", "(not (or +a +b))"},
+		{"((+a))", "+a"},
+		{"+a (+b or (+c -d))", "(and +a (or +b (and +c (not +d))))"},
+		// operators are left associative
+		{"+a or +b or +c", "(or (or +a +b) +c)"},
+		{"+a +b +c", "(and (and +a +b) +c)"},
+		// keywords are case insensitive
+		{"+a OR NOT +b", "(or +a (not +b))"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			e, err := Parse(strings.Fields(tt.in), stubResolver{})
+			if err != nil {
+				t.Fatalf("Parse(%q) error: %v", tt.in, err)
+			}
+			if got := e.String(); got != tt.want {
+				t.Errorf("Parse(%q) = %s, want %s", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTerms(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"%3"}, "%=3"},
+		{[]string{"%>=2"}, "%>=2"},
+		{[]string{"%!=1"}, "%!=1"},
+		{[]string{"%<3", "+a"}, "(and %<3 +a)"},
+		{[]string{"@", "today"}, "@on:2024-10-14"},
+		{[]string{"@today"}, "@on:2024-10-14"},
+		{[]string{"@", "before", "next", "fri"}, "@before:2024-10-25"},
+		{[]string{"@before", "today", "+a"}, "(and @before:2024-10-14 +a)"},
+		{[]string{"@", "after", "today", "or", "@", "by", "today"}, "(or @after:2024-10-14 @by:2024-10-14)"},
+		{[]string{"status:open"}, "status:open"},
+		{[]string{"STATUS:Done"}, "status:done"},
+		{[]string{"desc~^fix"}, "desc~^fix"},
+		{[]string{"desc~(a|b)"}, "desc~(a|b)"},
+		{[]string{"(desc~(a|b))"}, "desc~(a|b)"},
+		{[]string{"desc~with\\s+space"}, "desc~with\\s+space"},
+		{[]string{"(+a or +b) and not status:done"}, "(and (or +a +b) (not status:done))"},
+		{[]string{"+a (+b", "or", "+c)"}, "(and +a (or +b +c))"},
+		{[]string{"has:notes"}, "has:notes"},
+		{[]string{"has:Links"}, "has:links"},
+		{[]string{"sprint:ABC"}, "sprint:abc"},
+	}
+	for _, tt := range tests {
+		e, err := Parse(tt.args, stubResolver{})
+		if err != nil {
+			t.Errorf("Parse(%q) error: %v", tt.args, err)
+			continue
+		}
+		if got := e.String(); got != tt.want {
+			t.Errorf("Parse(%q) = %s, want %s", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestParseEmpty(t *testing.T) {
+	e, err := Parse(nil, stubResolver{})
+	if e != nil || err != nil {
+		t.Errorf("Parse(nil) = %v, %v, want nil, nil", e, err)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		in    string
+		arg   int
+		token string
+		msg   string
+	}{
+		{"+a or", 0, "", "expected a term"},
+		{"+a and or +b", 2, "or", "expected a term before 'or'"},
+		{"( +a", 0, "(", "unclosed '('"},
+		{"+a )", 1, ")", "unmatched ')'"},
+		{"()", 0, ")", "expected a term before ')'"},
+		{"not", 0, "", "expected a term"},
+		{"+", 0, "+", "expected a tag name"},
+		{"%", 0, "%", "expected a priority"},
+		{"%>9", 0, "%>9", "priority must be a number from 1 to 5"},
+		{"@", 0, "@", "expected a date after '@'"},
+		{"@ before", 0, "@", "expected a date after '@'"},
+		{"@ before someday", 2, "someday", "expected a date after '@ before'"},
+		{"status:maybe", 0, "status:maybe", "unknown status"},
+		{"has:wings", 0, "has:wings", "unknown field"},
+		{"desc~(", 0, "desc~(", "invalid regular expression"},
+		{"+a banana", 1, "banana", "unknown term"},
+		{"sprint:", 0, "sprint:", "expected a value after 'sprint:'"},
+	}
+	for _, tt := range tests {
+		_, err := Parse(strings.Fields(tt.in), stubResolver{})
+		var fe *Error
+		if !errors.As(err, &fe) {
+			t.Errorf("Parse(%q) error = %v, want *Error", tt.in, err)
+			continue
+		}
+		if fe.Arg != tt.arg || fe.Token != tt.token || !strings.Contains(fe.Msg, tt.msg) {
+			t.Errorf("Parse(%q) error = {%d %q %q}, want {%d %q %q...}", tt.in, fe.Arg, fe.Token, fe.Msg, tt.arg, tt.token, tt.msg)
+		}
+	}
+}
+
+func TestAll(t *testing.T) {
+	if e := All(nil, nil); e != nil {
+		t.Errorf("All(nil, nil) = %v, want nil", e)
+	}
+	if got := All(nil, Tag{"a"}, Status{}).String(); got != "(and +a status:open)" {
+		t.Errorf("All = %s, want (and +a status:open)", got)
+	}
+}
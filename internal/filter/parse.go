@@ -0,0 +1,342 @@
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Resolver converts the values of terms that depend on configuration
+type Resolver interface {
+	// Priority converts a priority as entered by the user to its stored value
+	Priority(s string) (int, error)
+	// Date parses the longest date phrase at the start of words, returning the number of words used
+	Date(words []string) (time.Time, int, error)
+	// Attr validates and normalizes the value of a user-defined attribute
+	Attr(name, value string) (string, error)
+}
+
+// Error reports a problem with a filter at one of its tokens
+type Error struct {
+	Arg   int    // index of the argument holding the token
+	Token string // offending token, empty at the end of the filter
+	Msg   string
+}
+
+func (e *Error) Error() string {
+	if e.Token == "" {
+		return fmt.Sprintf("invalid filter: %s at end of filter", e.Msg)
+	}
+	return fmt.Sprintf("invalid filter: %s at '%s' (argument %d)", e.Msg, e.Token, e.Arg+1)
+}
+
+type tokenKind int
+
+const (
+	tokWord tokenKind = iota
+	tokLParen
+	tokRParen
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	arg  int
+}
+
+// tokenize splits arguments into words and parentheses. An argument may hold several words, so a
+// filter can be quoted as one argument to keep the shell from reading its parentheses. Parentheses
+// may be attached to words, e.g. '(+a' and '+b)'; those closing a 'desc~' pattern's own groups are
+// kept with the pattern.
+func tokenize(args []string) []token {
+	var toks []token
+	for i, arg := range args {
+		for _, w := range strings.Fields(arg) {
+			toks = appendWord(toks, w, i)
+		}
+	}
+	return toks
+}
+
+// appendWord appends the tokens of word a from argument i
+func appendWord(toks []token, a string, i int) []token {
+	for strings.HasPrefix(a, "(") {
+		toks = append(toks, token{tokLParen, "(", i})
+		a = a[1:]
+	}
+	closing := 0
+	for strings.HasSuffix(a, ")") && a != ")" && !keepsParen(a) {
+		closing++
+		a = a[:len(a)-1]
+	}
+	switch {
+	case a == ")":
+		closing++
+	case a != "":
+		toks = append(toks, token{tokWord, a, i})
+	}
+	for ; closing > 0; closing-- {
+		toks = append(toks, token{tokRParen, ")", i})
+	}
+	return toks
+}
+
+// keepsParen reports whether the trailing ')' of a word belongs to a 'desc~' pattern
+func keepsParen(w string) bool {
+	p, ok := strings.CutPrefix(w, "desc~")
+	return ok && strings.Count(p, "(") >= strings.Count(p, ")")
+}
+
+type parser struct {
+	toks []token
+	pos  int
+	r    Resolver
+}
+
+// Parse parses a filter from command line arguments. An empty filter returns nil, matching every task.
+//
+// Grammar, from loosest to tightest binding:
+//
+//	or    := and ('or' and)*
+//	and   := unary (['and'] unary)*     adjacent terms are joined with 'and'
+//	unary := 'not' unary | '(' or ')' | term
+//	term  := +tag | -tag | %[op]priority | @ [before|on|by|after] date
+//	       | status:open|done | desc~regex | has:field | name:value
+func Parse(args []string, r Resolver) (Expr, error) {
+	p := &parser{toks: tokenize(args), r: r}
+	if len(p.toks) == 0 {
+		return nil, nil
+	}
+	e, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if t, ok := p.peek(); ok {
+		if t.kind == tokRParen {
+			return nil, p.errorAt(t, "unmatched ')'")
+		}
+		return nil, p.errorAt(t, "unexpected token")
+	}
+	return e, nil
+}
+
+func (p *parser) peek() (token, bool) {
+	if p.pos >= len(p.toks) {
+		return token{}, false
+	}
+	return p.toks[p.pos], true
+}
+
+func (p *parser) errorAt(t token, format string, a ...any) error {
+	return &Error{Arg: t.arg, Token: t.text, Msg: fmt.Sprintf(format, a...)}
+}
+
+func (p *parser) errorAtEnd(format string, a ...any) error {
+	return &Error{Msg: fmt.Sprintf(format, a...)}
+}
+
+func isKeyword(t token, kw string) bool {
+	return t.kind == tokWord && strings.EqualFold(t.text, kw)
+}
+
+func (p *parser) or() (Expr, error) {
+	l, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t, ok := p.peek()
+		if !ok || !isKeyword(t, "or") {
+			return l, nil
+		}
+		p.pos++
+		r, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		l = Or{l, r}
+	}
+}
+
+func (p *parser) and() (Expr, error) {
+	l, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t, ok := p.peek()
+		if !ok || t.kind == tokRParen || isKeyword(t, "or") {
+			return l, nil
+		}
+		if isKeyword(t, "and") {
+			p.pos++
+		}
+		r, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		l = And{l, r}
+	}
+}
+
+func (p *parser) unary() (Expr, error) {
+	t, ok := p.peek()
+	if !ok {
+		return nil, p.errorAtEnd("expected a term")
+	}
+	switch {
+	case isKeyword(t, "not"):
+		p.pos++
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return Not{x}, nil
+	case t.kind == tokLParen:
+		p.pos++
+		e, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if c, ok := p.peek(); !ok || c.kind != tokRParen {
+			return nil, p.errorAt(t, "unclosed '('")
+		}
+		p.pos++
+		return e, nil
+	case t.kind == tokRParen:
+		return nil, p.errorAt(t, "expected a term before ')'")
+	case isKeyword(t, "and") || isKeyword(t, "or"):
+		return nil, p.errorAt(t, "expected a term before '%s'", t.text)
+	}
+	p.pos++
+	return p.term(t)
+}
+
+// term parses a single word, consuming the words of a date phrase after '@'
+func (p *parser) term(t token) (Expr, error) {
+	w := t.text
+	switch {
+	case w[0] == '+' || w[0] == '-':
+		if len(w) == 1 {
+			return nil, p.errorAt(t, "expected a tag name after '%c'", w[0])
+		}
+		if w[0] == '-' {
+			return Not{Tag{w[1:]}}, nil
+		}
+		return Tag{w[1:]}, nil
+	case w[0] == '%':
+		return p.priority(t)
+	case w[0] == '@':
+		return p.time(t)
+	}
+
+	if pat, ok := strings.CutPrefix(w, "desc~"); ok {
+		if pat == "" {
+			return nil, p.errorAt(t, "expected a regular expression after 'desc~'")
+		}
+		if _, err := regexp.Compile(pat); err != nil {
+			return nil, p.errorAt(t, "invalid regular expression: %v", err)
+		}
+		return Desc{pat}, nil
+	}
+
+	name, val, ok := strings.Cut(w, ":")
+	if !ok || name == "" {
+		return nil, p.errorAt(t, "unknown term, expected +tag, -tag, %%priority, @ date, status:, desc~, has: or name:value")
+	}
+	switch strings.ToLower(name) {
+	case "status":
+		switch strings.ToLower(val) {
+		case "open", "pending":
+			return Status{false}, nil
+		case "done", "finished", "completed":
+			return Status{true}, nil
+		}
+		return nil, p.errorAt(t, "unknown status, expected open or done")
+	case "has":
+		for _, f := range hasFields {
+			if strings.EqualFold(val, f) {
+				return Has{f}, nil
+			}
+		}
+		return nil, p.errorAt(t, "unknown field, expected one of %s", strings.Join(hasFields, ", "))
+	}
+	if val == "" {
+		return nil, p.errorAt(t, "expected a value after '%s:'", name)
+	}
+	v, err := p.r.Attr(name, val)
+	if err != nil {
+		return nil, p.errorAt(t, "%v", err)
+	}
+	return Attr{name, v}, nil
+}
+
+var priorityOps = []Op{OpGe, OpLe, OpNe, OpGt, OpLt, OpEq}
+
+func (p *parser) priority(t token) (Expr, error) {
+	s := t.text[1:]
+	op := OpEq
+	for _, o := range priorityOps {
+		if rest, ok := strings.CutPrefix(s, string(o)); ok {
+			op, s = o, rest
+			break
+		}
+	}
+	if s == "" {
+		return nil, p.errorAt(t, "expected a priority after '%%%s'", strings.TrimPrefix(string(op), "="))
+	}
+	v, err := p.r.Priority(s)
+	if err != nil {
+		return nil, p.errorAt(t, "%v", err)
+	}
+	return Priority{op, v}, nil
+}
+
+// time parses '@ [before|on|by|after] date'; the '@' may be attached to the next word
+func (p *parser) time(t token) (Expr, error) {
+	var words []token
+	if len(t.text) > 1 {
+		words = append(words, token{tokWord, t.text[1:], t.arg})
+	}
+	for i := p.pos; i < len(p.toks) && p.toks[i].kind == tokWord; i++ {
+		words = append(words, p.toks[i])
+	}
+	attached := len(t.text) > 1
+
+	when := On
+	if len(words) > 0 {
+		for _, w := range []When{Before, On, By, After} {
+			if strings.EqualFold(words[0].text, string(w)) {
+				when = w
+				words = words[1:]
+				p.consume(&attached)
+				break
+			}
+		}
+	}
+	if len(words) == 0 {
+		return nil, p.errorAt(t, "expected a date after '@'")
+	}
+	texts := make([]string, len(words))
+	for i, w := range words {
+		texts[i] = w.text
+	}
+	d, n, err := p.r.Date(texts)
+	if err != nil {
+		return nil, p.errorAt(words[0], "expected a date after '@ %s'", when)
+	}
+	for i := 0; i < n; i++ {
+		p.consume(&attached)
+	}
+	return Time{when, d}, nil
+}
+
+// consume skips a word following '@', unless it was attached to the '@' token itself
+func (p *parser) consume(attached *bool) {
+	if *attached {
+		*attached = false
+		return
+	}
+	p.pos++
+}
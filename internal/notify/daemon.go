@@ -0,0 +1,91 @@
+package notify
+
+import (
+	"context"
+	"log"
+	"path/filepath"
+	"time"
+
+	"github.com/EvoSched/gotask/internal/service"
+	"github.com/EvoSched/gotask/internal/types"
+	"github.com/fsnotify/fsnotify"
+)
+
+// Daemon fires reminders as they come due. Reminders that came due while the daemon
+// wasn't running fire on the first check after it starts.
+type Daemon struct {
+	repo      *service.TaskRepo
+	notifiers []Notifier
+	interval  time.Duration
+	database  string // database file watched for changes
+}
+
+func NewDaemon(repo *service.TaskRepo, notifiers []Notifier, interval time.Duration, database string) *Daemon {
+	return &Daemon{repo, notifiers, interval, database}
+}
+
+// Run checks for due reminders every interval and whenever the database changes, until ctx is done
+func (d *Daemon) Run(ctx context.Context) error {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+	// sqlite replaces journal files next to the database, so watch the directory
+	abs, err := filepath.Abs(d.database)
+	if err != nil {
+		return err
+	}
+	if err := w.Add(filepath.Dir(abs)); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+	for {
+		if err := d.Check(time.Now()); err != nil {
+			log.Println(err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		case ev := <-w.Events:
+			if filepath.Base(ev.Name) != filepath.Base(abs) {
+				continue
+			}
+		case err := <-w.Errors:
+			log.Println(err)
+		}
+	}
+}
+
+// Check fires every pending reminder due at or before now. Reminders are stored as wall-clock
+// times, so they're compared with the time of day now shows in its own zone.
+func (d *Daemon) Check(now time.Time) error {
+	now = types.WallClock(now)
+	reminders, err := d.repo.GetPendingReminders()
+	if err != nil {
+		return err
+	}
+	for _, r := range reminders {
+		t, err := d.repo.GetTask(r.TaskID)
+		if err != nil {
+			return err
+		}
+		due := r.Due(t)
+		if due == nil || due.After(now) {
+			continue
+		}
+		n := Notification{Task: t, Due: *due}
+		for _, nt := range d.notifiers {
+			if err := nt.Notify(n); err != nil {
+				log.Printf("notifier failed for task %d: %v", t.ShortID, err)
+			}
+		}
+		if err := d.repo.MarkReminderFired(r.ID, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package notify
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/EvoSched/gotask/internal/config"
+	"github.com/EvoSched/gotask/internal/service"
+	"github.com/EvoSched/gotask/internal/sqlite"
+	"github.com/EvoSched/gotask/internal/types"
+)
+
+type recorder struct{ fired []Notification }
+
+func (r *recorder) Notify(n Notification) error {
+	r.fired = append(r.fired, n)
+	return nil
+}
+
+func TestCheckWallClock(t *testing.T) {
+	db, err := sqlite.NewSQLite(&config.SQLite{Database: filepath.Join(t.TempDir(), "t.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	repo := service.NewTaskRepo(db)
+
+	// '@ 5pm remind:-30m', stored as wall-clock times in UTC
+	start := time.Date(2024, 10, 14, 17, 0, 0, 0, time.UTC)
+	offset := -30 * time.Minute
+	task := types.NewTask("Call Sam", 3, nil, nil, &start, nil)
+	task.Reminders = []types.Reminder{{Offset: &offset}}
+	if _, err := repo.AddTask(task); err != nil {
+		t.Fatal(err)
+	}
+
+	// in UTC+2, the reminder is due at 16:30 on the clock, which is 14:30 UTC
+	zone := time.FixedZone("UTC+2", 2*60*60)
+	rec := &recorder{}
+	d := NewDaemon(repo, []Notifier{rec}, time.Minute, "")
+	for _, tt := range []struct {
+		clock string
+		fired int
+	}{
+		{"16:29", 0},
+		{"16:30", 1},
+		{"16:45", 1}, // fires once
+	} {
+		c, _ := time.Parse("15:04", tt.clock)
+		now := time.Date(2024, 10, 14, c.Hour(), c.Minute(), 0, 0, zone)
+		if err := d.Check(now); err != nil {
+			t.Fatal(err)
+		}
+		if len(rec.fired) != tt.fired {
+			t.Errorf("Check at %s local fired %d reminder(s), want %d", tt.clock, len(rec.fired), tt.fired)
+		}
+	}
+}
+
+func TestCheckMovedTask(t *testing.T) {
+	db, err := sqlite.NewSQLite(&config.SQLite{Database: filepath.Join(t.TempDir(), "t.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	repo := service.NewTaskRepo(db)
+
+	start := time.Date(2024, 10, 14, 17, 0, 0, 0, time.UTC)
+	offset := -30 * time.Minute
+	task := types.NewTask("Call Sam", 3, nil, nil, &start, nil)
+	task.Reminders = []types.Reminder{{Offset: &offset}}
+	if _, err := repo.AddTask(task); err != nil {
+		t.Fatal(err)
+	}
+	rec := &recorder{}
+	d := NewDaemon(repo, []Notifier{rec}, time.Minute, "")
+	if err := d.Check(start); err != nil {
+		t.Fatal(err)
+	}
+
+	// moving the task a day later re-arms the reminder that fired for the old time
+	task, err = repo.GetTask(task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	moved := start.AddDate(0, 0, 1)
+	task.StartAt = &moved
+	if err := repo.UpdateTask(task); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		now   time.Time
+		fired int
+	}{
+		{start.Add(time.Hour), 1},
+		{moved.Add(-31 * time.Minute), 1},
+		{moved.Add(-30 * time.Minute), 2},
+	} {
+		if err := d.Check(tt.now); err != nil {
+			t.Fatal(err)
+		}
+		if len(rec.fired) != tt.fired {
+			t.Errorf("Check at %s fired %d reminder(s), want %d", tt.now, len(rec.fired), tt.fired)
+		}
+	}
+}
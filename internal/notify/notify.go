@@ -0,0 +1,119 @@
+// Package notify delivers task reminders through pluggable notifiers
+package notify
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/EvoSched/gotask/internal/config"
+	"github.com/EvoSched/gotask/internal/types"
+)
+
+// FormatTime formats task times in notifications
+var FormatTime = func(t time.Time) string {
+	return t.Format("Mon, 02 Jan 2006 03:04pm")
+}
+
+// Notification is a reminder that has come due
+type Notification struct {
+	Task *types.Task
+	Due  time.Time
+}
+
+func (n Notification) Title() string {
+	return fmt.Sprintf("Task %d", n.Task.ShortID)
+}
+
+func (n Notification) Body() string {
+	if n.Task.StartAt != nil {
+		return fmt.Sprintf("%s (%s)", n.Task.Desc, FormatTime(*n.Task.StartAt))
+	}
+	return n.Task.Desc
+}
+
+// Notifier delivers notifications to the user
+type Notifier interface {
+	Notify(n Notification) error
+}
+
+// New creates the notifier described by cfg
+func New(cfg config.Notifier) (Notifier, error) {
+	switch cfg.Type {
+	case config.NotifierNotifySend:
+		return notifySend{}, nil
+	case config.NotifierBell:
+		return bell{os.Stdout}, nil
+	case config.NotifierFile:
+		return file{expandHome(cfg.Path)}, nil
+	case config.NotifierExec:
+		return execHook{cfg.Command, cfg.Args}, nil
+	default:
+		return nil, fmt.Errorf("invalid notifier type: %s", cfg.Type)
+	}
+}
+
+// notifySend shows a desktop notification through notify-send
+type notifySend struct{}
+
+func (notifySend) Notify(n Notification) error {
+	return exec.Command("notify-send", "--app-name=gotask", n.Title(), n.Body()).Run()
+}
+
+// bell rings the terminal bell and prints the reminder
+type bell struct {
+	w io.Writer
+}
+
+func (b bell) Notify(n Notification) error {
+	_, err := fmt.Fprintf(b.w, "\a%s: %s\n", n.Title(), n.Body())
+	return err
+}
+
+// file appends a line per reminder to a log file
+type file struct {
+	path string
+}
+
+func (f file) Notify(n Notification) error {
+	fd, err := os.OpenFile(f.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+	_, err = fmt.Fprintf(fd, "%s\t%s\t%s\n", time.Now().Format(time.RFC3339), n.Title(), n.Body())
+	return err
+}
+
+// execHook runs a command with the reminder passed in GT_* environment variables
+type execHook struct {
+	command string
+	args    []string
+}
+
+func (e execHook) Notify(n Notification) error {
+	cmd := exec.Command(e.command, e.args...)
+	cmd.Env = append(os.Environ(),
+		"GT_TASK_ID="+strconv.Itoa(n.Task.ShortID),
+		"GT_TASK_UUID="+n.Task.UUID,
+		"GT_TASK_DESC="+n.Task.Desc,
+		"GT_REMINDER_DUE="+types.LocalTime(n.Due).Format(time.RFC3339),
+	)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+func expandHome(p string) string {
+	if rest, ok := strings.CutPrefix(p, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return p
+}
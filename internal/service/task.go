@@ -2,17 +2,29 @@ package service
 
 import (
 	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/EvoSched/gotask/internal/config"
+	"github.com/EvoSched/gotask/internal/filter"
 	"github.com/EvoSched/gotask/internal/sqlite"
 	"github.com/EvoSched/gotask/internal/types"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 )
 
+// minUUIDPrefix is the shortest UUID prefix accepted as a task reference
+const minUUIDPrefix = 4
+
 type TaskRepoQuery interface {
 	GetTask(id int) (*types.Task, error)
 	GetTasks() ([]*types.Task, error)
 	GetDesc(id int) (string, error)
 	GetTasksDue() ([]*types.Task, error)
 	GetTasksArchived() ([]*types.Task, error)
+	FindTasks(f filter.Expr) ([]*types.Task, error)
 }
 
 type TaskRepoStmt interface {
@@ -24,11 +36,195 @@ type TaskRepoStmt interface {
 }
 
 type TaskRepo struct {
-	db *sql.DB
+	db   sqlite.DB
+	conn *sql.DB // nil inside a transaction
 }
 
 func NewTaskRepo(db *sql.DB) *TaskRepo {
-	return &TaskRepo{db}
+	return &TaskRepo{db, db}
+}
+
+// Transaction runs fn with a repo whose changes are committed together when fn returns nil,
+// and discarded otherwise. Calls nested in a transaction join it.
+func (r *TaskRepo) Transaction(fn func(tx *TaskRepo) error) error {
+	if r.conn == nil {
+		return fn(r)
+	}
+	tx, err := r.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := fn(&TaskRepo{db: tx}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ErrNoTask is returned when a task reference matches no task
+var ErrNoTask = errors.New("no task")
+
+// ResolveID returns the id of the task referenced by ref, which is either
+// a short id (e.g. "12"), a prefix of the task's UUID (e.g. "3f2a9c") or
+// "last" for the most recently added task. A number is also tried as a
+// UUID prefix when no task has it as its short id, and is ambiguous when
+// it is both.
+func (r *TaskRepo) ResolveID(ref string) (int, error) {
+	if n, err := strconv.Atoi(ref); err == nil {
+		id, err := sqlite.QueryIDByShortID(r.db, n)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return 0, err
+		}
+		var ids []int
+		if IsUUIDPrefix(ref) {
+			if ids, err = sqlite.QueryIDsByUUID(r.db, ref); err != nil {
+				return 0, err
+			}
+		}
+		switch {
+		case id != 0 && (len(ids) == 0 || len(ids) == 1 && ids[0] == id):
+			return id, nil
+		case id != 0:
+			return 0, fmt.Errorf("%s is both the ID of a task and a UUID prefix of %d task(s), use more of a UUID", ref, len(ids))
+		case len(ids) == 0:
+			return 0, fmt.Errorf("%w with ID %d", ErrNoTask, n)
+		}
+	}
+	if ref == "last" {
+		id, err := sqlite.QueryLastID(r.db)
+		if err == nil && id == 0 {
+			return 0, fmt.Errorf("%w to reference as last", ErrNoTask)
+		}
+		return id, err
+	}
+	if !IsUUIDPrefix(ref) {
+		return 0, fmt.Errorf("invalid task reference: %s", ref)
+	}
+	ids, err := sqlite.QueryIDsByUUID(r.db, ref)
+	if err != nil {
+		return 0, err
+	}
+	switch len(ids) {
+	case 0:
+		return 0, fmt.Errorf("%w with UUID prefix %s", ErrNoTask, ref)
+	case 1:
+		return ids[0], nil
+	default:
+		return 0, fmt.Errorf("UUID prefix %s matches %d tasks", ref, len(ids))
+	}
+}
+
+// MatchDesc returns the tasks whose description contains every word of query, ignoring case.
+// Without such a task, descriptions holding the letters of query in order are matched instead,
+// so "rdme" finds "Update README". Open tasks come first.
+func (r *TaskRepo) MatchDesc(query string) ([]*types.Task, error) {
+	tasks, err := sqlite.QueryTasks(r.db)
+	if err != nil {
+		return nil, err
+	}
+	query = strings.ToLower(query)
+	words := strings.Fields(query)
+	var found []*types.Task
+	for _, t := range tasks {
+		if containsAll(strings.ToLower(t.Desc), words) {
+			found = append(found, t)
+		}
+	}
+	if len(found) == 0 {
+		letters := strings.Join(words, "")
+		for _, t := range tasks {
+			if isSubsequence(letters, strings.ToLower(t.Desc)) {
+				found = append(found, t)
+			}
+		}
+	}
+	sort.SliceStable(found, func(i, j int) bool { return !found[i].Finished && found[j].Finished })
+	return found, nil
+}
+
+// containsAll reports whether s contains each of words
+func containsAll(s string, words []string) bool {
+	for _, w := range words {
+		if !strings.Contains(s, w) {
+			return false
+		}
+	}
+	return true
+}
+
+// isSubsequence reports whether the runes of sub appear in s in order
+func isSubsequence(sub, s string) bool {
+	rs := []rune(sub)
+	if len(rs) == 0 {
+		return false
+	}
+	for _, c := range s {
+		if c == rs[0] {
+			rs = rs[1:]
+			if len(rs) == 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IsUUIDPrefix reports whether s could be the beginning of a task UUID
+func IsUUIDPrefix(s string) bool {
+	if len(s) < minUUIDPrefix || len(s) > 36 {
+		return false
+	}
+	return strings.Trim(strings.ToLower(s), "0123456789abcdef-") == ""
+}
+
+// HasUUID reports whether a task with exactly this UUID exists
+func (r *TaskRepo) HasUUID(uuid string) (bool, error) {
+	ids, err := sqlite.QueryIDsByUUID(r.db, uuid)
+	if err != nil {
+		return false, err
+	}
+	for _, id := range ids {
+		t, err := sqlite.QueryTask(r.db, id)
+		if err != nil {
+			return false, err
+		}
+		if t.UUID == strings.ToLower(uuid) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// MigratePriorities converts stored priorities when the configured priority scheme has changed
+// since the last run, keeping each task's relative importance. It returns the number of tasks changed.
+func (r *TaskRepo) MigratePriorities(scheme config.Priority) (int, error) {
+	sig, err := sqlite.QuerySetting(r.db, "priority_scheme")
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return 0, err
+	}
+	if sig == scheme.Signature() {
+		return 0, nil
+	}
+	// without a recorded scheme, the database predates configurable priorities and tasks use
+	// the fixed default scheme
+	old := config.DefaultPriority
+	if sig != "" {
+		old, err = config.ParseSignature(sig)
+		if err != nil {
+			return 0, err
+		}
+	}
+	priorities, err := sqlite.QueryPriorities(r.db)
+	if err != nil {
+		return 0, err
+	}
+	changed := make(map[int]int)
+	for id, p := range priorities {
+		if n := scheme.Convert(old, p); n != p {
+			changed[id] = n
+		}
+	}
+	return len(changed), sqlite.UpdatePriorities(r.conn, changed, scheme.Signature())
 }
 
 func (r *TaskRepo) GetDesc(id int) (string, error) {
@@ -50,6 +246,26 @@ func (r *TaskRepo) GetTask(id int) (*types.Task, error) {
 		return nil, err
 	}
 	t.Tags = append(t.Tags, tags...)
+	t.Attrs, err = sqlite.QueryTaskAttrs(r.db, id)
+	if err != nil {
+		return nil, err
+	}
+	t.Attachments, err = sqlite.QueryTaskAttachments(r.db, id)
+	if err != nil {
+		return nil, err
+	}
+	t.Reminders, err = sqlite.QueryTaskReminders(r.db, id)
+	if err != nil {
+		return nil, err
+	}
+	t.Checklist, err = sqlite.QueryTaskChecklist(r.db, id)
+	if err != nil {
+		return nil, err
+	}
+	t.Links, err = sqlite.QueryTaskLinks(r.db, id)
+	if err != nil {
+		return nil, err
+	}
 	return &t, nil
 }
 
@@ -64,6 +280,14 @@ func (r *TaskRepo) GetTasks() ([]*types.Task, error) {
 			return nil, err
 		}
 		t.Tags = append(t.Tags, tags...)
+		t.Attrs, err = sqlite.QueryTaskAttrs(r.db, t.ID)
+		if err != nil {
+			return nil, err
+		}
+		t.Checklist, err = sqlite.QueryTaskChecklist(r.db, t.ID)
+		if err != nil {
+			return nil, err
+		}
 	}
 	return tasks, nil
 }
@@ -79,6 +303,14 @@ func (r *TaskRepo) GetTasksDue() ([]*types.Task, error) {
 			return nil, err
 		}
 		t.Tags = append(t.Tags, tags...)
+		t.Attrs, err = sqlite.QueryTaskAttrs(r.db, t.ID)
+		if err != nil {
+			return nil, err
+		}
+		t.Checklist, err = sqlite.QueryTaskChecklist(r.db, t.ID)
+		if err != nil {
+			return nil, err
+		}
 	}
 	return tasks, nil
 }
@@ -94,11 +326,47 @@ func (r *TaskRepo) GetTasksArchived() ([]*types.Task, error) {
 			return nil, err
 		}
 		t.Tags = append(t.Tags, tags...)
+		t.Attrs, err = sqlite.QueryTaskAttrs(r.db, t.ID)
+		if err != nil {
+			return nil, err
+		}
+		t.Checklist, err = sqlite.QueryTaskChecklist(r.db, t.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return tasks, nil
+}
+
+// FindTasks returns the tasks matching the filter; a nil filter matches every task
+func (r *TaskRepo) FindTasks(f filter.Expr) ([]*types.Task, error) {
+	tasks, err := sqlite.QueryTasksFilter(r.db, f)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range tasks {
+		tags, err := sqlite.QueryTaskTags(r.db, t.ID)
+		if err != nil {
+			return nil, err
+		}
+		t.Tags = append(t.Tags, tags...)
+		t.Attrs, err = sqlite.QueryTaskAttrs(r.db, t.ID)
+		if err != nil {
+			return nil, err
+		}
+		t.Checklist, err = sqlite.QueryTaskChecklist(r.db, t.ID)
+		if err != nil {
+			return nil, err
+		}
 	}
 	return tasks, nil
 }
 
+// AddTask inserts the task and returns its id; task.ID and task.ShortID are filled in
 func (r *TaskRepo) AddTask(task *types.Task) (int, error) {
+	if task.UUID == "" {
+		task.UUID = types.NewUUID()
+	}
 	err := sqlite.InsertTask(r.db, task)
 	if err != nil {
 		return 0, err
@@ -124,13 +392,131 @@ func (r *TaskRepo) AddTask(task *types.Task) (int, error) {
 			return 0, err
 		}
 	}
-	return sqlite.QueryLastID(r.db)
+	for n, v := range task.Attrs {
+		err = sqlite.InsertAttr(r.db, i, n, v)
+		if err != nil {
+			return 0, err
+		}
+	}
+	for _, rem := range task.Reminders {
+		rem.TaskID = i
+		err = sqlite.InsertReminder(r.db, &rem)
+		if err != nil {
+			return 0, err
+		}
+	}
+	t, err := sqlite.QueryTask(r.db, i)
+	if err != nil {
+		return 0, err
+	}
+	task.ID, task.ShortID = t.ID, t.ShortID
+	return i, nil
 }
 
 func (r *TaskRepo) AddNote(id int, note string) error {
 	return sqlite.InsertNote(r.db, id, note)
 }
 
+func (r *TaskRepo) AddAttachment(a *types.Attachment) error {
+	return sqlite.InsertAttachment(r.db, a)
+}
+
+// GetAttachmentData loads the stored contents of a copied attachment
+func (r *TaskRepo) GetAttachmentData(a *types.Attachment) error {
+	d, err := sqlite.QueryAttachmentData(r.db, a.ID)
+	if err != nil {
+		return err
+	}
+	a.Data = d
+	return nil
+}
+
+func (r *TaskRepo) DeleteAttachment(id int) error {
+	return sqlite.DeleteAttachment(r.db, id)
+}
+
+func (r *TaskRepo) AddReminder(rem *types.Reminder) error {
+	return sqlite.InsertReminder(r.db, rem)
+}
+
+// GetPendingReminders returns reminders that haven't fired yet on unfinished tasks
+func (r *TaskRepo) GetPendingReminders() ([]types.Reminder, error) {
+	return sqlite.QueryPendingReminders(r.db)
+}
+
+func (r *TaskRepo) MarkReminderFired(id int, at time.Time) error {
+	return sqlite.UpdateReminderFired(r.db, id, &at)
+}
+
+func (r *TaskRepo) SnoozeReminder(id int, until time.Time) error {
+	return sqlite.UpdateReminderSnooze(r.db, id, &until)
+}
+
+func (r *TaskRepo) DeleteReminders(taskId int) error {
+	return sqlite.DeleteReminders(r.db, taskId)
+}
+
+// GetTemplate returns a template saved in the database, or sql.ErrNoRows if there is none by that name
+func (r *TaskRepo) GetTemplate(name string) ([]config.TemplateTask, error) {
+	body, err := sqlite.QueryTemplate(r.db, name)
+	if err != nil {
+		return nil, err
+	}
+	var tasks []config.TemplateTask
+	err = json.Unmarshal([]byte(body), &tasks)
+	return tasks, err
+}
+
+func (r *TaskRepo) GetTemplateNames() ([]string, error) {
+	return sqlite.QueryTemplateNames(r.db)
+}
+
+func (r *TaskRepo) SaveTemplate(name string, tasks []config.TemplateTask) error {
+	b, err := json.Marshal(tasks)
+	if err != nil {
+		return err
+	}
+	return sqlite.UpsertTemplate(r.db, name, string(b))
+}
+
+func (r *TaskRepo) DeleteTemplate(name string) error {
+	return sqlite.DeleteTemplate(r.db, name)
+}
+
+func (r *TaskRepo) AddCheckItem(c *types.CheckItem) error {
+	return sqlite.InsertCheckItem(r.db, c)
+}
+
+func (r *TaskRepo) UpdateCheckItem(id int, done bool) error {
+	return sqlite.UpdateCheckItem(r.db, id, done)
+}
+
+func (r *TaskRepo) DeleteCheckItem(id int) error {
+	return sqlite.DeleteCheckItem(r.db, id)
+}
+
+func (r *TaskRepo) AddLink(l *types.Link) error {
+	return sqlite.InsertLink(r.db, l)
+}
+
+// DeleteLink removes the links between two tasks, returning how many were removed
+func (r *TaskRepo) DeleteLink(a, b int) (int64, error) {
+	return sqlite.DeleteLink(r.db, a, b)
+}
+
+// BlockedIDs returns the open tasks waiting on another, those following up on a task still open
+func (r *TaskRepo) BlockedIDs() (map[int]bool, error) {
+	ids, err := sqlite.QueryBlockedIDs(r.db)
+	if err != nil {
+		return nil, err
+	}
+	blocked := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		blocked[id] = true
+	}
+	return blocked, nil
+}
+
 func (r *TaskRepo) UpdateStatus(id int, status bool) error {
 	err := sqlite.UpdateStatus(r.db, id, status)
 	if err != nil {
@@ -145,11 +531,30 @@ func (r *TaskRepo) UpdateStatus(id int, status bool) error {
 	return err
 }
 
+// UpdateTask saves a task's fields, tags and attributes. Moving its start re-arms the reminders
+// relative to it, which may have fired or been snoozed for the old start.
 func (r *TaskRepo) UpdateTask(task *types.Task) error {
-	err := sqlite.UpdateTask(r.db, task)
+	old, err := sqlite.QueryTask(r.db, task.ID)
+	if err != nil {
+		return err
+	}
+	err = sqlite.UpdateTask(r.db, task)
 	if err != nil {
 		return err
 	}
+	moved := (old.StartAt == nil) != (task.StartAt == nil) ||
+		(old.StartAt != nil && !old.StartAt.Equal(*task.StartAt))
+	if moved {
+		err = sqlite.RearmReminders(r.db, task.ID)
+		if err != nil {
+			return err
+		}
+		for i := range task.Reminders {
+			if task.Reminders[i].Offset != nil {
+				task.Reminders[i].FiredAt, task.Reminders[i].SnoozedUntil = nil, nil
+			}
+		}
+	}
 	for _, t := range task.Tags {
 		ti, err := sqlite.QueryTag(r.db, t)
 		if err != nil {
@@ -171,9 +576,45 @@ func (r *TaskRepo) UpdateTask(task *types.Task) error {
 			}
 		}
 	}
+	// attributes are replaced wholesale so removed ones don't linger
+	err = sqlite.DeleteAttrs(r.db, task.ID)
+	if err != nil {
+		return err
+	}
+	for n, v := range task.Attrs {
+		err = sqlite.InsertAttr(r.db, task.ID, n, v)
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// Renumber compacts the short ids of all tasks to 1..n without touching their UUIDs
+func (r *TaskRepo) Renumber() error {
+	return sqlite.Renumber(r.conn)
+}
+
 func (r *TaskRepo) DeleteTask(id int) error {
+	err := sqlite.DeleteAttrs(r.db, id)
+	if err != nil {
+		return err
+	}
+	err = sqlite.DeleteAttachments(r.db, id)
+	if err != nil {
+		return err
+	}
+	err = sqlite.DeleteReminders(r.db, id)
+	if err != nil {
+		return err
+	}
+	err = sqlite.DeleteChecklist(r.db, id)
+	if err != nil {
+		return err
+	}
+	err = sqlite.DeleteLinks(r.db, id)
+	if err != nil {
+		return err
+	}
 	return sqlite.DeleteTask(r.db, id)
 }
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/EvoSched/gotask/internal/config"
+	"github.com/EvoSched/gotask/internal/sqlite"
+	"github.com/EvoSched/gotask/internal/types"
+)
+
+func TestResolveID(t *testing.T) {
+	db, err := sqlite.NewSQLite(&config.SQLite{Database: filepath.Join(t.TempDir(), "t.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := NewTaskRepo(db)
+	var ids []int
+	for _, uuid := range []string{
+		"50000000-0000-4000-8000-000000000000",
+		"20000000-0000-4000-8000-000000000000",
+		"1000abcd-0000-4000-8000-000000000000",
+	} {
+		task := types.NewTask("task", 3, nil, nil, nil, nil)
+		task.UUID = uuid
+		id, err := repo.AddTask(task)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	// the second task is shown as 1000, which the third task's UUID also starts with
+	if _, err := db.Exec(`UPDATE task SET short_id = 1000 WHERE id = ?`, ids[1]); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		ref  string
+		want int
+	}{
+		{"1", ids[0]},
+		{"3", ids[2]},
+		{"5000", ids[0]},
+		{"2000", ids[1]},
+		{"1000a", ids[2]},
+		{"last", ids[2]},
+	}
+	for _, tt := range tests {
+		if got, err := repo.ResolveID(tt.ref); err != nil || got != tt.want {
+			t.Errorf("ResolveID(%q) = %d, %v, want %d", tt.ref, got, err, tt.want)
+		}
+	}
+	if _, err := repo.ResolveID("9999"); !errors.Is(err, ErrNoTask) {
+		t.Errorf("ResolveID(9999) error = %v, want ErrNoTask", err)
+	}
+	if _, err := repo.ResolveID("1000"); err == nil || errors.Is(err, ErrNoTask) {
+		t.Errorf("ResolveID(1000) error = %v, want it to be ambiguous", err)
+	}
+}
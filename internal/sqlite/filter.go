@@ -0,0 +1,121 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/EvoSched/gotask/internal/filter"
+	"github.com/EvoSched/gotask/internal/types"
+	"github.com/mattn/go-sqlite3"
+)
+
+func init() {
+	// the default sqlite3 driver has no REGEXP function, which 'desc~' filters compile to
+	sql.Register(SQLiteDriver, &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			return conn.RegisterFunc("regexp", regexpMatch, true)
+		},
+	})
+}
+
+var regexpCache sync.Map
+
+// regexpMatch implements 'value REGEXP pattern', compiling each pattern once
+func regexpMatch(pattern, value string) (bool, error) {
+	if re, ok := regexpCache.Load(pattern); ok {
+		return re.(*regexp.Regexp).MatchString(value), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return false, err
+	}
+	regexpCache.Store(pattern, re)
+	return re.MatchString(value), nil
+}
+
+// hasQueries are the conditions for each 'has:' field, in terms of the task row t
+var hasQueries = map[string]string{
+	filter.HasNotes:       `EXISTS (SELECT 1 FROM note WHERE note.task_id = t.id)`,
+	filter.HasTags:        `EXISTS (SELECT 1 FROM tag_pair WHERE tag_pair.task_id = t.id)`,
+	filter.HasAttachments: `EXISTS (SELECT 1 FROM attachment WHERE attachment.task_id = t.id)`,
+	filter.HasReminders:   `EXISTS (SELECT 1 FROM reminder WHERE reminder.task_id = t.id)`,
+	filter.HasChecklist:   `EXISTS (SELECT 1 FROM checklist WHERE checklist.task_id = t.id)`,
+	filter.HasLinks:       `EXISTS (SELECT 1 FROM link WHERE link.from_id = t.id OR link.to_id = t.id)`,
+	filter.HasTime:        `t.start_at IS NOT NULL`,
+}
+
+// compileFilter converts a filter to a condition on the task row t and its parameters
+func compileFilter(e filter.Expr) (string, []any, error) {
+	switch e := e.(type) {
+	case filter.And, filter.Or:
+		var l, r filter.Expr
+		op := "AND"
+		if a, ok := e.(filter.And); ok {
+			l, r = a.L, a.R
+		} else {
+			o := e.(filter.Or)
+			l, r, op = o.L, o.R, "OR"
+		}
+		ls, la, err := compileFilter(l)
+		if err != nil {
+			return "", nil, err
+		}
+		rs, ra, err := compileFilter(r)
+		if err != nil {
+			return "", nil, err
+		}
+		return "(" + ls + " " + op + " " + rs + ")", append(la, ra...), nil
+	case filter.Not:
+		s, args, err := compileFilter(e.X)
+		if err != nil {
+			return "", nil, err
+		}
+		return "NOT " + s, args, nil
+	case filter.Tag:
+		return `EXISTS (SELECT 1 FROM tag_pair JOIN tag ON tag.id = tag_pair.tag_id WHERE tag_pair.task_id = t.id AND tag.name = ?)`,
+			[]any{strings.ToUpper(e.Name)}, nil
+	case filter.Priority:
+		return "t.priority " + string(e.Op) + " ?", []any{e.Value}, nil
+	case filter.Time:
+		start := time.Date(e.Day.Year(), e.Day.Month(), e.Day.Day(), 0, 0, 0, 0, time.UTC)
+		end := start.AddDate(0, 0, 1)
+		switch e.When {
+		case filter.Before:
+			return "t.start_at < ?", []any{start}, nil
+		case filter.On:
+			return "(t.start_at >= ? AND t.start_at < ?)", []any{start, end}, nil
+		case filter.By:
+			return "t.start_at < ?", []any{end}, nil
+		case filter.After:
+			return "t.start_at >= ?", []any{end}, nil
+		}
+	case filter.Status:
+		return "t.finished = ?", []any{e.Done}, nil
+	case filter.Desc:
+		return "t.desc REGEXP ?", []any{e.Pattern}, nil
+	case filter.Has:
+		if q, ok := hasQueries[e.Field]; ok {
+			return q, nil, nil
+		}
+	case filter.Attr:
+		return `EXISTS (SELECT 1 FROM attribute WHERE attribute.task_id = t.id AND attribute.name = ? AND attribute.value = ?)`,
+			[]any{e.Name, e.Value}, nil
+	}
+	return "", nil, fmt.Errorf("unsupported filter: %v", e)
+}
+
+// QueryTasksFilter returns the tasks matching the filter; a nil filter matches every task
+func QueryTasksFilter(db DB, f filter.Expr) ([]*types.Task, error) {
+	if f == nil {
+		return QueryTasks(db)
+	}
+	cond, args, err := compileFilter(f)
+	if err != nil {
+		return nil, err
+	}
+	return queryTasks(db, `SELECT `+taskColumns+` FROM task t WHERE `+cond+` ORDER BY short_id`, args...)
+}
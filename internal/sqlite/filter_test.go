@@ -0,0 +1,82 @@
+package sqlite_test
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/EvoSched/gotask/internal/config"
+	"github.com/EvoSched/gotask/internal/filter"
+	"github.com/EvoSched/gotask/internal/service"
+	"github.com/EvoSched/gotask/internal/sqlite"
+	"github.com/EvoSched/gotask/internal/types"
+)
+
+func TestQueryTasksFilter(t *testing.T) {
+	db, err := sqlite.NewSQLite(&config.SQLite{Database: filepath.Join(t.TempDir(), "t.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	repo := service.NewTaskRepo(db)
+	at := func(d, h int) *time.Time {
+		v := time.Date(2024, time.October, d, h, 0, 0, 0, time.UTC)
+		return &v
+	}
+	report := types.NewTask("Write report", 1, []string{"WORK"}, nil, at(14, 9), nil)
+	report.Attrs = map[string]string{"customer": "acme"}
+	plants := types.NewTask("Water plants", 3, []string{"HOME"}, nil, at(15, 18), nil)
+	plants.Finished = true
+	for _, task := range []*types.Task{
+		report,
+		plants,
+		types.NewTask("Plan sprint", 2, []string{"WORK", "URGENT"}, nil, nil, nil),
+		types.NewTask("Read a book", 5, nil, nil, at(16, 23), nil),
+	} {
+		if _, err := repo.AddTask(task); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := repo.AddNote(report.ID, "draft sent"); err != nil {
+		t.Fatal(err)
+	}
+
+	work, home, urgent := filter.Tag{Name: "work"}, filter.Tag{Name: "HOME"}, filter.Tag{Name: "urgent"}
+	tests := []struct {
+		f   filter.Expr
+		ids []int
+	}{
+		{nil, []int{1, 2, 3, 4}},
+		{work, []int{1, 3}},
+		{filter.Not{X: work}, []int{2, 4}},
+		{filter.And{L: work, R: filter.Not{X: urgent}}, []int{1}},
+		{filter.Or{L: home, R: urgent}, []int{2, 3}},
+		{filter.Or{L: filter.And{L: work, R: filter.Priority{Op: filter.OpLt, Value: 2}}, R: home}, []int{1, 2}},
+		{filter.Not{X: filter.Or{L: work, R: home}}, []int{4}},
+		{filter.Priority{Op: filter.OpGe, Value: 3}, []int{2, 4}},
+		{filter.Status{Done: true}, []int{2}},
+		{filter.And{L: filter.Status{Done: false}, R: filter.Has{Field: filter.HasTime}}, []int{1, 4}},
+		{filter.Time{When: filter.Before, Day: *at(15, 12)}, []int{1}},
+		{filter.Time{When: filter.On, Day: *at(15, 0)}, []int{2}},
+		{filter.Time{When: filter.By, Day: *at(15, 0)}, []int{1, 2}},
+		{filter.Time{When: filter.After, Day: *at(15, 23)}, []int{4}},
+		{filter.Desc{Pattern: "^W"}, []int{1, 2}},
+		{filter.Has{Field: filter.HasNotes}, []int{1}},
+		{filter.Attr{Name: "customer", Value: "acme"}, []int{1}},
+	}
+	for _, tt := range tests {
+		tasks, err := sqlite.QueryTasksFilter(db, tt.f)
+		if err != nil {
+			t.Errorf("QueryTasksFilter(%v) error: %v", tt.f, err)
+			continue
+		}
+		var ids []int
+		for _, task := range tasks {
+			ids = append(ids, task.ShortID)
+		}
+		if !slices.Equal(ids, tt.ids) {
+			t.Errorf("QueryTasksFilter(%v) = %v, want %v", tt.f, ids, tt.ids)
+		}
+	}
+}
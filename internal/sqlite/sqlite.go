@@ -4,16 +4,24 @@ import (
 	"database/sql"
 	"github.com/EvoSched/gotask/internal/config"
 	"github.com/EvoSched/gotask/internal/types"
-	_ "github.com/mattn/go-sqlite3" // Import go-sqlite3 library
 	"os"
 	"strings"
 	"time"
 )
 
 const (
-	SQLiteDriver = "sqlite3"
+	// SQLiteDriver is go-sqlite3 registered with the functions filters need, see filter.go
+	SQLiteDriver = "sqlite3_gotask"
 )
 
+// DB runs statements on the database or inside a transaction; *sql.DB and *sql.Tx implement it
+type DB interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+	Prepare(query string) (*sql.Stmt, error)
+}
+
 func NewSQLite(config *config.SQLite) (*sql.DB, error) {
 	err := setupDB(config)
 	if err != nil {
@@ -28,6 +36,11 @@ func NewSQLite(config *config.SQLite) (*sql.DB, error) {
 	if err != nil {
 		return nil, err
 	}
+	// databases created by older versions may lack newer columns
+	err = migrate(db)
+	if err != nil {
+		return nil, err
+	}
 	return db, err
 }
 
@@ -42,9 +55,11 @@ func setupDB(config *config.SQLite) error {
 	return nil
 }
 
-func createTables(db *sql.DB) error {
+func createTables(db DB) error {
 	stmt := `CREATE TABLE IF NOT EXISTS task (
     "id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,		
+	"uuid" TEXT NOT NULL UNIQUE,
+	"short_id" INTEGER NOT NULL,
 	"desc" TEXT NOT NULL,
 	"priority" INTEGER NOT NULL,
 	"start_at" DATETIME,
@@ -68,23 +83,148 @@ CREATE TABLE IF NOT EXISTS tag_pair (
     "tag_id" INTEGER NOT NULL,
     FOREIGN KEY(task_id) REFERENCES task (id),
     FOREIGN KEY(tag_id) REFERENCES tag (id)
+);
+CREATE TABLE IF NOT EXISTS attribute (
+    "id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+    "task_id" INTEGER NOT NULL,
+    "name" TEXT NOT NULL,
+    "value" TEXT NOT NULL,
+    UNIQUE(task_id, name),
+    FOREIGN KEY(task_id) REFERENCES task (id)
+);
+CREATE TABLE IF NOT EXISTS reminder (
+    "id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+    "task_id" INTEGER NOT NULL,
+    "offset_sec" INTEGER,
+    "at" DATETIME,
+    "snoozed_until" DATETIME,
+    "fired_at" DATETIME,
+    CHECK ((offset_sec IS NULL) != (at IS NULL)),
+    FOREIGN KEY(task_id) REFERENCES task (id)
+);
+CREATE TABLE IF NOT EXISTS checklist (
+    "id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+    "task_id" INTEGER NOT NULL,
+    "text" TEXT NOT NULL,
+    "done" INTEGER NOT NULL CHECK (done IN (0,1)),
+    FOREIGN KEY(task_id) REFERENCES task (id)
+);
+CREATE TABLE IF NOT EXISTS link (
+    "id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+    "from_id" INTEGER NOT NULL,
+    "to_id" INTEGER NOT NULL,
+    "kind" TEXT NOT NULL CHECK (kind IN ('relates','duplicates','follows')),
+    CHECK (from_id != to_id),
+    UNIQUE(from_id, to_id, kind),
+    FOREIGN KEY(from_id) REFERENCES task (id),
+    FOREIGN KEY(to_id) REFERENCES task (id)
+);
+CREATE TABLE IF NOT EXISTS template (
+    "name" TEXT NOT NULL PRIMARY KEY,
+    "body" TEXT NOT NULL,
+    "updated_at" DATETIME NOT NULL
+);
+CREATE TABLE IF NOT EXISTS setting (
+    "key" TEXT NOT NULL PRIMARY KEY,
+    "value" TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS attachment (
+    "id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+    "task_id" INTEGER NOT NULL,
+    "kind" TEXT NOT NULL CHECK (kind IN ('url','file','copy')),
+    "name" TEXT NOT NULL,
+    "size" INTEGER NOT NULL,
+    "data" BLOB,
+    "created_at" DATETIME NOT NULL,
+    FOREIGN KEY(task_id) REFERENCES task (id)
 );`
 	_, err := db.Exec(stmt)
 	return err
 }
 
-func QueryTask(db *sql.DB, id int) (types.Task, error) {
-	var task types.Task
-	row := db.QueryRow(`SELECT id, desc, priority, start_at, end_at, updated_at, completed_at, finished FROM task WHERE id = ?`, id)
-	err := row.Scan(&task.ID, &task.Desc, &task.Priority, &task.StartAt, &task.EndAt, &task.UpdatedAt, &task.CompletedAt, &task.Finished)
+// migrate adds columns introduced after a database was created
+func migrate(db DB) error {
+	ok, err := hasColumn(db, "task", "uuid")
+	if err != nil {
+		return err
+	}
+	if !ok {
+		// SQLite can't add a UNIQUE column, so uniqueness is enforced by an index instead
+		_, err = db.Exec(`ALTER TABLE task ADD COLUMN "uuid" TEXT NOT NULL DEFAULT '';
+ALTER TABLE task ADD COLUMN "short_id" INTEGER NOT NULL DEFAULT 0;
+UPDATE task SET short_id = id;`)
+		if err != nil {
+			return err
+		}
+		ids, err := queryIDs(db, `SELECT id FROM task`)
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			_, err = db.Exec(`UPDATE task SET uuid = ? WHERE id = ?`, types.NewUUID(), id)
+			if err != nil {
+				return err
+			}
+		}
+		_, err = db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS task_uuid ON task (uuid)`)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func hasColumn(db DB, table string, column string) (bool, error) {
+	rows, err := db.Query(`SELECT name FROM pragma_table_info(?)`, table)
 	if err != nil {
-		return task, err
+		return false, err
 	}
-	return task, nil
+	defer rows.Close()
+	for rows.Next() {
+		var n string
+		if err := rows.Scan(&n); err != nil {
+			return false, err
+		}
+		if n == column {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
 }
 
-func QueryTasks(db *sql.DB) ([]*types.Task, error) {
-	rows, err := db.Query(`SELECT id, desc, priority, start_at, end_at, updated_at, completed_at, finished FROM task`)
+func queryIDs(db DB, query string, args ...any) ([]int, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// taskColumns lists the task columns in the order scanTask reads them
+const taskColumns = `id, uuid, short_id, desc, priority, start_at, end_at, updated_at, completed_at, finished`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanTask(s scanner) (types.Task, error) {
+	var task types.Task
+	err := s.Scan(&task.ID, &task.UUID, &task.ShortID, &task.Desc, &task.Priority, &task.StartAt, &task.EndAt, &task.UpdatedAt, &task.CompletedAt, &task.Finished)
+	return task, err
+}
+
+func queryTasks(db DB, query string, args ...any) ([]*types.Task, error) {
+	rows, err := db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -92,8 +232,7 @@ func QueryTasks(db *sql.DB) ([]*types.Task, error) {
 
 	var tasks []*types.Task
 	for rows.Next() {
-		var task types.Task
-		err := rows.Scan(&task.ID, &task.Desc, &task.Priority, &task.StartAt, &task.EndAt, &task.UpdatedAt, &task.CompletedAt, &task.Finished)
+		task, err := scanTask(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -102,7 +241,29 @@ func QueryTasks(db *sql.DB) ([]*types.Task, error) {
 	return tasks, nil
 }
 
-func QueryTaskDesc(db *sql.DB, id int) (string, error) {
+func QueryTask(db DB, id int) (types.Task, error) {
+	row := db.QueryRow(`SELECT `+taskColumns+` FROM task WHERE id = ?`, id)
+	return scanTask(row)
+}
+
+func QueryTasks(db DB) ([]*types.Task, error) {
+	return queryTasks(db, `SELECT `+taskColumns+` FROM task ORDER BY short_id`)
+}
+
+// QueryIDByShortID returns the id of the task currently displayed as shortId
+func QueryIDByShortID(db DB, shortId int) (int, error) {
+	var id int
+	row := db.QueryRow(`SELECT id FROM task WHERE short_id = ?`, shortId)
+	err := row.Scan(&id)
+	return id, err
+}
+
+// QueryIDsByUUID returns the ids of all tasks whose UUID starts with prefix
+func QueryIDsByUUID(db DB, prefix string) ([]int, error) {
+	return queryIDs(db, `SELECT id FROM task WHERE uuid LIKE ? || '%'`, strings.ToLower(prefix))
+}
+
+func QueryTaskDesc(db DB, id int) (string, error) {
 	var desc string
 	row := db.QueryRow(`SELECT desc FROM task WHERE id = ?`, id)
 	err := row.Scan(&desc)
@@ -112,7 +273,7 @@ func QueryTaskDesc(db *sql.DB, id int) (string, error) {
 	return desc, nil
 }
 
-func QueryTaskNotes(db *sql.DB, id int) ([]string, error) {
+func QueryTaskNotes(db DB, id int) ([]string, error) {
 	rows, err := db.Query(`SELECT comment FROM note WHERE task_id = ?`, id)
 	if err != nil {
 		return nil, err
@@ -131,7 +292,7 @@ func QueryTaskNotes(db *sql.DB, id int) ([]string, error) {
 	return notes, nil
 }
 
-func QueryTaskTags(db *sql.DB, id int) ([]string, error) {
+func QueryTaskTags(db DB, id int) ([]string, error) {
 	rows, err := db.Query(`SELECT t.name from tag t JOIN tag_pair p on t.id = p.tag_id WHERE p.task_id = ?`, id)
 	if err != nil {
 		return nil, err
@@ -150,7 +311,202 @@ func QueryTaskTags(db *sql.DB, id int) ([]string, error) {
 	return tags, nil
 }
 
-func QueryTag(db *sql.DB, tag string) (int, error) {
+func QueryTaskAttrs(db DB, id int) (map[string]string, error) {
+	rows, err := db.Query(`SELECT name, value FROM attribute WHERE task_id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attrs := make(map[string]string)
+	for rows.Next() {
+		var n, v string
+		err := rows.Scan(&n, &v)
+		if err != nil {
+			return nil, err
+		}
+		attrs[n] = v
+	}
+	return attrs, nil
+}
+
+// QueryTaskAttachments returns the attachments of a task in the order they were added, without their data
+func QueryTaskAttachments(db DB, id int) ([]types.Attachment, error) {
+	rows, err := db.Query(`SELECT id, task_id, kind, name, size, created_at FROM attachment WHERE task_id = ? ORDER BY id`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attachments []types.Attachment
+	for rows.Next() {
+		var a types.Attachment
+		err := rows.Scan(&a.ID, &a.TaskID, &a.Kind, &a.Name, &a.Size, &a.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, a)
+	}
+	return attachments, nil
+}
+
+func QueryAttachmentData(db DB, id int) ([]byte, error) {
+	var data []byte
+	row := db.QueryRow(`SELECT data FROM attachment WHERE id = ?`, id)
+	err := row.Scan(&data)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func queryReminders(db DB, query string, args ...any) ([]types.Reminder, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reminders []types.Reminder
+	for rows.Next() {
+		var r types.Reminder
+		var offset sql.NullInt64
+		err := rows.Scan(&r.ID, &r.TaskID, &offset, &r.At, &r.SnoozedUntil, &r.FiredAt)
+		if err != nil {
+			return nil, err
+		}
+		if offset.Valid {
+			d := time.Duration(offset.Int64) * time.Second
+			r.Offset = &d
+		}
+		reminders = append(reminders, r)
+	}
+	return reminders, nil
+}
+
+func QueryTaskReminders(db DB, id int) ([]types.Reminder, error) {
+	return queryReminders(db, `SELECT id, task_id, offset_sec, at, snoozed_until, fired_at FROM reminder WHERE task_id = ? ORDER BY id`, id)
+}
+
+// QueryPendingReminders returns reminders that haven't fired on tasks that aren't finished
+func QueryPendingReminders(db DB) ([]types.Reminder, error) {
+	return queryReminders(db, `SELECT r.id, r.task_id, r.offset_sec, r.at, r.snoozed_until, r.fired_at FROM reminder r
+JOIN task t ON t.id = r.task_id WHERE r.fired_at IS NULL AND t.finished = 0 ORDER BY r.id`)
+}
+
+func QueryTaskChecklist(db DB, id int) ([]types.CheckItem, error) {
+	rows, err := db.Query(`SELECT id, task_id, text, done FROM checklist WHERE task_id = ? ORDER BY id`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []types.CheckItem
+	for rows.Next() {
+		var c types.CheckItem
+		if err := rows.Scan(&c.ID, &c.TaskID, &c.Text, &c.Done); err != nil {
+			return nil, err
+		}
+		items = append(items, c)
+	}
+	return items, nil
+}
+
+// QueryTaskLinks returns the links from and to a task
+func QueryTaskLinks(db DB, id int) ([]types.Link, error) {
+	rows, err := db.Query(`SELECT id, from_id, to_id, kind FROM link WHERE from_id = ? OR to_id = ? ORDER BY id`, id, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var links []types.Link
+	for rows.Next() {
+		var l types.Link
+		if err := rows.Scan(&l.ID, &l.FromID, &l.ToID, &l.Kind); err != nil {
+			return nil, err
+		}
+		links = append(links, l)
+	}
+	return links, nil
+}
+
+// QueryBlockedIDs returns the open tasks that follow up on a task still open
+func QueryBlockedIDs(db DB) ([]int, error) {
+	rows, err := db.Query(`SELECT DISTINCT link.from_id FROM link
+		JOIN task f ON f.id = link.from_id
+		JOIN task t ON t.id = link.to_id
+		WHERE link.kind = ? AND f.finished = 0 AND t.finished = 0`, types.LinkFollows)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// QueryTemplate returns the JSON body of a saved template
+func QueryTemplate(db DB, name string) (string, error) {
+	var body string
+	row := db.QueryRow(`SELECT body FROM template WHERE name = ?`, name)
+	err := row.Scan(&body)
+	return body, err
+}
+
+func QueryTemplateNames(db DB) ([]string, error) {
+	rows, err := db.Query(`SELECT name FROM template ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var n string
+		if err := rows.Scan(&n); err != nil {
+			return nil, err
+		}
+		names = append(names, n)
+	}
+	return names, nil
+}
+
+// QuerySetting returns a value stored by UpdateSetting, or sql.ErrNoRows if it was never stored
+func QuerySetting(db DB, key string) (string, error) {
+	var v string
+	row := db.QueryRow(`SELECT value FROM setting WHERE key = ?`, key)
+	err := row.Scan(&v)
+	return v, err
+}
+
+// QueryPriorities returns the priority of every task keyed by task id
+func QueryPriorities(db DB) (map[int]int, error) {
+	rows, err := db.Query(`SELECT id, priority FROM task`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	p := make(map[int]int)
+	for rows.Next() {
+		var id, v int
+		if err := rows.Scan(&id, &v); err != nil {
+			return nil, err
+		}
+		p[id] = v
+	}
+	return p, nil
+}
+
+func QueryTag(db DB, tag string) (int, error) {
 	row := db.QueryRow(`SELECT id FROM tag WHERE name = ?`, strings.ToUpper(tag))
 	var tagId int
 	err := row.Scan(&tagId)
@@ -160,13 +516,13 @@ func QueryTag(db *sql.DB, tag string) (int, error) {
 	return tagId, nil
 }
 
-func QueryTagPair(db *sql.DB, taskId int, tagId int) error {
+func QueryTagPair(db DB, taskId int, tagId int) error {
 	row := db.QueryRow(`SELECT id from tag_pair WHERE task_id = ? AND tag_id = ?`, taskId, tagId)
 	var id int
 	return row.Scan(&id)
 }
 
-func QueryLastID(db *sql.DB) (int, error) {
+func QueryLastID(db DB) (int, error) {
 	// Query to find the maximum existing ID
 	row := db.QueryRow(`SELECT COALESCE(MAX(id), 0) FROM task`)
 
@@ -177,36 +533,24 @@ func QueryLastID(db *sql.DB) (int, error) {
 	return id, nil
 }
 
-func QueryTasksArchived(db *sql.DB, archived bool) ([]*types.Task, error) {
-	rows, err := db.Query(`SELECT id, desc, priority, start_at, end_at, updated_at, completed_at, finished FROM task WHERE task.finished = ?`, archived)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var tasks []*types.Task
-	for rows.Next() {
-		var task types.Task
-		err := rows.Scan(&task.ID, &task.Desc, &task.Priority, &task.StartAt, &task.EndAt, &task.UpdatedAt, &task.CompletedAt, &task.Finished)
-		if err != nil {
-			return nil, err
-		}
-		tasks = append(tasks, &task)
-	}
-	return tasks, nil
+func QueryTasksArchived(db DB, archived bool) ([]*types.Task, error) {
+	return queryTasks(db, `SELECT `+taskColumns+` FROM task WHERE task.finished = ? ORDER BY short_id`, archived)
 }
 
-func InsertTask(db *sql.DB, task *types.Task) error {
-	stmt, err := db.Prepare(`INSERT INTO task(desc, priority, start_at, end_at, updated_at, completed_at, finished) VALUES(?, ?, ?, ?, ?, ?, ?)`)
+func InsertTask(db DB, task *types.Task) error {
+	// new tasks take the next free short id after the highest one in use
+	stmt, err := db.Prepare(`INSERT INTO task(uuid, short_id, desc, priority, start_at, end_at, updated_at, completed_at, finished)
+VALUES(?, (SELECT COALESCE(MAX(short_id), 0) + 1 FROM task), ?, ?, ?, ?, ?, ?, ?)`)
 	if err != nil {
 		return err
 	}
 	defer stmt.Close()
 
-	_, err = stmt.Exec(task.Desc, task.Priority, task.StartAt, task.EndAt, task.UpdatedAt, task.CompletedAt, task.Finished)
+	_, err = stmt.Exec(task.UUID, task.Desc, task.Priority, task.StartAt, task.EndAt, task.UpdatedAt, task.CompletedAt, task.Finished)
 	return err
 }
 
-func InsertNote(db *sql.DB, id int, note string) error {
+func InsertNote(db DB, id int, note string) error {
 	stmt, err := db.Prepare(`INSERT INTO note(task_id, comment) VALUES(?, ?)`)
 	if err != nil {
 		return err
@@ -217,7 +561,7 @@ func InsertNote(db *sql.DB, id int, note string) error {
 	return err
 }
 
-func InsertTag(db *sql.DB, name string) error {
+func InsertTag(db DB, name string) error {
 	stmt, err := db.Prepare(`INSERT INTO tag(name) VALUES(?)`)
 	if err != nil {
 		return err
@@ -227,7 +571,7 @@ func InsertTag(db *sql.DB, name string) error {
 	return err
 }
 
-func InsertTagPair(db *sql.DB, taskId int, tagId int) error {
+func InsertTagPair(db DB, taskId int, tagId int) error {
 	stmt, err := db.Prepare(`INSERT INTO tag_pair(task_id, tag_id) VALUES(?, ?)`)
 	if err != nil {
 		return err
@@ -240,7 +584,136 @@ func InsertTagPair(db *sql.DB, taskId int, tagId int) error {
 	return nil
 }
 
-func UpdateStatus(db *sql.DB, id int, status bool) error {
+func InsertAttr(db DB, taskId int, name string, value string) error {
+	stmt, err := db.Prepare(`INSERT INTO attribute(task_id, name, value) VALUES(?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	_, err = stmt.Exec(taskId, name, value)
+	return err
+}
+
+func InsertAttachment(db DB, a *types.Attachment) error {
+	stmt, err := db.Prepare(`INSERT INTO attachment(task_id, kind, name, size, data, created_at) VALUES(?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	_, err = stmt.Exec(a.TaskID, a.Kind, a.Name, a.Size, a.Data, a.CreatedAt)
+	return err
+}
+
+func InsertReminder(db DB, r *types.Reminder) error {
+	stmt, err := db.Prepare(`INSERT INTO reminder(task_id, offset_sec, at) VALUES(?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	var offset *int64
+	if r.Offset != nil {
+		s := int64(r.Offset.Seconds())
+		offset = &s
+	}
+	_, err = stmt.Exec(r.TaskID, offset, r.At)
+	return err
+}
+
+func UpdateReminderFired(db DB, id int, firedAt *time.Time) error {
+	stmt, err := db.Prepare(`UPDATE reminder SET fired_at = ? WHERE id = ?`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	_, err = stmt.Exec(firedAt, id)
+	return err
+}
+
+// UpdateReminderSnooze re-arms a reminder to fire again at until
+func UpdateReminderSnooze(db DB, id int, until *time.Time) error {
+	stmt, err := db.Prepare(`UPDATE reminder SET snoozed_until = ?, fired_at = NULL WHERE id = ?`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	_, err = stmt.Exec(until, id)
+	return err
+}
+
+func InsertCheckItem(db DB, c *types.CheckItem) error {
+	stmt, err := db.Prepare(`INSERT INTO checklist(task_id, text, done) VALUES(?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	_, err = stmt.Exec(c.TaskID, c.Text, c.Done)
+	return err
+}
+
+func UpdateCheckItem(db DB, id int, done bool) error {
+	stmt, err := db.Prepare(`UPDATE checklist SET done = ? WHERE id = ?`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	_, err = stmt.Exec(done, id)
+	return err
+}
+
+// InsertLink records a link between two tasks, ignoring links that already exist
+func InsertLink(db DB, l *types.Link) error {
+	stmt, err := db.Prepare(`INSERT OR IGNORE INTO link(from_id, to_id, kind) VALUES(?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	_, err = stmt.Exec(l.FromID, l.ToID, l.Kind)
+	return err
+}
+
+// UpsertTemplate saves a template, replacing any template with the same name
+func UpsertTemplate(db DB, name string, body string) error {
+	stmt, err := db.Prepare(`INSERT INTO template(name, body, updated_at) VALUES(?, ?, ?)
+ON CONFLICT(name) DO UPDATE SET body = excluded.body, updated_at = excluded.updated_at`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	_, err = stmt.Exec(name, body, time.Now())
+	return err
+}
+
+func UpdateSetting(db DB, key string, value string) error {
+	stmt, err := db.Prepare(`INSERT INTO setting(key, value) VALUES(?, ?) ON CONFLICT(key) DO UPDATE SET value = excluded.value`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	_, err = stmt.Exec(key, value)
+	return err
+}
+
+// UpdatePriorities sets the priority of each task keyed by task id and records the scheme in one transaction
+func UpdatePriorities(db *sql.DB, priorities map[int]int, scheme string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for id, p := range priorities {
+		_, err = tx.Exec(`UPDATE task SET priority = ? WHERE id = ?`, p, id)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = tx.Exec(`INSERT INTO setting(key, value) VALUES('priority_scheme', ?) ON CONFLICT(key) DO UPDATE SET value = excluded.value`, scheme)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func UpdateStatus(db DB, id int, status bool) error {
 	stmt, err := db.Prepare(`UPDATE task SET finished = ? WHERE id = ?`)
 	if err != nil {
 		return err
@@ -251,7 +724,7 @@ func UpdateStatus(db *sql.DB, id int, status bool) error {
 	return err
 }
 
-func UpdateCompletedAt(db *sql.DB, id int, date *time.Time) error {
+func UpdateCompletedAt(db DB, id int, date *time.Time) error {
 	stmt, err := db.Prepare(`UPDATE task SET completed_at = ? WHERE id = ?`)
 	if err != nil {
 		return err
@@ -262,7 +735,7 @@ func UpdateCompletedAt(db *sql.DB, id int, date *time.Time) error {
 	return err
 }
 
-func UpdateTask(db *sql.DB, task *types.Task) error {
+func UpdateTask(db DB, task *types.Task) error {
 	stmt, err := db.Prepare(`UPDATE task SET desc = ?, priority = ?, start_at = ?, end_at = ?, updated_at = ?, completed_at = ?, finished = ? WHERE id = ?`)
 	if err != nil {
 		return err
@@ -272,7 +745,144 @@ func UpdateTask(db *sql.DB, task *types.Task) error {
 	return err
 }
 
-func DeleteTask(db *sql.DB, id int) error {
+func DeleteAttrs(db DB, taskId int) error {
+	stmt, err := db.Prepare(`DELETE FROM attribute WHERE task_id = ?`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	_, err = stmt.Exec(taskId)
+	return err
+}
+
+// Renumber compacts short ids to 1..n, keeping their current order
+func Renumber(db *sql.DB) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	rows, err := tx.Query(`SELECT id FROM task ORDER BY short_id`)
+	if err != nil {
+		return err
+	}
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	for i, id := range ids {
+		_, err = tx.Exec(`UPDATE task SET short_id = ? WHERE id = ?`, i+1, id)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// RearmReminders clears whether a task's reminders relative to its start have fired or been
+// snoozed, so they fire for a new start
+func RearmReminders(db DB, taskId int) error {
+	stmt, err := db.Prepare(`UPDATE reminder SET fired_at = NULL, snoozed_until = NULL WHERE task_id = ? AND offset_sec IS NOT NULL`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	_, err = stmt.Exec(taskId)
+	return err
+}
+
+func DeleteReminders(db DB, taskId int) error {
+	stmt, err := db.Prepare(`DELETE FROM reminder WHERE task_id = ?`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	_, err = stmt.Exec(taskId)
+	return err
+}
+
+func DeleteCheckItem(db DB, id int) error {
+	stmt, err := db.Prepare(`DELETE FROM checklist WHERE id = ?`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	_, err = stmt.Exec(id)
+	return err
+}
+
+func DeleteChecklist(db DB, taskId int) error {
+	stmt, err := db.Prepare(`DELETE FROM checklist WHERE task_id = ?`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	_, err = stmt.Exec(taskId)
+	return err
+}
+
+// DeleteLink removes the links between two tasks in either direction
+func DeleteLink(db DB, a, b int) (int64, error) {
+	stmt, err := db.Prepare(`DELETE FROM link WHERE (from_id = ? AND to_id = ?) OR (from_id = ? AND to_id = ?)`)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+	res, err := stmt.Exec(a, b, b, a)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// DeleteLinks removes every link from or to a task
+func DeleteLinks(db DB, taskId int) error {
+	stmt, err := db.Prepare(`DELETE FROM link WHERE from_id = ? OR to_id = ?`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	_, err = stmt.Exec(taskId, taskId)
+	return err
+}
+
+func DeleteTemplate(db DB, name string) error {
+	stmt, err := db.Prepare(`DELETE FROM template WHERE name = ?`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	_, err = stmt.Exec(name)
+	return err
+}
+
+func DeleteAttachment(db DB, id int) error {
+	stmt, err := db.Prepare(`DELETE FROM attachment WHERE id = ?`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	_, err = stmt.Exec(id)
+	return err
+}
+
+func DeleteAttachments(db DB, taskId int) error {
+	stmt, err := db.Prepare(`DELETE FROM attachment WHERE task_id = ?`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	_, err = stmt.Exec(taskId)
+	return err
+}
+
+func DeleteTask(db DB, id int) error {
 	stmt, err := db.Prepare(`DELETE FROM task WHERE id = ?`)
 	if err != nil {
 		return err
@@ -0,0 +1,21 @@
+package types
+
+import (
+	"time"
+)
+
+const (
+	AttachURL  = "url"  // link to a web resource
+	AttachFile = "file" // reference to a file on the local filesystem
+	AttachCopy = "copy" // file contents copied into the database
+)
+
+type Attachment struct {
+	ID        int
+	TaskID    int
+	Kind      string // url, file or copy
+	Name      string // url, absolute path, or original file name for copies
+	Size      int64
+	Data      []byte // file contents, only loaded for copies when opened
+	CreatedAt *time.Time
+}
@@ -0,0 +1,9 @@
+package types
+
+// CheckItem is a step of a task's checklist
+type CheckItem struct {
+	ID     int
+	TaskID int
+	Text   string
+	Done   bool
+}
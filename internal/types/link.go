@@ -0,0 +1,55 @@
+package types
+
+import "fmt"
+
+// LinkKind is the relation a link records from one task to another
+type LinkKind string
+
+const (
+	LinkRelates    LinkKind = "relates"    // task relates to another
+	LinkDuplicates LinkKind = "duplicates" // task duplicates another
+	LinkFollows    LinkKind = "follows"    // task follows up on another
+)
+
+var LinkKinds = []LinkKind{LinkRelates, LinkDuplicates, LinkFollows}
+
+func ParseLinkKind(s string) (LinkKind, error) {
+	for _, k := range LinkKinds {
+		if string(k) == s {
+			return k, nil
+		}
+	}
+	return "", fmt.Errorf("invalid link type: %s (expected relates, duplicates or follows)", s)
+}
+
+// Link is a typed relation from one task to another
+type Link struct {
+	ID     int
+	FromID int
+	ToID   int
+	Kind   LinkKind
+}
+
+// Outgoing returns the phrase describing the link from its source task
+func (k LinkKind) Outgoing() string {
+	switch k {
+	case LinkDuplicates:
+		return "duplicates"
+	case LinkFollows:
+		return "follows up on"
+	default:
+		return "relates to"
+	}
+}
+
+// Incoming returns the phrase describing the link from its target task
+func (k LinkKind) Incoming() string {
+	switch k {
+	case LinkDuplicates:
+		return "duplicated by"
+	case LinkFollows:
+		return "followed up by"
+	default:
+		return "related to"
+	}
+}
@@ -0,0 +1,31 @@
+package types
+
+import (
+	"time"
+)
+
+// Reminder is either relative to the task's start (Offset) or at a fixed time (At)
+type Reminder struct {
+	ID           int
+	TaskID       int
+	Offset       *time.Duration // e.g. -30m for half an hour before the task starts
+	At           *time.Time
+	SnoozedUntil *time.Time
+	FiredAt      *time.Time
+}
+
+// Due returns when the reminder should fire for task t, or nil if a relative
+// reminder belongs to a task without a start time
+func (r Reminder) Due(t *Task) *time.Time {
+	if r.SnoozedUntil != nil {
+		return r.SnoozedUntil
+	}
+	if r.At != nil {
+		return r.At
+	}
+	if r.Offset != nil && t.StartAt != nil {
+		d := t.StartAt.Add(*r.Offset)
+		return &d
+	}
+	return nil
+}
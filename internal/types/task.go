@@ -1,15 +1,24 @@
 package types
 
 import (
+	"crypto/rand"
+	"fmt"
 	"time"
 )
 
 type Task struct {
 	ID          int
+	UUID        string // stable identifier that survives export, import and renumbering
+	ShortID     int    // working id shown to and typed by the user, compacted by 'gt renumber'
 	Desc        string
 	Priority    int
-	Tags        []string   // tags, tags: string tag1,tag2,tag3
-	Notes       []string   // comment1,comment2,comment3
+	Tags        []string          // tags, tags: string tag1,tag2,tag3
+	Notes       []string          // comment1,comment2,comment3
+	Attrs       map[string]string // user-defined attributes, name: value
+	Attachments []Attachment
+	Reminders   []Reminder
+	Checklist   []CheckItem
+	Links       []Link     // links from and to the task
 	StartAt     *time.Time // timestamp datetime
 	EndAt       *time.Time
 	UpdatedAt   *time.Time
@@ -20,6 +29,7 @@ type Task struct {
 func NewTask(desc string, priority int, tags []string, comments []string, startAt *time.Time, endAt *time.Time) *Task {
 	now := time.Now()
 	return &Task{
+		UUID:      NewUUID(),
 		Desc:      desc,
 		Priority:  priority,
 		Tags:      tags,
@@ -29,3 +39,37 @@ func NewTask(desc string, priority int, tags []string, comments []string, startA
 		UpdatedAt: &now,
 	}
 }
+
+// WallClock returns the date and time of day t shows in its own zone as a UTC time, the form
+// task and reminder times are stored in, so the current time compares with them in any zone
+func WallClock(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
+
+// LocalTime returns the instant a stored wall-clock time stands for in the local zone, the
+// inverse of WallClock
+func LocalTime(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.Local)
+}
+
+// NewUUID returns a random (version 4) UUID
+func NewUUID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// Progress returns the number of checked items and the size of the task's checklist
+func (t *Task) Progress() (int, int) {
+	done := 0
+	for _, c := range t.Checklist {
+		if c.Done {
+			done++
+		}
+	}
+	return done, len(t.Checklist)
+}